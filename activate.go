@@ -22,8 +22,23 @@ const (
 	Activate     ActivationState = "y"
 	Deactivate   ActivationState = "n"
 	AutoActivate ActivationState = "ay"
+	// ActivateExclusive activates a logical volume in a shared volume group with an exclusive lock,
+	// so it cannot be activated on any other host at the same time.
+	ActivateExclusive ActivationState = "ey"
+	// ActivateShared activates a logical volume in a shared volume group with a shared lock,
+	// so it can be activated on multiple hosts at the same time.
+	ActivateShared ActivationState = "sy"
 )
 
+// IsActivating returns true if the state activates logical volumes.
+func (opt ActivationState) IsActivating() bool {
+	switch opt {
+	case Activate, AutoActivate, ActivateExclusive, ActivateShared:
+		return true
+	}
+	return false
+}
+
 func (opt ActivationState) ApplyToLVCreateOptions(opts *LVCreateOptions) {
 	opts.ActivationState = opt
 }
@@ -32,6 +47,14 @@ func (opt ActivationState) ApplyToLVChangeOptions(opts *LVChangeOptions) {
 	opts.ActivationState = opt
 }
 
+func (opt ActivationState) ApplyToVGChangeOptions(opts *VGChangeOptions) {
+	opts.ActivationState = opt
+}
+
+func (opt ActivationState) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.ActivationState = opt
+}
+
 func (opt ActivationState) ApplyToArgs(args Arguments) error {
 	if opt == "" {
 		return nil
@@ -0,0 +1,35 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+// AllVolumeGroups applies a command to all volume groups instead of the given ones.
+type AllVolumeGroups bool
+
+func (opt AllVolumeGroups) ApplyToVGExportOptions(opts *VGExportOptions) {
+	opts.AllVolumeGroups = opt
+}
+
+func (opt AllVolumeGroups) ApplyToVGImportOptions(opts *VGImportOptions) {
+	opts.AllVolumeGroups = opt
+}
+
+func (opt AllVolumeGroups) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--all")
+	}
+	return nil
+}
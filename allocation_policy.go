@@ -34,6 +34,14 @@ const (
 func (opt AllocationPolicy) ApplyToLVCreateOptions(opts *LVCreateOptions) {
 	opts.AllocationPolicy = opt
 }
+func (opt AllocationPolicy) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.AllocationPolicy = opt
+}
+
+func (opt AllocationPolicy) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.AllocationPolicy = opt
+}
+
 func (opt AllocationPolicy) ApplyToLVChangeOptions(opts *LVCreateOptions) {
 	opts.AllocationPolicy = opt
 }
@@ -0,0 +1,508 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultSysfsRoot is the mount point of sysfs that is walked to discover block devices.
+	DefaultSysfsRoot = "/sys"
+	// DefaultDevRoot is the directory containing the block device nodes.
+	DefaultDevRoot = "/dev"
+)
+
+// BlockDevice is a block device as discovered in sysfs.
+type BlockDevice struct {
+	// Name is the kernel name of the device, e.g. sda or sda1.
+	Name string
+	// Path is the path of the device node, e.g. /dev/sda.
+	Path  string
+	Major int64
+	Minor int64
+
+	Size               Size
+	Rotational         bool
+	ReadOnly           bool
+	Removable          bool
+	LogicalSectorSize  int64
+	PhysicalSectorSize int64
+
+	// Parent is the name of the disk a partition belongs to and empty for whole disks.
+	Parent string
+	// Partitions are the names of the partitions of a whole disk.
+	Partitions []string
+	// Holders are the names of the devices stacked on top of the device, e.g. dm-0 or md0.
+	Holders []string
+
+	// DeviceMapperName and DeviceMapperUUID are set for device-mapper devices.
+	DeviceMapperName string
+	DeviceMapperUUID string
+
+	// DeviceIDs are the stable device ids of the device by which lvm2 identifies it in the devices file.
+	// DeviceIDTypeDevname is not included, as it is matched against the Aliases of the device.
+	DeviceIDs map[DeviceIDType]string
+}
+
+// IsPartition returns true if the device is a partition of another block device.
+func (dev *BlockDevice) IsPartition() bool {
+	return dev.Parent != ""
+}
+
+// IsLogicalVolume returns true if the device is the device-mapper device of an lvm2 logical volume.
+func (dev *BlockDevice) IsLogicalVolume() bool {
+	return strings.HasPrefix(dev.DeviceMapperUUID, "LVM-")
+}
+
+// Aliases returns the paths the device is known by, which are the paths device filters are matched against.
+func (dev *BlockDevice) Aliases() []string {
+	aliases := []string{dev.Path}
+	if dev.DeviceMapperName != "" {
+		aliases = append(aliases, filepath.Join(filepath.Dir(dev.Path), "mapper", dev.DeviceMapperName))
+	}
+	return aliases
+}
+
+// MatchesDevicesFileEntry returns true if the device is identified by the devices file entry.
+// Entries with DeviceIDTypeDevname match any alias of the device, other entries match the stable device id
+// of the same type.
+func (dev *BlockDevice) MatchesDevicesFileEntry(entry *DeviceListEntry) bool {
+	if entry.IDType == DeviceIDTypeDevname {
+		return slices.Contains(dev.Aliases(), entry.IDName)
+	}
+	id, ok := dev.DeviceIDs[entry.IDType]
+	return ok && id == entry.IDName
+}
+
+// DevicesFileEntry returns the entry of the devices file that identifies the device or nil if there is none.
+// Entries are matched by their device id first. DEVNAME is only a hint where lvm2 last saw the device,
+// so it is only used for entries whose device id type could not be read for the device.
+func (dev *BlockDevice) DevicesFileEntry(entries DeviceList) *DeviceListEntry {
+	for i := range entries {
+		if dev.MatchesDevicesFileEntry(&entries[i]) {
+			return &entries[i]
+		}
+	}
+	for i := range entries {
+		_, known := dev.DeviceIDs[entries[i].IDType]
+		if !known && entries[i].DevName != "" && slices.Contains(dev.Aliases(), entries[i].DevName) {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// ReadBlockDevices walks sysRoot/block and sysRoot/class/block and returns all block devices
+// including partitions, sorted by name.
+// Device nodes are expected in devRoot.
+func ReadBlockDevices(sysRoot, devRoot string) ([]*BlockDevice, error) {
+	devices := map[string]*BlockDevice{}
+
+	disks, err := os.ReadDir(filepath.Join(sysRoot, "block"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, disk := range disks {
+		dir := filepath.Join(sysRoot, "block", disk.Name())
+		dev, err := readBlockDevice(dir, dir, disk.Name(), "", devRoot)
+		if err != nil {
+			return nil, err
+		}
+		devices[dev.Name] = dev
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			partDir := filepath.Join(dir, entry.Name())
+			if _, err := os.Stat(filepath.Join(partDir, "partition")); err != nil {
+				continue
+			}
+			part, err := readBlockDevice(partDir, dir, entry.Name(), dev.Name, devRoot)
+			if err != nil {
+				return nil, err
+			}
+			dev.Partitions = append(dev.Partitions, part.Name)
+			devices[part.Name] = part
+		}
+	}
+
+	// class/block contains partitions as well, which are only found here if the disk is missing in block.
+	classes, err := os.ReadDir(filepath.Join(sysRoot, "class", "block"))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	for _, class := range classes {
+		if _, ok := devices[class.Name()]; ok {
+			continue
+		}
+		dir := filepath.Join(sysRoot, "class", "block", class.Name())
+		queueDir, parent := dir, ""
+		if _, err := os.Stat(filepath.Join(dir, "partition")); err == nil {
+			resolved, err := filepath.EvalSymlinks(dir)
+			if err != nil {
+				return nil, err
+			}
+			queueDir = filepath.Dir(resolved)
+			parent = filepath.Base(queueDir)
+		}
+		dev, err := readBlockDevice(dir, queueDir, class.Name(), parent, devRoot)
+		if err != nil {
+			return nil, err
+		}
+		if disk, ok := devices[parent]; ok {
+			disk.Partitions = append(disk.Partitions, dev.Name)
+		}
+		devices[dev.Name] = dev
+	}
+
+	result := make([]*BlockDevice, 0, len(devices))
+	for _, dev := range devices {
+		result = append(result, dev)
+	}
+	slices.SortFunc(result, func(a, b *BlockDevice) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result, nil
+}
+
+// readBlockDevice reads the sysfs attributes of a device from dir.
+// The queue attributes are read from queueDir, which is the directory of the disk for partitions.
+func readBlockDevice(dir, queueDir, name, parent, devRoot string) (*BlockDevice, error) {
+	dev := &BlockDevice{Name: name, Path: filepath.Join(devRoot, name), Parent: parent}
+
+	if majorMinor, err := readSysfsString(dir, "dev"); err != nil {
+		return nil, err
+	} else if major, minor, ok := strings.Cut(majorMinor, ":"); ok {
+		if dev.Major, err = strconv.ParseInt(major, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid major number of %s: %w", name, err)
+		}
+		if dev.Minor, err = strconv.ParseInt(minor, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid minor number of %s: %w", name, err)
+		}
+	}
+
+	sectors, err := readSysfsInt(dir, "size")
+	if err != nil {
+		return nil, err
+	}
+	dev.Size = NewSize(float64(sectors*512), UnitBytes)
+
+	for file, field := range map[string]*int64{
+		filepath.Join("queue", "logical_block_size"):  &dev.LogicalSectorSize,
+		filepath.Join("queue", "physical_block_size"): &dev.PhysicalSectorSize,
+	} {
+		if *field, err = readSysfsInt(queueDir, file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	for file, field := range map[string]*bool{
+		filepath.Join("queue", "rotational"): &dev.Rotational,
+		"ro":                                 &dev.ReadOnly,
+		"removable":                          &dev.Removable,
+	} {
+		attrDir := dir
+		if file != "ro" {
+			attrDir = queueDir
+		}
+		value, err := readSysfsInt(attrDir, file)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+		*field = value == 1
+	}
+
+	if holders, err := os.ReadDir(filepath.Join(dir, "holders")); err == nil {
+		for _, holder := range holders {
+			dev.Holders = append(dev.Holders, holder.Name())
+		}
+	}
+
+	if dev.DeviceMapperName, err = readSysfsString(dir, filepath.Join("dm", "name")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	if dev.DeviceMapperUUID, err = readSysfsString(dir, filepath.Join("dm", "uuid")); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if dev.DeviceIDs, err = readDeviceIDs(dir, dev.DeviceMapperUUID); err != nil {
+		return nil, err
+	}
+
+	return dev, nil
+}
+
+// deviceMapperIDTypes are the device id types lvm2 derives from the device-mapper uuid prefix.
+var deviceMapperIDTypes = map[string]DeviceIDType{
+	"mpath-": DeviceIDTypeMPathUUID,
+	"CRYPT-": DeviceIDTypeCryptUUID,
+	"LVM-":   DeviceIDTypeLVMLVUUID,
+}
+
+// readDeviceIDs reads the stable device ids of a device from its sysfs directory the way lvm2 does.
+// Whitespace in ids is replaced with underscores, as the devices file does not allow it.
+func readDeviceIDs(dir, dmUUID string) (map[DeviceIDType]string, error) {
+	ids := map[DeviceIDType]string{}
+	for file, idType := range map[string]DeviceIDType{
+		"wwid":                                DeviceIDTypeSysWWID,
+		filepath.Join("device", "wwid"):       DeviceIDTypeSysWWID,
+		filepath.Join("device", "serial"):     DeviceIDTypeSysSerial,
+		filepath.Join("md", "uuid"):           DeviceIDTypeMDUUID,
+		filepath.Join("loop", "backing_file"): DeviceIDTypeLoopFile,
+	} {
+		value, err := readSysfsString(dir, file)
+		if errors.Is(err, os.ErrNotExist) || value == "" {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		ids[idType] = strings.Join(strings.Fields(value), "_")
+	}
+
+	// The wwid_* types are the wwid of the device split by its designator type, e.g. naa.5000c500a1b2c3d4.
+	if wwid, ok := ids[DeviceIDTypeSysWWID]; ok {
+		for prefix, idType := range map[string]DeviceIDType{
+			"naa.": DeviceIDTypeWWIDNAA,
+			"eui.": DeviceIDTypeWWIDEUI,
+			"t10.": DeviceIDTypeWWIDT10,
+		} {
+			if strings.HasPrefix(wwid, prefix) {
+				ids[idType] = wwid
+			}
+		}
+	}
+
+	for prefix, idType := range deviceMapperIDTypes {
+		if strings.HasPrefix(dmUUID, prefix) {
+			ids[idType] = dmUUID
+		}
+	}
+
+	return ids, nil
+}
+
+func readSysfsString(dir, file string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, file))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readSysfsInt(dir, file string) (int64, error) {
+	value, err := readSysfsString(dir, file)
+	if err != nil {
+		return 0, err
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q in %s: %w", value, filepath.Join(dir, file), err)
+	}
+	return parsed, nil
+}
+
+// Signature is the type of a signature on a block device, named like the TYPE and PTTYPE reported by blkid.
+type Signature string
+
+const (
+	SignatureLVM2      Signature = "LVM2_member"
+	SignatureLinuxRAID Signature = "linux_raid_member"
+	SignatureLUKS      Signature = "crypto_LUKS"
+	SignatureSwap      Signature = "swap"
+	SignatureExt2      Signature = "ext2"
+	SignatureExt3      Signature = "ext3"
+	SignatureExt4      Signature = "ext4"
+	SignatureXFS       Signature = "xfs"
+	SignatureBtrfs     Signature = "btrfs"
+	SignatureVFAT      Signature = "vfat"
+	SignatureISO9660   Signature = "iso9660"
+	SignatureGPT       Signature = "gpt"
+	SignatureDOS       Signature = "dos"
+)
+
+// signatureProbeSize covers all signatures found at the start of a device, the farthest being the btrfs superblock.
+const signatureProbeSize = 68 * 1024
+
+// ProbeSignaturesFromPath opens the device at path and probes its signatures with ProbeSignatures.
+func ProbeSignaturesFromPath(path string) ([]Signature, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	size, err := fileSize(f)
+	if err != nil {
+		return nil, err
+	}
+	return ProbeSignatures(f, size)
+}
+
+// deviceSize returns the size in bytes of the device or image at path.
+func deviceSize(path string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return fileSize(f)
+}
+
+// fileSize returns the size of a regular file or the size of a block device, which is not reported by stat.
+func fileSize(f *os.File) (int64, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	if stat.Mode().IsRegular() {
+		return stat.Size(), nil
+	}
+	return f.Seek(0, io.SeekEnd)
+}
+
+// ProbeSignatures returns the filesystem, raid, crypto, lvm2 and partition table signatures of a device
+// with the given size in bytes. The size is needed for md superblocks stored at the end of the device.
+func ProbeSignatures(r io.ReaderAt, size int64) ([]Signature, error) {
+	buf := make([]byte, signatureProbeSize)
+	n, err := r.ReadAt(buf, 0)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	buf = buf[:n]
+
+	var signatures []Signature
+	at := func(offset int, magic string) bool {
+		return offset+len(magic) <= len(buf) && string(buf[offset:offset+len(magic)]) == magic
+	}
+
+	if ok, err := ReadLVM2Label(io.NewSectionReader(r, 0, int64(n))); err != nil {
+		return nil, err
+	} else if ok {
+		signatures = append(signatures, SignatureLVM2)
+	}
+	if ok, err := hasMDSuperblock(r, buf, size); err != nil {
+		return nil, err
+	} else if ok {
+		signatures = append(signatures, SignatureLinuxRAID)
+	}
+	if at(0, "LUKS\xba\xbe") {
+		signatures = append(signatures, SignatureLUKS)
+	}
+	for _, pageSize := range []int{4096, 8192, 16384, 65536} {
+		if at(pageSize-10, "SWAPSPACE2") || at(pageSize-10, "SWAP-SPACE") {
+			signatures = append(signatures, SignatureSwap)
+			break
+		}
+	}
+	if ext := extSignature(buf); ext != "" {
+		signatures = append(signatures, ext)
+	}
+	if at(0, "XFSB") {
+		signatures = append(signatures, SignatureXFS)
+	}
+	if at(65536+64, "_BHRfS_M") {
+		signatures = append(signatures, SignatureBtrfs)
+	}
+	vfat := at(82, "FAT32   ") || at(54, "FAT12   ") || at(54, "FAT16   ")
+	if vfat {
+		signatures = append(signatures, SignatureVFAT)
+	}
+	if at(32769, "CD001") {
+		signatures = append(signatures, SignatureISO9660)
+	}
+	if at(512, "EFI PART") || at(4096, "EFI PART") {
+		signatures = append(signatures, SignatureGPT)
+	} else if !vfat && at(510, "\x55\xaa") {
+		signatures = append(signatures, SignatureDOS)
+	}
+	return signatures, nil
+}
+
+// extSignature distinguishes ext2, ext3 and ext4 by the feature flags of the superblock at 1024.
+func extSignature(buf []byte) Signature {
+	const (
+		superblock           = 1024
+		magic                = 0xef53
+		compatHasJournal     = 0x4
+		incompatExtents      = 0x40
+		incompat64Bit        = 0x80
+		incompatFlexBG       = 0x200
+		roCompatHugeFile     = 0x8
+		roCompatDirNlink     = 0x20
+		roCompatExtraIsize   = 0x40
+		roCompatMetadataCsum = 0x400
+	)
+	if len(buf) < superblock+0x68 || binary.LittleEndian.Uint16(buf[superblock+0x38:]) != magic {
+		return ""
+	}
+	compat := binary.LittleEndian.Uint32(buf[superblock+0x5c:])
+	incompat := binary.LittleEndian.Uint32(buf[superblock+0x60:])
+	roCompat := binary.LittleEndian.Uint32(buf[superblock+0x64:])
+	switch {
+	case incompat&(incompatExtents|incompat64Bit|incompatFlexBG) != 0,
+		roCompat&(roCompatHugeFile|roCompatDirNlink|roCompatExtraIsize|roCompatMetadataCsum) != 0:
+		return SignatureExt4
+	case compat&compatHasJournal != 0:
+		return SignatureExt3
+	default:
+		return SignatureExt2
+	}
+}
+
+// hasMDSuperblock checks the locations of md superblocks: 1.1 at the start, 1.2 at 4KiB,
+// 1.0 at 8KiB before the end and 0.90 in the last 64KiB aligned block.
+func hasMDSuperblock(r io.ReaderAt, start []byte, size int64) (bool, error) {
+	const magic = 0xa92b4efc
+	offsets := []int64{0, 4096}
+	if size > 0 {
+		if size >= 8192 {
+			offsets = append(offsets, (size-8192)&^4095)
+		}
+		if size >= 65536 {
+			offsets = append(offsets, (size&^65535)-65536)
+		}
+	}
+	buf := make([]byte, 4)
+	for _, offset := range offsets {
+		if offset+4 <= int64(len(start)) {
+			copy(buf, start[offset:])
+		} else if _, err := r.ReadAt(buf, offset); err != nil {
+			if errors.Is(err, io.EOF) {
+				continue
+			}
+			return false, err
+		}
+		if binary.LittleEndian.Uint32(buf) == magic {
+			return true, nil
+		}
+	}
+	return false, nil
+}
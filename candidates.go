@@ -0,0 +1,233 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// MinimumPhysicalVolumeSize is the default devices/pv_min_size, devices smaller than it are ignored by lvm2.
+var MinimumPhysicalVolumeSize = NewSize(2, UnitMiB)
+
+// CandidateExclusionReason is the reason why a block device cannot be used for a new physical volume.
+type CandidateExclusionReason string
+
+const (
+	CandidateExclusionReadOnly       CandidateExclusionReason = "read-only"
+	CandidateExclusionTooSmall       CandidateExclusionReason = "smaller than the minimum physical volume size"
+	CandidateExclusionPartitioned    CandidateExclusionReason = "has partitions"
+	CandidateExclusionHolders        CandidateExclusionReason = "in use by other devices"
+	CandidateExclusionLogicalVolume  CandidateExclusionReason = "is an lvm2 logical volume"
+	CandidateExclusionSignature      CandidateExclusionReason = "has an existing signature"
+	CandidateExclusionProbeFailed    CandidateExclusionReason = "signatures could not be probed"
+	CandidateExclusionFilter         CandidateExclusionReason = "rejected by devices/filter"
+	CandidateExclusionGlobalFilter   CandidateExclusionReason = "rejected by devices/global_filter"
+	CandidateExclusionInvalidFilters CandidateExclusionReason = "device filters could not be evaluated"
+)
+
+// CandidateExclusion explains why a block device was excluded.
+type CandidateExclusion struct {
+	Reason CandidateExclusionReason
+	// Detail names what triggered the exclusion, e.g. the holders or signatures.
+	Detail string
+}
+
+func (e CandidateExclusion) String() string {
+	if e.Detail == "" {
+		return string(e.Reason)
+	}
+	return fmt.Sprintf("%s (%s)", e.Reason, e.Detail)
+}
+
+// Candidate is a block device evaluated as a target for PVCreate.
+type Candidate struct {
+	*BlockDevice
+
+	// Signatures are the signatures found on the device.
+	Signatures []Signature
+
+	// PassesFilter is true if the device is accepted by devices/global_filter and, unless the devices file
+	// is in use, devices/filter.
+	PassesFilter bool
+
+	// UsesDevicesFile is true if lvm2 is configured to use a devices file.
+	// InDevicesFile is true if the device has an entry in it, which is given in DevicesFileEntry.
+	// A device that is not in the devices file is not excluded, as PVCreate adds it.
+	UsesDevicesFile  bool
+	InDevicesFile    bool
+	DevicesFileEntry *DeviceListEntry
+
+	// Exclusions are the reasons why the device cannot be used, it is a candidate if there are none.
+	Exclusions []CandidateExclusion
+}
+
+// IsCandidate returns true if the device can be used for a new physical volume.
+func (c *Candidate) IsCandidate() bool {
+	return len(c.Exclusions) == 0
+}
+
+// applyFilter excludes the device with the reason if the filter rejects it and returns whether it was accepted.
+func (c *Candidate) applyFilter(filter DeviceFilter, reason CandidateExclusionReason) bool {
+	accepted, err := filter.Accepts(c.Aliases()...)
+	if err != nil {
+		c.exclude(CandidateExclusionInvalidFilters, err.Error())
+		return false
+	}
+	if !accepted {
+		c.exclude(reason, strings.Join(filter, ", "))
+	}
+	return accepted
+}
+
+func (c *Candidate) exclude(reason CandidateExclusionReason, detail string) {
+	c.Exclusions = append(c.Exclusions, CandidateExclusion{Reason: reason, Detail: detail})
+}
+
+type (
+	// SysfsRoot is the sysfs mount point that is walked to discover block devices, DefaultSysfsRoot if unset.
+	SysfsRoot string
+	// DevRoot is the directory of the device nodes, DefaultDevRoot if unset.
+	DevRoot string
+
+	CandidatesOptions struct {
+		SysfsRoot
+		DevRoot
+		DevicesFile
+
+		// DeviceFilterConfig overrides the configuration that is otherwise read with lvm config.
+		*DeviceFilterConfig
+		// DeviceList overrides the devices file entries that are otherwise read with DevList.
+		DeviceList
+	}
+	CandidatesOption interface {
+		ApplyToCandidatesOptions(opts *CandidatesOptions)
+	}
+)
+
+func (opt SysfsRoot) ApplyToCandidatesOptions(opts *CandidatesOptions) {
+	opts.SysfsRoot = opt
+}
+
+func (opt DevRoot) ApplyToCandidatesOptions(opts *CandidatesOptions) {
+	opts.DevRoot = opt
+}
+
+func (opt DevicesFile) ApplyToCandidatesOptions(opts *CandidatesOptions) {
+	opts.DevicesFile = opt
+}
+
+func (opt *DeviceFilterConfig) ApplyToCandidatesOptions(opts *CandidatesOptions) {
+	opts.DeviceFilterConfig = opt
+}
+
+func (opt DeviceList) ApplyToCandidatesOptions(opts *CandidatesOptions) {
+	opts.DeviceList = opt
+}
+
+func (opts *CandidatesOptions) ApplyToCandidatesOptions(new *CandidatesOptions) {
+	*new = *opts
+}
+
+func (c *client) Candidates(ctx context.Context, opts ...CandidatesOption) ([]*Candidate, error) {
+	options := CandidatesOptions{SysfsRoot: DefaultSysfsRoot, DevRoot: DefaultDevRoot}
+	for _, opt := range opts {
+		opt.ApplyToCandidatesOptions(&options)
+	}
+
+	cfg := options.DeviceFilterConfig
+	if cfg == nil {
+		var err error
+		if cfg, err = c.deviceFilterConfig(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	entries := options.DeviceList
+	if cfg.UseDevicesFile && entries == nil {
+		var err error
+		if entries, err = c.DevList(ctx, options.DevicesFile); err != nil {
+			return nil, fmt.Errorf("failed to read devices file: %w", err)
+		}
+	}
+
+	devices, err := ReadBlockDevices(string(options.SysfsRoot), string(options.DevRoot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover block devices: %w", err)
+	}
+
+	candidates := make([]*Candidate, 0, len(devices))
+	for _, dev := range devices {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, EvaluateCandidate(dev, cfg, entries))
+	}
+	return candidates, nil
+}
+
+// EvaluateCandidate probes the signatures of the device and evaluates it against the
+// device filter configuration and the entries of the devices file.
+func EvaluateCandidate(dev *BlockDevice, cfg *DeviceFilterConfig, entries DeviceList) *Candidate {
+	if cfg == nil {
+		cfg = &DeviceFilterConfig{}
+	}
+	candidate := &Candidate{BlockDevice: dev, UsesDevicesFile: cfg.UseDevicesFile}
+
+	if dev.ReadOnly {
+		candidate.exclude(CandidateExclusionReadOnly, "")
+	}
+	if convert(dev.Size.Val, dev.Size.Unit, UnitBytes) < convert(MinimumPhysicalVolumeSize.Val, MinimumPhysicalVolumeSize.Unit, UnitBytes) {
+		candidate.exclude(CandidateExclusionTooSmall, dev.Size.String())
+	}
+	if len(dev.Partitions) > 0 {
+		candidate.exclude(CandidateExclusionPartitioned, strings.Join(dev.Partitions, ", "))
+	}
+	if len(dev.Holders) > 0 {
+		candidate.exclude(CandidateExclusionHolders, strings.Join(dev.Holders, ", "))
+	}
+	if dev.IsLogicalVolume() {
+		candidate.exclude(CandidateExclusionLogicalVolume, dev.DeviceMapperName)
+	}
+
+	if dev.Size.Val > 0 {
+		signatures, err := ProbeSignaturesFromPath(dev.Path)
+		if err != nil {
+			candidate.exclude(CandidateExclusionProbeFailed, err.Error())
+		}
+		candidate.Signatures = signatures
+	}
+	if len(candidate.Signatures) > 0 {
+		names := make([]string, len(candidate.Signatures))
+		for i, signature := range candidate.Signatures {
+			names[i] = string(signature)
+		}
+		candidate.exclude(CandidateExclusionSignature, strings.Join(names, ", "))
+	}
+
+	// lvm2 ignores devices/filter when the devices file is in use, devices/global_filter always applies.
+	candidate.PassesFilter = candidate.applyFilter(cfg.GlobalFilter, CandidateExclusionGlobalFilter)
+	if !cfg.UseDevicesFile {
+		candidate.PassesFilter = candidate.applyFilter(cfg.Filter, CandidateExclusionFilter) && candidate.PassesFilter
+	}
+
+	candidate.DevicesFileEntry = dev.DevicesFileEntry(entries)
+	candidate.InDevicesFile = candidate.DevicesFileEntry != nil
+
+	return candidate
+}
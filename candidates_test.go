@@ -0,0 +1,293 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+type fakeBlockDevice struct {
+	name     string
+	parent   string
+	dev      string
+	sectors  int64
+	attrs    map[string]string
+	holders  []string
+	contents func(image []byte)
+}
+
+// setupFakeSysfs creates a sysfs tree with block and class/block entries and a device node image
+// of every device in the returned dev root.
+func setupFakeSysfs(t *testing.T, devices []fakeBlockDevice) (string, string) {
+	t.Helper()
+	root := t.TempDir()
+	sysRoot, devRoot := filepath.Join(root, "sys"), filepath.Join(root, "dev")
+	write := func(path, content string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, dev := range devices {
+		dir := filepath.Join(sysRoot, "block", dev.name)
+		if dev.parent != "" {
+			dir = filepath.Join(sysRoot, "block", dev.parent, dev.name)
+			write(filepath.Join(dir, "partition"), "1")
+		}
+		write(filepath.Join(dir, "dev"), dev.dev)
+		write(filepath.Join(dir, "size"), fmt.Sprint(dev.sectors))
+		for attr, value := range dev.attrs {
+			write(filepath.Join(dir, attr), value)
+		}
+		if err := os.MkdirAll(filepath.Join(dir, "holders"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		for _, holder := range dev.holders {
+			write(filepath.Join(dir, "holders", holder), "")
+		}
+		if err := os.MkdirAll(filepath.Join(sysRoot, "class", "block"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Symlink(dir, filepath.Join(sysRoot, "class", "block", dev.name)); err != nil {
+			t.Fatal(err)
+		}
+
+		image := make([]byte, min(dev.sectors*512, 128*1024))
+		if dev.contents != nil {
+			dev.contents(image)
+		}
+		write(filepath.Join(devRoot, dev.name), string(image))
+	}
+	return sysRoot, devRoot
+}
+
+func TestCandidates(t *testing.T) {
+	t.Parallel()
+	lvm2Label := func(image []byte) {
+		copy(image[512:], "LABELONE")
+		copy(image[512+24:], "LVM2 001")
+	}
+	xfs := func(image []byte) {
+		copy(image, "XFSB")
+	}
+	ext4 := func(image []byte) {
+		binary.LittleEndian.PutUint16(image[1024+0x38:], 0xef53)
+		binary.LittleEndian.PutUint32(image[1024+0x60:], 0x40)
+	}
+	gpt := func(image []byte) {
+		copy(image[510:], "\x55\xaa")
+		copy(image[512:], "EFI PART")
+	}
+	sysRoot, devRoot := setupFakeSysfs(t, []fakeBlockDevice{
+		{name: "sda", dev: "8:0", sectors: 204800, contents: gpt, attrs: map[string]string{
+			"queue/rotational": "1", "queue/logical_block_size": "512", "queue/physical_block_size": "4096",
+		}},
+		{name: "sda1", parent: "sda", dev: "8:1", sectors: 102400, contents: xfs},
+		{name: "sda2", parent: "sda", dev: "8:2", sectors: 100000},
+		{name: "sdb", dev: "8:16", sectors: 204800, contents: lvm2Label, holders: []string{"dm-0"}},
+		{name: "sdc", dev: "8:32", sectors: 2048},
+		{name: "sr0", dev: "11:0", sectors: 204800, attrs: map[string]string{"ro": "1", "removable": "1"}},
+		{name: "dm-0", dev: "253:0", sectors: 102400, contents: ext4, attrs: map[string]string{
+			"dm/name": "vg-lv", "dm/uuid": "LVM-abcdef",
+		}},
+		{name: "vdb", dev: "252:16", sectors: 204800, attrs: map[string]string{
+			"queue/rotational": "0", "device/wwid": "naa.600a0b800012345", "device/serial": "  SER 123 ",
+		}},
+		{name: "vdc", dev: "252:32", sectors: 204800},
+	})
+
+	cfg := &DeviceFilterConfig{
+		Filter:       DeviceFilter{"r|/vdc$|"},
+		GlobalFilter: DeviceFilter{"r|/sr[0-9]+$|", "a|.*|"},
+	}
+	candidates, err := NewClient().Candidates(context.Background(), SysfsRoot(sysRoot), DevRoot(devRoot), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	byName := map[string]*Candidate{}
+	var names []string
+	for _, candidate := range candidates {
+		byName[candidate.Name] = candidate
+		names = append(names, candidate.Name)
+	}
+	if expected := []string{"dm-0", "sda", "sda1", "sda2", "sdb", "sdc", "sr0", "vdb", "vdc"}; !slices.Equal(names, expected) {
+		t.Fatalf("expected devices %v, got %v", expected, names)
+	}
+
+	sda := byName["sda"]
+	if !sda.Rotational || sda.LogicalSectorSize != 512 || sda.PhysicalSectorSize != 4096 || sda.Major != 8 {
+		t.Fatalf("unexpected properties of sda: %+v", sda.BlockDevice)
+	}
+	if !slices.Equal(sda.Partitions, []string{"sda1", "sda2"}) {
+		t.Fatalf("expected partitions of sda, got %v", sda.Partitions)
+	}
+	if sda2 := byName["sda2"]; sda2.Parent != "sda" || !sda2.Rotational || sda2.LogicalSectorSize != 512 {
+		t.Fatalf("expected partition to inherit queue attributes of sda, got %+v", sda2.BlockDevice)
+	}
+	if vdb := byName["vdb"]; vdb.Rotational || vdb.Size.Val != 204800*512 {
+		t.Fatalf("unexpected properties of vdb: %+v", vdb.BlockDevice)
+	}
+	expectedIDs := map[DeviceIDType]string{
+		DeviceIDTypeSysWWID:   "naa.600a0b800012345",
+		DeviceIDTypeWWIDNAA:   "naa.600a0b800012345",
+		DeviceIDTypeSysSerial: "SER_123",
+	}
+	if vdb := byName["vdb"]; !maps.Equal(vdb.DeviceIDs, expectedIDs) {
+		t.Fatalf("expected device ids %v of vdb, got %v", expectedIDs, vdb.DeviceIDs)
+	}
+	if dm0 := byName["dm-0"]; dm0.DeviceIDs[DeviceIDTypeLVMLVUUID] != "LVM-abcdef" {
+		t.Fatalf("expected lvmlv_uuid of dm-0, got %v", dm0.DeviceIDs)
+	}
+
+	for name, expected := range map[string][]CandidateExclusionReason{
+		"sda":  {CandidateExclusionPartitioned, CandidateExclusionSignature},
+		"sda1": {CandidateExclusionSignature},
+		"sda2": nil,
+		"sdb":  {CandidateExclusionHolders, CandidateExclusionSignature},
+		"sdc":  {CandidateExclusionTooSmall},
+		"sr0":  {CandidateExclusionReadOnly, CandidateExclusionGlobalFilter},
+		"dm-0": {CandidateExclusionLogicalVolume, CandidateExclusionSignature},
+		"vdb":  nil,
+		"vdc":  {CandidateExclusionFilter},
+	} {
+		candidate := byName[name]
+		var reasons []CandidateExclusionReason
+		for _, exclusion := range candidate.Exclusions {
+			reasons = append(reasons, exclusion.Reason)
+		}
+		if !slices.Equal(reasons, expected) {
+			t.Errorf("expected exclusions %v for %s, got %v", expected, name, candidate.Exclusions)
+		}
+		if candidate.IsCandidate() != (len(expected) == 0) {
+			t.Errorf("expected %s to be a candidate: %v", name, len(expected) == 0)
+		}
+	}
+
+	for name, expected := range map[string][]Signature{
+		"sda":  {SignatureGPT},
+		"sda1": {SignatureXFS},
+		"sdb":  {SignatureLVM2},
+		"dm-0": {SignatureExt4},
+	} {
+		if !slices.Equal(byName[name].Signatures, expected) {
+			t.Errorf("expected signatures %v for %s, got %v", expected, name, byName[name].Signatures)
+		}
+	}
+
+	// With the devices file in use, devices/filter no longer applies and entries are matched by device name.
+	cfg.UseDevicesFile = true
+	candidates, err = NewClient().Candidates(context.Background(), SysfsRoot(sysRoot), DevRoot(devRoot), cfg,
+		DeviceList{{IDType: DeviceIDTypeDevname, IDName: filepath.Join(devRoot, "vdc"), DevName: filepath.Join(devRoot, "vdc")}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, candidate := range candidates {
+		if candidate.Name != "vdc" {
+			continue
+		}
+		if !candidate.IsCandidate() || !candidate.PassesFilter || !candidate.InDevicesFile || candidate.DevicesFileEntry == nil {
+			t.Fatalf("expected vdc to be a candidate in the devices file, got %+v", candidate)
+		}
+	}
+
+	// Entries with a stable device id match regardless of the device name lvm2 last saw the device at,
+	// devname entries match any alias of the device.
+	entries := DeviceList{
+		{IDType: DeviceIDTypeSysWWID, IDName: "naa.600a0b800012345", DevName: filepath.Join(devRoot, "sdx")},
+		{IDType: DeviceIDTypeSysWWID, IDName: "naa.other", DevName: filepath.Join(devRoot, "sda2")},
+		{IDType: DeviceIDTypeDevname, IDName: filepath.Join(devRoot, "mapper", "vg-lv"), DevName: filepath.Join(devRoot, "mapper", "vg-lv")},
+	}
+	candidates, err = NewClient().Candidates(context.Background(), SysfsRoot(sysRoot), DevRoot(devRoot), cfg, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, candidate := range candidates {
+		var expected *DeviceListEntry
+		switch candidate.Name {
+		case "vdb":
+			expected = &entries[0]
+		case "sda2":
+			expected = &entries[1]
+		case "dm-0":
+			expected = &entries[2]
+		}
+		if candidate.DevicesFileEntry != expected || candidate.InDevicesFile != (expected != nil) {
+			t.Errorf("expected devices file entry %v for %s, got %v", expected, candidate.Name, candidate.DevicesFileEntry)
+		}
+	}
+}
+
+func TestDeviceFilter(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		filter   DeviceFilter
+		aliases  []string
+		accepted bool
+		err      bool
+	}{
+		{name: "empty", aliases: []string{"/dev/sda"}, accepted: true},
+		{name: "accept all", filter: DeviceFilter{"a|.*|"}, aliases: []string{"/dev/sda"}, accepted: true},
+		{name: "reject all", filter: DeviceFilter{"r|.*|"}, aliases: []string{"/dev/sda"}},
+		{name: "first match wins", filter: DeviceFilter{"a|loop|", "r|.*|"}, aliases: []string{"/dev/loop0"}, accepted: true},
+		{name: "first match rejects", filter: DeviceFilter{"a|loop|", "r|.*|"}, aliases: []string{"/dev/sda"}},
+		{name: "no match", filter: DeviceFilter{"r|/dev/cdrom|"}, aliases: []string{"/dev/sda"}, accepted: true},
+		{name: "other delimiter", filter: DeviceFilter{"r#^/dev/sd.*#"}, aliases: []string{"/dev/sda"}},
+		{name: "alias accepted", filter: DeviceFilter{"a|/dev/mapper/|", "r|.*|"}, aliases: []string{"/dev/dm-0", "/dev/mapper/vg-lv"}, accepted: true},
+		{name: "invalid type", filter: DeviceFilter{"x|.*|"}, aliases: []string{"/dev/sda"}, err: true},
+		{name: "missing delimiter", filter: DeviceFilter{"a|.*"}, aliases: []string{"/dev/sda"}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			accepted, err := tc.filter.Accepts(tc.aliases...)
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if accepted != tc.accepted {
+				t.Fatalf("expected accepted %v, got %v", tc.accepted, accepted)
+			}
+		})
+	}
+}
+
+func TestParseDeviceFilterConfig(t *testing.T) {
+	t.Parallel()
+	cfg, err := ParseDeviceFilterConfig(strings.NewReader(
+		"filter=\"a|.*|\"\nglobal_filter=[\"a|^/dev/sd[a,b]$|\",\"r|.*|\"]\nuse_devicesfile=1\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(cfg.Filter, DeviceFilter{"a|.*|"}) ||
+		!slices.Equal(cfg.GlobalFilter, DeviceFilter{"a|^/dev/sd[a,b]$|", "r|.*|"}) || !cfg.UseDevicesFile {
+		t.Fatalf("unexpected config %+v", cfg)
+	}
+}
@@ -23,8 +23,9 @@ import (
 )
 
 var (
-	ErrVolumeGroupNotFound   = errors.New("volume group not found")
-	ErrLogicalVolumeNotFound = errors.New("logical volume not found")
+	ErrVolumeGroupNotFound    = errors.New("volume group not found")
+	ErrLogicalVolumeNotFound  = errors.New("logical volume not found")
+	ErrPhysicalVolumeNotFound = errors.New("physical volume not found")
 )
 
 type client struct{}
@@ -94,6 +95,29 @@ type MetaClient interface {
 	// - int(8,16,32,64)
 	ReadAndDecodeConfig(ctx context.Context, v any, opts ...ConfigOption) error
 
+	// SystemID returns the system ID of the host as determined by lvm2 from global/system_id_source.
+	// Volume groups with a different system ID are foreign to the host, see VolumeGroup.IsForeign.
+	//
+	// See man lvm systemid and man lvmsystemid for more information.
+	SystemID(ctx context.Context) (*HostSystemID, error)
+
+	// ReportInto reports on objects of the given report type and decodes the rows into v.
+	// v has to be a pointer to a slice of structs or a pointer to a single struct.
+	// Only the fields tagged with LVMConfigStructTag are requested from lvm2, e.g.
+	//
+	// type Volume struct {
+	//     Name LogicalVolumeName `lvm:"lv_name"`
+	//     Size Size              `lvm:"lv_size"`
+	//     Tags []string          `lvm:"lv_tags"`
+	// }
+	//
+	// All tags have to be known report fields usable with the report type, see ReportFields.
+	// Sizes are reported in bytes unless a Unit is given.
+	// See DecodeReportRow for the supported field types.
+	//
+	// See man lvm lvs, man lvm vgs and man lvm pvs for more information.
+	ReportInto(ctx context.Context, v any, reportType ReportType, opts ...ReportOption) error
+
 	// WriteAndEncodeConfig writes configuration values to the given writer.
 	// The configuration values are encoded from the given value v.
 	// If the configuration cannot be written, an error is returned.
@@ -197,6 +221,42 @@ type VolumeGroupClient interface {
 	// See man lvm vgs for more information.
 	VGs(ctx context.Context, opts ...VGsOption) ([]*VolumeGroup, error)
 
+	// Planner returns a Planner for the volume group, which calculates the extents that logical volumes
+	// consume before they are created, including RAID metadata, thin pool metadata and the spare pool metadata.
+	//
+	// Example:
+	// planner, _ := Planner(ctx, "vg")
+	// plan, _ := planner.Plan(LVCreateOptionList{LogicalVolumeName("lv"), MustParseSize("1G"), TypeRAID1})
+	// plan.Fits()
+	Planner(ctx context.Context, vg VolumeGroupName) (*Planner, error)
+
+	// PlanRecovery inspects a volume group with missing physical volumes and classifies its logical volumes
+	// as intact, repairable or lost. The returned RecoveryPlan repairs all repairable logical volumes,
+	// other actions such as degraded activation or removal can be chosen with RecoveryPlan.Choose.
+	//
+	// Example:
+	// plan, _ := PlanRecovery(ctx, "vg")
+	// plan.Choose("lv", RecoveryActionRemove)
+	// Recover(ctx, plan)
+	PlanRecovery(ctx context.Context, vg VolumeGroupName) (*RecoveryPlan, error)
+
+	// Recover executes the RecoveryPlan: repairs with lvconvert --repair, activations with the chosen
+	// activation mode and removals, followed by vgreduce --removemissing if RemoveMissing is set.
+	//
+	// See man lvmraid for more information.
+	Recover(ctx context.Context, plan *RecoveryPlan) error
+
+	// FullReport returns a consistent snapshot of all volume groups with their physical volumes,
+	// logical volumes and segments in a single call. References between the objects are resolved,
+	// e.g. every logical volume references the physical volumes it is allocated on.
+	// Internal sub volumes are always included and reference the logical volume they belong to.
+	//
+	// The metadata sequence number of each volume group (see FullReport.SeqNos) can be used
+	// to detect modifications that happened after the report was taken.
+	//
+	// See man lvm fullreport for more information.
+	FullReport(ctx context.Context, opts ...FullReportOption) (*FullReport, error)
+
 	// VGCreate creates a new volume group with the given options.
 	//
 	// See man lvm vgcreate for more information.
@@ -226,6 +286,72 @@ type VolumeGroupClient interface {
 	//
 	// See man lvm vgchange for more information.
 	VGChange(ctx context.Context, opts ...VGChangeOption) error
+
+	// VGExport makes volume groups unknown to the system so that their physical volumes can be moved to another system.
+	// Logical volumes of the volume group have to be deactivated before.
+	// If duplicate physical volumes are reported, a DuplicatePVError is returned,
+	// with Succeeded set if the export completed regardless.
+	//
+	// See man lvm vgexport for more information.
+	VGExport(ctx context.Context, opts ...VGExportOption) error
+
+	// VGImport makes exported volume groups known to the system again.
+	// With Force, volume groups can be imported even if physical volumes are missing.
+	// If duplicate physical volumes are reported, a DuplicatePVError is returned,
+	// with Succeeded set if the import completed regardless.
+	//
+	// See man lvm vgimport for more information.
+	VGImport(ctx context.Context, opts ...VGImportOption) error
+
+	// VGImportClone imports duplicated physical volumes, e.g. from a LUN snapshot, as a new volume group
+	// by assigning new uuids to them and a new volume group name based on BaseVolumeGroupName.
+	// If duplicate physical volumes are reported, a DuplicatePVError is returned,
+	// with Succeeded set if the import completed regardless.
+	//
+	// See man lvm vgimportclone for more information.
+	VGImportClone(ctx context.Context, opts ...VGImportCloneOption) error
+
+	// VGCheck checks the metadata of volume groups for consistency and returns the reported problems
+	// together with the sequence number and validity of each affected metadata area.
+	// With UpdateMetadata, inconsistent or outdated metadata is repaired.
+	// The result is returned alongside the error if vgck reports problems.
+	//
+	// See man lvm vgck for more information.
+	VGCheck(ctx context.Context, opts ...VGCheckOption) (*VGCheckResult, error)
+
+	// VGSplit moves physical volumes and the logical volumes allocated on them from the source
+	// volume group into a new or existing destination volume group.
+	// The physical volumes are either given directly or through the LogicalVolumeName they belong to.
+	// Before splitting, the segments of the source volume group are checked and a SplitSpanError
+	// is returned if logical volumes would span both volume groups.
+	//
+	// See man lvm vgsplit for more information.
+	VGSplit(ctx context.Context, opts ...VGSplitOption) error
+
+	// VGMerge merges the inactive source volume group into the destination volume group.
+	// A MergeConflictError is returned if both volume groups contain logical volumes with the same name.
+	//
+	// See man lvm vgmerge for more information.
+	VGMerge(ctx context.Context, opts ...VGMergeOption) error
+
+	// VGLockStart starts the lockspace of a shared volume group in lvmlockd.
+	// If no volume group is given, the lockspaces of all shared volume groups are started.
+	// Logical volumes of a shared volume group can only be activated after its lockspace is started.
+	//
+	// See man lvm vgchange and man lvmlockd for more information.
+	VGLockStart(ctx context.Context, opts ...VGChangeOption) error
+
+	// VGLockStop stops the lockspace of a shared volume group in lvmlockd.
+	// If no volume group is given, the lockspaces of all shared volume groups are stopped.
+	//
+	// See man lvm vgchange and man lvmlockd for more information.
+	VGLockStop(ctx context.Context, opts ...VGChangeOption) error
+
+	// Lockspaces returns the lockspaces of shared volume groups currently started in lvmlockd
+	// with the locks held by this host.
+	//
+	// See man lvmlockctl for more information.
+	Lockspaces(ctx context.Context) ([]*Lockspace, error)
 }
 
 // LogicalVolumeClient is a client that provides operations on lvm2 logical volumes.
@@ -249,31 +375,68 @@ type LogicalVolumeClient interface {
 	// See man lvm lvs for more information.
 	LVs(ctx context.Context, opts ...LVsOption) ([]*LogicalVolume, error)
 
+	// LVSegments return a list of logical volume segments that match the given options.
+	// Every logical volume has at least one segment, which describes the type of the segment as well as
+	// the physical extent ranges and devices it is allocated on.
+	//
+	// If a LogicalVolumeName is given, only the segments of that logical volume are returned.
+	// If no segments are found, an empty slice is returned.
+	//
+	// See man lvm lvs for more information.
+	LVSegments(ctx context.Context, opts ...LVsOption) ([]*LVSegment, error)
+
 	// LVCreate creates a new logical volume with the given options.
 	//
 	// See man lvm lvcreate for more information.
 	LVCreate(ctx context.Context, opts ...LVCreateOption) error
 
+	// ThinPoolAllocations reports the allocation of all thin pools in the logical volumes that match the given options,
+	// summing up the virtual sizes of their thin volumes so that workloads can be placed on pools with space left.
+	// MaxOvercommit can be passed to LVCreate to refuse thin volumes that exceed a ratio of virtual to physical size.
+	//
+	// See ThinPoolAllocationsOf for more information.
+	ThinPoolAllocations(ctx context.Context, opts ...LVsOption) ([]*ThinPoolAllocation, error)
+
+	// RepairThinPool repairs the metadata of an inactive thin pool, e.g. after ErrThinPoolCheckNeeded or
+	// ErrThinPoolMetadataReadOnly were reported, with lvconvert --repair, which uses the spare metadata logical volume.
+	// With SwapMetadata, the metadata is swapped with another logical volume for a manual repair instead.
+	// The returned ThinPoolRepair holds the thin pool before and after the repair and the replaced metadata.
+	// Active thin pools are refused with ErrThinPoolActive.
+	//
+	// See man lvm lvconvert and man lvmthin for more information.
+	RepairThinPool(ctx context.Context, pool *ThinPool, opts ...RepairThinPoolOption) (*ThinPoolRepair, error)
+
 	// LVRemove removes a logical volume with the given options.
 	//
 	// See man lvm lvremove for more information.
 	LVRemove(ctx context.Context, opts ...LVRemoveOption) error
 
 	// LVResize resizes a logical volume with the given options.
+	// The filesystem is resized with ResizeFS or ResizeFSMode, ResizeFSModeGo shrinks it before
+	// and grows it after the logical volume is resized without relying on lvm2.
 	//
 	// See man lvm lvresize for more information.
 	LVResize(ctx context.Context, opts ...LVResizeOption) error
 
 	// LVExtend extends a logical volume with the given options.
+	// The filesystem is grown with ResizeFS or ResizeFSMode, see LVResize.
 	//
 	// See man lvm lvextend for more information.
 	LVExtend(ctx context.Context, opts ...LVExtendOption) error
 
 	// LVReduce reduces a logical volume with the given options.
+	// The filesystem is shrunk with ResizeFS or ResizeFSMode, see LVResize.
+	// Filesystems that cannot be shrunk, such as xfs, are refused with ErrFileSystemShrinkUnsupported.
 	//
 	// See man lvm lvreduce for more information.
 	LVReduce(ctx context.Context, opts ...LVReduceOption) error
 
+	// WaitForDevice blocks until the device node of the active logical volume exists in the volume group directory
+	// and has the kernel major and minor number reported by lvm2, which udev may create after the activation returned.
+	// Inactive logical volumes fail with ErrLogicalVolumeNotActive and pools or hidden sub volumes such as thin and VDO pools with ErrNoDeviceNode.
+	// WaitForDeviceOptions can also be passed to LVCreate, LVChange and VGChange to wait after activation.
+	WaitForDevice(ctx context.Context, fq *FQLogicalVolumeName, opts ...WaitForDeviceOption) error
+
 	// LVRename renames a logical volume with the given options.
 	//
 	// See man lvm lvrename for more information.
@@ -283,6 +446,24 @@ type LogicalVolumeClient interface {
 	//
 	// See man lvm lvchange for more information.
 	LVChange(ctx context.Context, opts ...LVChangeOption) error
+
+	// Poll waits for a background operation (pvmove, conversion or merge) on a logical volume to complete.
+	// Progress updates are passed to the PollProgressHandler if given.
+	// Cancelling the context stops waiting, but does not abort the operation, for this use AbortPoll.
+	//
+	// Example:
+	// Poll(ctx, MustNewFQLogicalVolumeName("vg", "pvmove0"), PollOperationPVMove)
+	//
+	// See man lvm lvpoll for more information.
+	Poll(ctx context.Context, opts ...LVPollOption) error
+
+	// PollingOperations returns all operations that are currently polled in the background,
+	// based on the report fields copy_percent, sync_percent, lv_merging and lv_converting.
+	// The options are used to limit the logical volumes that are queried, see LVs.
+	// Requested columns are extended with the columns required to detect the operations.
+	//
+	// If no operations are in progress, an empty slice is returned.
+	PollingOperations(ctx context.Context, opts ...LVsOption) ([]*PollingOperation, error)
 }
 
 // PhysicalVolumeClient is a client that provides operations on lvm2 physical volumes.
@@ -296,6 +477,23 @@ type PhysicalVolumeClient interface {
 	// See man lvm pvs for more information.
 	PVs(ctx context.Context, opts ...PVsOption) ([]*PhysicalVolume, error)
 
+	// SelectPhysicalVolumes returns the allocatable physical volumes that match the given tags, free space and device class,
+	// with the most free space first. The result can be passed to LVCreate, LVExtend and LVResize
+	// to restrict the allocation, e.g. to place metadata or cache volumes on fast media.
+	//
+	// Example:
+	// SelectPhysicalVolumes(ctx, VolumeGroupName("vg"), DeviceClassSSD, MinimumFree(MustParseSize("1G")))
+	SelectPhysicalVolumes(ctx context.Context, opts ...SelectPhysicalVolumesOption) (PhysicalVolumeNames, error)
+
+	// PVSegments return a list of physical volume segments that match the given options.
+	// The segments describe which extents of a physical volume are allocated to which logical volume,
+	// and which extents are free. To compute the layout of each physical volume, see NewPhysicalVolumeLayouts.
+	//
+	// If no segments are found, an empty slice is returned.
+	//
+	// See man lvm pvs for more information.
+	PVSegments(ctx context.Context, opts ...PVsOption) ([]*PVSegment, error)
+
 	// PVCreate creates a new physical volume with the given options.
 	//
 	// See man lvm pvcreate for more information.
@@ -317,9 +515,36 @@ type PhysicalVolumeClient interface {
 	PVChange(ctx context.Context, opts ...PVChangeOption) error
 
 	// PVMove moves extents between physical volumes with the given options.
+	// Progress updates are passed to the PollProgressHandler if given.
 	//
 	// see man lvm pvmove for more information.
 	PVMove(ctx context.Context, opts ...PVMoveOption) error
+
+	// Decommission removes a physical volume from lvm2, e.g. to replace a failing disk.
+	// After verifying that the other physical volumes in the volume group have enough free space,
+	// allocation on the physical volume is disallowed, the images of RAID logical volumes are replaced
+	// and the extents of all other logical volumes are moved one by one with pvmove.
+	// The physical volume is then removed from the volume group, wiped with pvremove and removed from the devices file.
+	//
+	// Every step is skipped if it was already done, so an interrupted decommission is resumed by calling it again,
+	// including moves that were interrupted. A physical volume that is neither reported by lvm2 nor part of the
+	// devices file fails with ErrPhysicalVolumeNotFound.
+	Decommission(ctx context.Context, pv PhysicalVolumeName, opts ...DecommissionOption) error
+
+	// PVCheck dumps and checks the on-disk lvm2 headers and metadata of a physical volume.
+	// If no PVCheckDump is given, PVCheckDumpHeaders is used.
+	// If pvck reports problems, the parsed result is returned together with the error.
+	//
+	// See man lvm pvck for more information.
+	PVCheck(ctx context.Context, opts ...PVCheckOption) (*PVCheckResult, error)
+
+	// PVScan scans devices for physical volumes and reports the volume groups and logical volumes on them.
+	// With PVScanCache, CheckComplete and AutoActivate, it replicates the event based autoactivation of lvm2
+	// for a newly appeared device and reports which volume groups became complete.
+	// The result is returned alongside the error if pvscan reports warnings or errors after scanning.
+	//
+	// See man lvm pvscan for more information.
+	PVScan(ctx context.Context, opts ...PVScanOption) (*PVScanResult, error)
 }
 
 // DevicesClient is a client that provides operations on lvm2 device files.
@@ -347,4 +572,33 @@ type DevicesClient interface {
 	// Replicates lvmdevices --adddev, --addpvid, --deldev and --delpvid
 	// See man lvmdevices for more information.
 	DevModify(ctx context.Context, opts ...DevModifyOption) error
+
+	// ReadDevicesFile parses the devices file, the system devices file in DefaultDevicesDirectory if not specified.
+	// When running containerized, the devices file of the host is read through HostRoot unless a DevicesDirectory is given.
+	//
+	// See man lvmdevices for more information.
+	ReadDevicesFile(ctx context.Context, opts ...DevicesFileOption) (*DevicesFileContents, error)
+
+	// WriteDevicesFile atomically replaces the devices file with the contents and increments its version.
+	// The devices file is locked like lvm2 does while editing it, waiting for the lock until the context is done.
+	// When running containerized, the devices file of the host is written through HostRoot unless a DevicesDirectory is given.
+	WriteDevicesFile(ctx context.Context, contents *DevicesFileContents, opts ...DevicesFileOption) error
+
+	// DiffDevicesFile compares the devices file with the physical volumes on all block devices of the host
+	// and reports physical volumes missing in it, stale entries and entries with outdated device names.
+	// The DevicesFileDiff can be resolved with DevModifyBatch(ctx, diff.Batch()).
+	DiffDevicesFile(ctx context.Context, opts ...DevicesFileOption) (*DevicesFileDiff, error)
+
+	// DevModifyBatch adds and removes multiple entries of the devices file in a single write,
+	// unlike DevModify, which modifies one device per lvmdevices invocation.
+	// The devices file is locked and located like in WriteDevicesFile.
+	DevModifyBatch(ctx context.Context, batch DevicesFileBatch, opts ...DevicesFileOption) error
+
+	// Candidates discovers the block devices in sysfs and evaluates whether they can be used for PVCreate.
+	// Every device is reported with its properties, signatures, devices/filter and devices file status,
+	// and a CandidateExclusion for every reason it cannot be used.
+	//
+	// Replicates the device checks of pvcreate together with lvmdevices and lvm config devices/filter
+	// See man pvcreate and man lvmdevices for more information.
+	Candidates(ctx context.Context, opts ...CandidatesOption) ([]*Candidate, error)
 }
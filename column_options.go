@@ -30,6 +30,12 @@ var (
 	DefaultPVsColumnOptions = ColumnOptions{
 		"pv_all",
 	}
+	DefaultLVSegmentsColumnOptions = ColumnOptions{
+		"lv_uuid", "lv_name", "vg_name", "seg_all",
+	}
+	DefaultPVSegmentsColumnOptions = ColumnOptions{
+		"pv_uuid", "pv_name", "vg_name", "pvseg_all", "lv_uuid", "lv_name", "segtype",
+	}
 )
 
 type ColumnOptions []string
@@ -42,6 +48,14 @@ func (opt ColumnOptions) ApplyToVGsOptions(opts *VGsOptions) {
 	opts.ColumnOptions = opt
 }
 
+func (opt ColumnOptions) ApplyToPVsOptions(opts *PVsOptions) {
+	opts.ColumnOptions = opt
+}
+
+func (opt ColumnOptions) ApplyToReportOptions(opts *ReportOptions) {
+	opts.ColumnOptions = opt
+}
+
 func (opt ColumnOptions) ApplyToArgs(args Arguments) error {
 	var optionsString string
 	if len(opt) > 0 {
@@ -54,6 +68,10 @@ func (opt ColumnOptions) ApplyToArgs(args Arguments) error {
 			optionsString = strings.Join(DefaultLVsColumnOptions, ",")
 		case ArgsTypePVs:
 			optionsString = strings.Join(DefaultPVsColumnOptions, ",")
+		case ArgsTypeLVSegments:
+			optionsString = strings.Join(DefaultLVSegmentsColumnOptions, ",")
+		case ArgsTypePVSegments:
+			optionsString = strings.Join(DefaultPVSegmentsColumnOptions, ",")
 		}
 	}
 	args.AddOrReplaceAll([]string{"--options", optionsString})
@@ -0,0 +1,421 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+type (
+	DecommissionOptions struct {
+		// PhysicalVolumeNames are the physical volumes the extents are moved to.
+		// If empty, all other allocatable physical volumes in the volume group are used.
+		PhysicalVolumeNames
+		AllocationPolicy
+
+		// PollInterval and PollProgressHandler report the progress of moved extents.
+		PollInterval
+		PollProgressHandler
+
+		// DevicesFile is the devices file the physical volume is removed from,
+		// the system devices file if unset and used by lvm2.
+		DevicesFile
+	}
+	DecommissionOption interface {
+		ApplyToDecommissionOptions(opts *DecommissionOptions)
+	}
+)
+
+func (opt PhysicalVolumeName) ApplyToDecommissionOptions(opts *DecommissionOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt)
+}
+
+func (opt PhysicalVolumeNames) ApplyToDecommissionOptions(opts *DecommissionOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (opt AllocationPolicy) ApplyToDecommissionOptions(opts *DecommissionOptions) {
+	opts.AllocationPolicy = opt
+}
+
+func (opt PollInterval) ApplyToDecommissionOptions(opts *DecommissionOptions) {
+	opts.PollInterval = opt
+}
+
+func (opt PollProgressHandler) ApplyToDecommissionOptions(opts *DecommissionOptions) {
+	opts.PollProgressHandler = opt
+}
+
+func (opt DevicesFile) ApplyToDecommissionOptions(opts *DecommissionOptions) {
+	opts.DevicesFile = opt
+}
+
+func (opts *DecommissionOptions) ApplyToDecommissionOptions(new *DecommissionOptions) {
+	*new = *opts
+}
+
+// ReplacePhysicalVolume is the physical volume whose RAID images are replaced with lvconvert --replace.
+type ReplacePhysicalVolume PhysicalVolumeName
+
+type (
+	ReplaceRAIDImagesOptions struct {
+		VolumeGroupName
+		LogicalVolumeName
+		ReplacePhysicalVolume
+
+		// PhysicalVolumeNames are the physical volumes the replacement images are allocated on.
+		// If empty, lvm2 allocates from all other physical volumes in the volume group.
+		PhysicalVolumeNames
+
+		CommonOptions
+	}
+	ReplaceRAIDImagesOption interface {
+		ApplyToReplaceRAIDImagesOptions(opts *ReplaceRAIDImagesOptions)
+	}
+	ReplaceRAIDImagesOptionsList []ReplaceRAIDImagesOption
+)
+
+var (
+	_ ArgumentGenerator = ReplaceRAIDImagesOptionsList{}
+	_ Argument          = (*ReplaceRAIDImagesOptions)(nil)
+)
+
+func (opt ReplacePhysicalVolume) ApplyToReplaceRAIDImagesOptions(opts *ReplaceRAIDImagesOptions) {
+	opts.ReplacePhysicalVolume = opt
+}
+
+func (opt ReplacePhysicalVolume) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return fmt.Errorf("%w: the physical volume to replace", ErrPhysicalVolumeNameRequired)
+	}
+	args.AddOrReplaceAll([]string{"--replace", string(opt)})
+	return nil
+}
+
+func (opt PhysicalVolumeNames) ApplyToReplaceRAIDImagesOptions(opts *ReplaceRAIDImagesOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (list ReplaceRAIDImagesOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := ReplaceRAIDImagesOptions{}
+	for _, opt := range list {
+		opt.ApplyToReplaceRAIDImagesOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *ReplaceRAIDImagesOptions) ApplyToReplaceRAIDImagesOptions(new *ReplaceRAIDImagesOptions) {
+	*new = *opts
+}
+
+func (opts *ReplaceRAIDImagesOptions) ApplyToArgs(args Arguments) error {
+	fq := &FQLogicalVolumeName{VolumeGroupName: opts.VolumeGroupName, LogicalVolumeName: opts.LogicalVolumeName}
+	if err := fq.Validate(); err != nil {
+		return err
+	}
+
+	for _, arg := range []Argument{
+		opts.ReplacePhysicalVolume,
+		fq,
+		opts.CommonOptions,
+		opts.PhysicalVolumeNames,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Evacuation describes how the logical volumes allocated on a physical volume are moved off of it.
+type Evacuation struct {
+	// Move are the logical volumes whose extents are moved with pvmove.
+	Move []LogicalVolumeName
+	// Replace are the RAID logical volumes whose images are replaced with lvconvert --replace,
+	// which rebuilds them from the remaining images instead of copying the extents.
+	Replace []LogicalVolumeName
+}
+
+// PlanEvacuation determines the Evacuation of the physical volume from its segments.
+// The segment types of the logical volumes decide whether RAID images are replaced or moved,
+// as raid0 has no redundancy to rebuild replaced images from.
+// Segments of other physical volumes, free segments and segments of pvmove volumes are ignored.
+func PlanEvacuation(pv PhysicalVolumeName, segments []*PVSegment, lvSegments []*LVSegment) *Evacuation {
+	evacuation := &Evacuation{}
+	for _, seg := range segments {
+		if seg.PhysicalVolumeName != pv || seg.IsFree() {
+			continue
+		}
+		name := internalLogicalVolumeName(string(seg.LogicalVolumeName))
+		if strings.HasPrefix(string(name), "pvmove") {
+			continue
+		}
+		if lv, kind, ok := subVolumeOf(string(name)); ok && kind != subVolumeMirrorImage {
+			if isStripedRAID(lvSegments, lv) {
+				if !slices.Contains(evacuation.Move, lv) {
+					evacuation.Move = append(evacuation.Move, lv)
+				}
+			} else if !slices.Contains(evacuation.Replace, lv) {
+				evacuation.Replace = append(evacuation.Replace, lv)
+			}
+		} else if !slices.Contains(evacuation.Move, name) {
+			evacuation.Move = append(evacuation.Move, name)
+		}
+	}
+	return evacuation
+}
+
+// isStripedRAID returns true if the logical volume is a raid0 logical volume without redundancy.
+func isStripedRAID(lvSegments []*LVSegment, lv LogicalVolumeName) bool {
+	for _, seg := range lvSegments {
+		if internalLogicalVolumeName(string(seg.LogicalVolumeName)) == lv {
+			return seg.Type == TypeRAID0 || seg.Type == TypeRAID0Meta
+		}
+	}
+	return false
+}
+
+func (c *client) Decommission(ctx context.Context, pv PhysicalVolumeName, opts ...DecommissionOption) error {
+	if pv == "" {
+		return ErrPhysicalVolumeNameRequired
+	}
+	options := DecommissionOptions{}
+	for _, opt := range opts {
+		opt.ApplyToDecommissionOptions(&options)
+	}
+
+	pvs, err := c.PVs(ctx, UnitBytes, options.DevicesFile)
+	if err != nil {
+		return err
+	}
+	idx := slices.IndexFunc(pvs, func(candidate *PhysicalVolume) bool { return candidate.Name == pv })
+
+	if idx >= 0 && pvs[idx].VGName != "" {
+		source := pvs[idx]
+		if err := c.evacuate(ctx, source, pvs, options); err != nil {
+			return err
+		}
+		slog.InfoContext(ctx, "removing physical volume from volume group", slog.String("pv", string(pv)), slog.String("vg", string(source.VGName)))
+		if err := c.VGReduce(ctx, source.VGName, pv, options.DevicesFile); err != nil {
+			return fmt.Errorf("failed to remove %s from %s: %w", pv, source.VGName, err)
+		}
+	}
+
+	var pvid string
+	if idx >= 0 {
+		slog.InfoContext(ctx, "removing physical volume", slog.String("pv", string(pv)))
+		if err := c.PVRemove(ctx, pv, options.DevicesFile); err != nil {
+			return fmt.Errorf("failed to remove physical volume %s: %w", pv, err)
+		}
+		pvid = strings.ReplaceAll(pvs[idx].UUID, "-", "")
+	}
+
+	removed, err := c.removeFromDevicesFile(ctx, pv, pvid, options.DevicesFile)
+	if err != nil {
+		return err
+	}
+	if idx < 0 && !removed {
+		return fmt.Errorf("%w: %s", ErrPhysicalVolumeNotFound, pv)
+	}
+	return nil
+}
+
+// evacuate moves all extents off the physical volume after verifying that the destinations have enough free space.
+// Interrupted moves of the physical volume are resumed before the remaining logical volumes are moved.
+func (c *client) evacuate(ctx context.Context, source *PhysicalVolume, pvs []*PhysicalVolume, opts DecommissionOptions) error {
+	vg := source.VGName
+
+	var destinations []*PhysicalVolume
+	for _, pv := range pvs {
+		if pv.Name == source.Name || pv.VGName != vg {
+			continue
+		}
+		if len(opts.PhysicalVolumeNames) > 0 && !slices.Contains(opts.PhysicalVolumeNames, pv.Name) {
+			continue
+		}
+		destinations = append(destinations, pv)
+	}
+	destinations, err := SelectPhysicalVolumesFrom(destinations, nil, SelectPhysicalVolumesOptions{})
+	if err != nil {
+		return err
+	}
+	if err := verifyEvacuationCapacity(source, destinations); err != nil {
+		return err
+	}
+	to := make(PhysicalVolumeNames, 0, len(destinations))
+	for _, pv := range destinations {
+		to = append(to, pv.Name)
+	}
+
+	if source.Attr.DuplicateAllocatableUsed == Allocatable {
+		if err := c.PVChange(ctx, source.Name, DisallowAllocation, opts.DevicesFile); err != nil {
+			return fmt.Errorf("failed to disallow allocation on %s: %w", source.Name, err)
+		}
+	}
+
+	ops, err := c.PollingOperations(ctx, vg, opts.DevicesFile)
+	if err != nil {
+		return err
+	}
+	for _, op := range ops {
+		if op.PollOperation != PollOperationPVMove || op.LogicalVolume.Attr.VolumeType != VolumeTypePVMove || op.LogicalVolume.MovePV != string(source.Name) {
+			continue
+		}
+		name := internalLogicalVolumeName(string(op.LogicalVolume.Name))
+		slog.InfoContext(ctx, "resuming interrupted move", slog.String("pv", string(source.Name)), slog.String("lv", string(name)))
+		if err := c.Poll(ctx, vg, name, PollOperationPVMove, opts.PollInterval, opts.PollProgressHandler, opts.DevicesFile); err != nil {
+			return fmt.Errorf("failed to resume move of %s: %w", source.Name, err)
+		}
+	}
+
+	segments, err := c.PVSegments(ctx, opts.DevicesFile)
+	if err != nil {
+		return err
+	}
+	lvSegments, err := c.LVSegments(ctx, vg, ShowInternal(true), opts.DevicesFile)
+	if err != nil {
+		return err
+	}
+	evacuation := PlanEvacuation(source.Name, segments, lvSegments)
+
+	for _, lv := range evacuation.Replace {
+		fq := &FQLogicalVolumeName{VolumeGroupName: vg, LogicalVolumeName: lv}
+		slog.InfoContext(ctx, "replacing raid images", slog.String("pv", string(source.Name)), slog.String("lv", fq.String()))
+		args, err := ReplaceRAIDImagesOptionsList{&ReplaceRAIDImagesOptions{
+			VolumeGroupName:       vg,
+			LogicalVolumeName:     lv,
+			ReplacePhysicalVolume: ReplacePhysicalVolume(source.Name),
+			PhysicalVolumeNames:   to,
+			CommonOptions:         CommonOptions{DevicesFile: opts.DevicesFile},
+		}}.AsArgs()
+		if err != nil {
+			return err
+		}
+		if err := c.RunLVM(ctx, append([]string{"lvconvert"}, args.GetRaw()...)...); err != nil {
+			return fmt.Errorf("failed to replace raid images of %s on %s: %w", fq, source.Name, err)
+		}
+	}
+
+	for _, lv := range evacuation.Move {
+		slog.InfoContext(ctx, "moving extents", slog.String("pv", string(source.Name)), slog.String("lv", string(lv)))
+		if err := c.PVMove(ctx, source.Name, to, lv, opts.AllocationPolicy, opts.PollInterval, opts.PollProgressHandler, opts.DevicesFile); err != nil {
+			return fmt.Errorf("failed to move %s off %s: %w", lv, source.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// verifyEvacuationCapacity returns ErrInsufficientFreeExtents if the used space of the source
+// does not fit into the free space of the destinations.
+func verifyEvacuationCapacity(source *PhysicalVolume, destinations []*PhysicalVolume) error {
+	if source.Used.Val <= 0 {
+		return nil
+	}
+	used, err := source.Used.ToUnit(UnitBytes)
+	if err != nil {
+		return err
+	}
+	var free float64
+	for _, pv := range destinations {
+		bytes, err := pv.Free.ToUnit(UnitBytes)
+		if err != nil {
+			return err
+		}
+		free += bytes.Val
+	}
+	if used.Val > free {
+		return fmt.Errorf("%w: %s uses %s, but only %s are free on other physical volumes in %s",
+			ErrInsufficientFreeExtents, source.Name, used, NewSize(free, UnitBytes), source.VGName)
+	}
+	return nil
+}
+
+// removeFromDevicesFile removes the device from the devices file if lvm2 uses one and the device is part of it.
+// The entry is matched by the stable device ids and aliases of the device like EvaluateCandidate,
+// and by the PVID and device name if the device is no longer present.
+func (c *client) removeFromDevicesFile(ctx context.Context, pv PhysicalVolumeName, pvid string, file DevicesFile) (bool, error) {
+	if file == "" {
+		cfg, err := c.deviceFilterConfig(ctx)
+		if err != nil {
+			return false, err
+		}
+		if !cfg.UseDevicesFile {
+			return false, nil
+		}
+	}
+	entries, err := c.DevList(ctx, file)
+	if err != nil {
+		return false, err
+	}
+	entry := devicesFileEntryOf(string(pv), pvid, entries)
+	if entry == nil {
+		return false, nil
+	}
+	del := DelDevice(string(pv))
+	if entry.PVID != "" {
+		// the device name of the entry may be outdated or the device may be gone, the PVID is stable.
+		del = DelDeviceByPVID(entry.PVID)
+	}
+	slog.InfoContext(ctx, "removing device from devices file", slog.String("pv", string(pv)))
+	if err := c.DevModify(ctx, del, file); err != nil {
+		return false, fmt.Errorf("failed to remove %s from devices file: %w", pv, err)
+	}
+	return true, nil
+}
+
+// devicesFileEntryOf returns the entry of the devices file for the device or nil if there is none.
+func devicesFileEntryOf(device, pvid string, entries DeviceList) *DeviceListEntry {
+	if dev := blockDeviceOf(device); dev != nil {
+		if entry := dev.DevicesFileEntry(entries); entry != nil {
+			return entry
+		}
+	}
+	for i, entry := range entries {
+		if (pvid != "" && entry.PVID == pvid) || entry.DevName == device ||
+			(entry.IDType == DeviceIDTypeDevname && entry.IDName == device) {
+			return &entries[i]
+		}
+	}
+	return nil
+}
+
+// blockDeviceOf returns the block device of the device node or nil if it cannot be found.
+func blockDeviceOf(device string) *BlockDevice {
+	major, minor, err := DeviceNumber(device)
+	if err != nil {
+		return nil
+	}
+	devices, err := ReadBlockDevices(DefaultSysfsRoot, DefaultDevRoot)
+	if err != nil {
+		return nil
+	}
+	for _, dev := range devices {
+		if dev.Major == major && dev.Minor == minor {
+			return dev
+		}
+	}
+	return nil
+}
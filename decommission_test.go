@@ -0,0 +1,151 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestPlanEvacuation(t *testing.T) {
+	t.Parallel()
+	seg := func(pv, lv string) *PVSegment {
+		return &PVSegment{PhysicalVolumeName: PhysicalVolumeName(pv), LogicalVolumeName: LogicalVolumeName(lv), Type: TypeLinear}
+	}
+	evacuation := PlanEvacuation("/dev/sdb", []*PVSegment{
+		seg("/dev/sdb", "data"),
+		seg("/dev/sdb", "data"),
+		seg("/dev/sdb", "[raid_rimage_1]"),
+		seg("/dev/sdb", "[raid_rmeta_1]"),
+		seg("/dev/sdb", "[striped_rimage_0]"),
+		seg("/dev/sdb", "[striped_meta_rmeta_0]"),
+		seg("/dev/sdb", "[pool_tdata]"),
+		seg("/dev/sdb", "[pvmove0]"),
+		seg("/dev/sdc", "other"),
+		{PhysicalVolumeName: "/dev/sdb", Type: SegmentTypeFree},
+	}, []*LVSegment{
+		{LogicalVolumeName: "data", Type: TypeLinear},
+		{LogicalVolumeName: "raid", Type: TypeRAID1},
+		{LogicalVolumeName: "[raid_rimage_1]", Type: TypeLinear},
+		{LogicalVolumeName: "striped", Type: TypeRAID0},
+		{LogicalVolumeName: "striped_meta", Type: TypeRAID0Meta},
+	})
+	if !slices.Equal(evacuation.Move, []LogicalVolumeName{"data", "striped", "striped_meta", "pool_tdata"}) {
+		t.Fatalf("unexpected logical volumes to move: %v", evacuation.Move)
+	}
+	if !slices.Equal(evacuation.Replace, []LogicalVolumeName{"raid"}) {
+		t.Fatalf("unexpected logical volumes to replace: %v", evacuation.Replace)
+	}
+}
+
+func TestDecommissionArgs(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		gen  ArgumentGenerator
+		args []string
+	}{
+		{
+			name: "pvmove with progress",
+			gen: PVMoveOptionsList{
+				PhysicalVolumeName("/dev/sdb"), PhysicalVolumeName("/dev/sdc"), LogicalVolumeName("lv"),
+				PollInterval(time.Second), PollProgressHandler(func(PollProgress) {}),
+			},
+			args: []string{"--name=lv", "/dev/sdb", "/dev/sdc", "--interval=1", "--yes"},
+		},
+		{
+			name: "pvchange allocatable",
+			gen:  PVChangeOptionsList{PhysicalVolumeName("/dev/sdb"), DisallowAllocation},
+			args: []string{"/dev/sdb", "--allocatable=n", "--yes"},
+		},
+		{
+			name: "lvconvert replace",
+			gen: ReplaceRAIDImagesOptionsList{
+				&ReplaceRAIDImagesOptions{VolumeGroupName: "vg", LogicalVolumeName: "lv"},
+				ReplacePhysicalVolume("/dev/sdb"), PhysicalVolumeNames{"/dev/sdc", "/dev/sdd"},
+			},
+			args: []string{"--replace", "/dev/sdb", "vg/lv", "--yes", "/dev/sdc", "/dev/sdd"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.gen.AsArgs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+
+	if _, err := (ReplaceRAIDImagesOptionsList{&ReplaceRAIDImagesOptions{VolumeGroupName: "vg", LogicalVolumeName: "lv"}}).AsArgs(); !errors.Is(err, ErrPhysicalVolumeNameRequired) {
+		t.Fatalf("expected physical volume to replace to be required, got %v", err)
+	}
+	if err := NewClient().Decommission(context.Background(), ""); !errors.Is(err, ErrPhysicalVolumeNameRequired) {
+		t.Fatalf("expected physical volume name to be required, got %v", err)
+	}
+}
+
+func TestDecommission(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{LoopDevices: []Size{MustParseSize("16M"), MustParseSize("16M")}}.SetupDevicesAndVolumeGroup(t)
+	vg := infra.volumeGroup.Name
+	pvs := infra.loopDevices.PhysicalVolumeNames()
+	source, target := pvs[0], pvs[1]
+
+	lv := MustNewFQLogicalVolumeName(vg, "moved")
+	if err := clnt.LVCreate(ctx, lv, MustParseSize("8M"), source); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clnt.Decommission(ctx, source, PollInterval(time.Second), PollProgressHandler(func(progress PollProgress) {
+		t.Logf("%s: %s %.2f%%", progress.Name, progress.Title, progress.Percent)
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	segments, err := clnt.LVSegments(ctx, vg, lv.LogicalVolumeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seg := range segments {
+		if !slices.Equal(seg.PERanges.PhysicalVolumeNames(), PhysicalVolumeNames{target}) {
+			t.Fatalf("expected logical volume to be moved to %s, got %v", target, seg.PERanges)
+		}
+	}
+
+	remaining, err := clnt.PVs(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if slices.ContainsFunc(remaining, func(pv *PhysicalVolume) bool { return pv.Name == source }) {
+		t.Fatalf("expected %s to be removed", source)
+	}
+	if err := clnt.Decommission(ctx, source); !errors.Is(err, ErrPhysicalVolumeNotFound) {
+		t.Fatalf("expected decommissioned physical volume not to be found, got %v", err)
+	}
+}
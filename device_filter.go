@@ -0,0 +1,142 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// DeviceFilter is a list of lvm2 device filter patterns as configured in devices/filter and devices/global_filter.
+// Every pattern starts with "a" to accept or "r" to reject, followed by a regular expression enclosed
+// in a delimiter, e.g. "a|^/dev/sd.*|" or "r|.*|".
+type DeviceFilter []string
+
+// Accepts evaluates the filter against the aliases of a device like lvm2 does:
+// the first pattern that matches an alias decides whether the alias is accepted or rejected.
+// The device is accepted if any of its aliases is accepted or if no pattern matches at all.
+func (filter DeviceFilter) Accepts(aliases ...string) (bool, error) {
+	rejected := false
+	for _, alias := range aliases {
+		for _, pattern := range filter {
+			accept, expr, err := parseDeviceFilterPattern(pattern)
+			if err != nil {
+				return false, err
+			}
+			if !expr.MatchString(alias) {
+				continue
+			}
+			if accept {
+				return true, nil
+			}
+			rejected = true
+			break
+		}
+	}
+	return !rejected, nil
+}
+
+func parseDeviceFilterPattern(pattern string) (bool, *regexp.Regexp, error) {
+	if len(pattern) < 3 || (pattern[0] != 'a' && pattern[0] != 'r') {
+		return false, nil, fmt.Errorf("invalid device filter pattern %q: must start with a or r", pattern)
+	}
+	delimiter := pattern[1]
+	end := strings.LastIndexByte(pattern, delimiter)
+	if end < 2 {
+		return false, nil, fmt.Errorf("invalid device filter pattern %q: missing closing delimiter %q", pattern, delimiter)
+	}
+	expr, err := regexp.Compile(pattern[2:end])
+	if err != nil {
+		return false, nil, fmt.Errorf("invalid device filter pattern %q: %w", pattern, err)
+	}
+	return pattern[0] == 'a', expr, nil
+}
+
+// DeviceFilterConfig is the part of the lvm2 configuration that decides which devices lvm2 uses.
+type DeviceFilterConfig struct {
+	Filter         DeviceFilter
+	GlobalFilter   DeviceFilter
+	UseDevicesFile bool
+}
+
+// deviceFilterConfigQuery is the lvm config query for DeviceFilterConfig.
+var deviceFilterConfigQuery = []string{"devices/filter", "devices/global_filter", "devices/use_devicesfile"}
+
+func (c *client) deviceFilterConfig(ctx context.Context) (*DeviceFilterConfig, error) {
+	var cfg *DeviceFilterConfig
+	processor := RawOutputProcessor(func(out io.Reader) error {
+		var err error
+		cfg, err = ParseDeviceFilterConfig(out)
+		return err
+	})
+	args := append(append([]string{"config"}, ConfigTypeFull.AsArgs()...), deviceFilterConfigQuery...)
+	if err := c.RunLVMRaw(ctx, processor, args...); err != nil {
+		return nil, fmt.Errorf("failed to read device filter configuration: %w", err)
+	}
+	return cfg, nil
+}
+
+// ParseDeviceFilterConfig parses the output of lvm config for devices/filter, devices/global_filter
+// and devices/use_devicesfile. Filters are either a single quoted pattern or a list of quoted patterns.
+func ParseDeviceFilterConfig(out io.Reader) (*DeviceFilterConfig, error) {
+	cfg := &DeviceFilterConfig{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(scanner.Text()), "#"))
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "filter":
+			cfg.Filter = parseConfigStringList(value)
+		case "global_filter":
+			cfg.GlobalFilter = parseConfigStringList(value)
+		case "use_devicesfile":
+			use, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid use_devicesfile value %q: %w", value, err)
+			}
+			cfg.UseDevicesFile = use != 0
+		}
+	}
+	return cfg, scanner.Err()
+}
+
+// parseConfigStringList parses a quoted string or a list of quoted strings of an lvm config value.
+// Commas inside the quotes are kept, as they are valid inside device filter patterns.
+func parseConfigStringList(value string) []string {
+	var list []string
+	for {
+		start := strings.IndexByte(value, '"')
+		if start < 0 {
+			return list
+		}
+		end := strings.IndexByte(value[start+1:], '"')
+		if end < 0 {
+			return list
+		}
+		list = append(list, value[start+1:start+1+end])
+		value = value[start+end+2:]
+	}
+}
@@ -0,0 +1,28 @@
+//go:build !linux
+
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import "errors"
+
+var ErrDeviceNumberNotSupported = errors.New("reading device numbers is not supported on this platform")
+
+// DeviceNumber is only supported on linux and returns ErrDeviceNumberNotSupported.
+func DeviceNumber(_ string) (int64, int64, error) {
+	return 0, 0, ErrDeviceNumberNotSupported
+}
@@ -0,0 +1,190 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+var (
+	ErrLogicalVolumeNotActive = errors.New("logical volume is not active")
+	ErrNoDeviceNode           = errors.New("logical volume has no device node")
+)
+
+// ToolUdevadm is used to wait for udev to process its event queue.
+const ToolUdevadm Tool = "udevadm"
+
+// DefaultDevicePollInterval is the interval in which device nodes are checked if no DevicePollInterval is given.
+var DefaultDevicePollInterval = 100 * time.Millisecond
+
+type (
+	// UdevSettle runs udevadm settle before the device node is polled.
+	UdevSettle bool
+	// DevicePollInterval is the interval in which the device node is checked.
+	DevicePollInterval time.Duration
+
+	// WaitForDeviceOptions configure WaitForDevice.
+	// Given to LVCreate, LVChange or VGChange, they block until the devices of the activated logical volumes are usable.
+	WaitForDeviceOptions struct {
+		UdevSettle
+		DevicePollInterval
+	}
+	WaitForDeviceOption interface {
+		ApplyToWaitForDeviceOptions(opts *WaitForDeviceOptions)
+	}
+)
+
+func (opt UdevSettle) ApplyToWaitForDeviceOptions(opts *WaitForDeviceOptions) {
+	opts.UdevSettle = opt
+}
+
+func (opt DevicePollInterval) ApplyToWaitForDeviceOptions(opts *WaitForDeviceOptions) {
+	opts.DevicePollInterval = opt
+}
+
+func (opts *WaitForDeviceOptions) ApplyToWaitForDeviceOptions(new *WaitForDeviceOptions) {
+	*new = *opts
+}
+
+func (opts *WaitForDeviceOptions) ApplyToLVCreateOptions(new *LVCreateOptions) {
+	new.WaitForDeviceOptions = opts
+}
+
+func (opts *WaitForDeviceOptions) ApplyToLVChangeOptions(new *LVChangeOptions) {
+	new.WaitForDeviceOptions = opts
+}
+
+func (opts *WaitForDeviceOptions) ApplyToVGChangeOptions(new *VGChangeOptions) {
+	new.WaitForDeviceOptions = opts
+}
+
+func (c *client) WaitForDevice(ctx context.Context, fq *FQLogicalVolumeName, opts ...WaitForDeviceOption) error {
+	if err := fq.Validate(); err != nil {
+		return err
+	}
+	options := WaitForDeviceOptions{}
+	for _, opt := range opts {
+		opt.ApplyToWaitForDeviceOptions(&options)
+	}
+
+	lv, err := c.LV(ctx, fq.VolumeGroupName, fq.LogicalVolumeName)
+	if err != nil {
+		return err
+	}
+	if !lv.Attr.State.IsActive() {
+		return fmt.Errorf("%w: %s", ErrLogicalVolumeNotActive, fq)
+	}
+	if !hasDeviceNode(lv) {
+		return fmt.Errorf("%w: %s", ErrNoDeviceNode, fq)
+	}
+	return waitForDeviceNode(ctx, fq.DevicePath(), lv.Major, lv.Minor, options)
+}
+
+// waitForActivatedDevices waits for the device nodes of all active logical volumes in the volume group,
+// or only of the named logical volume, while inactive ones and those without device node are skipped.
+func (c *client) waitForActivatedDevices(ctx context.Context, vg VolumeGroupName, name LogicalVolumeName, opts WaitForDeviceOptions) error {
+	lvs, err := c.LVs(ctx, vg)
+	if err != nil {
+		return fmt.Errorf("failed to list logical volumes to wait for: %w", err)
+	}
+	for _, lv := range lvs {
+		if (name != "" && lv.Name != name) || !lv.Attr.State.IsActive() || !hasDeviceNode(lv) {
+			continue
+		}
+		fq := &FQLogicalVolumeName{VolumeGroupName: vg, LogicalVolumeName: lv.Name}
+		if err := waitForDeviceNode(ctx, fq.DevicePath(), lv.Major, lv.Minor, opts); err != nil {
+			return err
+		}
+		// udev only needs to settle once for all devices
+		opts.UdevSettle = false
+	}
+	return nil
+}
+
+// volumeTypesWithoutDeviceNode are the pools and hidden sub volumes that lvm2 does not create
+// a device node in the volume group directory for.
+var volumeTypesWithoutDeviceNode = []VolumeType{
+	VolumeTypeThinPool,
+	VolumeTypeThinPoolData,
+	VolumeTypeThinPoolMetadata,
+	VolumeTypeVDOPool,
+	VolumeTypeVDOPoolData,
+	VolumeTypeMirrorOrRAIDImage,
+	VolumeTypeMirrorOrRAIDImageOutOfSync,
+	VolumeTypeMirrorLogDevice,
+	VolumeTypePVMove,
+}
+
+// hasDeviceNode returns false for logical volumes that lvm2 does not create a device node in the volume group directory for,
+// which are pools and hidden sub volumes, reported with their name in brackets.
+func hasDeviceNode(lv *LogicalVolume) bool {
+	return !slices.Contains(volumeTypesWithoutDeviceNode, lv.Attr.VolumeType) && !strings.HasPrefix(string(lv.Name), "[")
+}
+
+// waitForDeviceNode polls until the device node exists and, if major is not negative, has the major and minor number.
+// A failing or missing udevadm is ignored, as device nodes are also created without udev, e.g. by devtmpfs.
+func waitForDeviceNode(ctx context.Context, device string, major, minor int64, opts WaitForDeviceOptions) error {
+	if opts.UdevSettle {
+		if err := runFileSystemTool(ctx, ToolUdevadm, "settle"); err != nil {
+			slog.DebugContext(ctx, "udevadm settle failed, polling for device node", "device", device, "error", err)
+		}
+	}
+	interval := time.Duration(opts.DevicePollInterval)
+	if interval <= 0 {
+		interval = DefaultDevicePollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ready, err := isDeviceNodeReady(device, major, minor)
+		if err != nil {
+			return err
+		}
+		if ready {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("device node %s did not appear: %w", device, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+func isDeviceNodeReady(device string, major, minor int64) (bool, error) {
+	if _, err := os.Stat(device); errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	if major < 0 {
+		return true, nil
+	}
+	// The node may still point to a previous device of the same name until udev updated the symlink.
+	nodeMajor, nodeMinor, err := DeviceNumber(device)
+	if err != nil {
+		return false, err
+	}
+	return nodeMajor == major && nodeMinor == minor, nil
+}
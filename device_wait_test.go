@@ -0,0 +1,124 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestWaitForDeviceOptions(t *testing.T) {
+	t.Parallel()
+	wait := &WaitForDeviceOptions{UdevSettle: true, DevicePollInterval: DevicePollInterval(time.Second)}
+
+	// waiting happens after lvm2 returned and must not change the arguments
+	for _, tc := range []struct {
+		name          string
+		with, without ArgumentGenerator
+	}{
+		{
+			name:    "lvcreate",
+			with:    LVCreateOptionList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParseSize("1G"), wait},
+			without: LVCreateOptionList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParseSize("1G")},
+		},
+		{
+			name:    "lvchange",
+			with:    LVChangeOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), Activate, wait},
+			without: LVChangeOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), Activate},
+		},
+		{
+			name:    "vgchange",
+			with:    VGChangeOptionsList{VolumeGroupName("vg"), AutoActivate, wait},
+			without: VGChangeOptionsList{VolumeGroupName("vg"), AutoActivate},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			with, err := tc.with.AsArgs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			without, err := tc.without.AsArgs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(with.GetRaw(), without.GetRaw()) {
+				t.Fatalf("expected %v, got %v", without.GetRaw(), with.GetRaw())
+			}
+		})
+	}
+
+	options := WaitForDeviceOptions{}
+	for _, opt := range []WaitForDeviceOption{wait, UdevSettle(false)} {
+		opt.ApplyToWaitForDeviceOptions(&options)
+	}
+	if options.UdevSettle || options.DevicePollInterval != DevicePollInterval(time.Second) {
+		t.Fatalf("unexpected options %+v", options)
+	}
+
+	for state, activating := range map[ActivationState]bool{
+		Activate: true, AutoActivate: true, ActivateExclusive: true, ActivateShared: true, Deactivate: false, "": false,
+	} {
+		if state.IsActivating() != activating {
+			t.Errorf("expected %q activating to be %v", state, activating)
+		}
+	}
+
+	if err := NewClient().WaitForDevice(context.Background(), &FQLogicalVolumeName{VolumeGroupName: "vg"}); !errors.Is(err, ErrLogicalVolumeNameRequired) {
+		t.Fatalf("expected logical volume name to be required, got %v", err)
+	}
+}
+
+func TestWaitForDevice(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{LoopDevices: []Size{MustParseSize("10M")}}.SetupDevicesAndVolumeGroup(t)
+	fq := &FQLogicalVolumeName{VolumeGroupName: infra.volumeGroup.Name, LogicalVolumeName: "wait"}
+
+	if err := clnt.LVCreate(ctx, fq, MustParseSize("4M"), &WaitForDeviceOptions{UdevSettle: true}); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := clnt.LVRemove(ctx, fq); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := clnt.WaitForDevice(ctx, fq); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := clnt.LVChange(ctx, fq, Deactivate, &WaitForDeviceOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.WaitForDevice(ctx, fq); !errors.Is(err, ErrLogicalVolumeNotActive) {
+		t.Fatalf("expected inactive logical volume, got %v", err)
+	}
+	if err := clnt.LVChange(ctx, fq, Activate, &WaitForDeviceOptions{}); err != nil {
+		t.Fatal(err)
+	}
+}
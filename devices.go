@@ -90,6 +90,10 @@ func (opt Devices) ApplyToPVMoveOptions(opts *PVMoveOptions) {
 	opts.Devices = opt
 }
 
+func (opt Devices) ApplyToFullReportOptions(opts *FullReportOptions) {
+	opts.Devices = opt
+}
+
 func (opt Devices) ApplyToArgs(args Arguments) error {
 	if len(opt) == 0 {
 		return nil
@@ -106,6 +110,9 @@ func (opt DevicesFile) ApplyToDevModifyOptions(opts *DevModifyOptions) {
 func (opt DevicesFile) ApplyToDevListOptions(opts *DevListOptions) {
 	opts.DevicesFile = opt
 }
+func (opt DevicesFile) ApplyToDevicesFileOptions(opts *DevicesFileOptions) {
+	opts.DevicesFile = opt
+}
 
 func (opt DevicesFile) ApplyToVGsOptions(opts *VGsOptions) {
 	opts.DevicesFile = opt
@@ -172,6 +179,13 @@ func (opt DevicesFile) ApplyToPVChangeOptions(opts *PVChangeOptions) {
 func (opt DevicesFile) ApplyToPVMoveOptions(opts *PVMoveOptions) {
 	opts.DevicesFile = opt
 }
+func (opt DevicesFile) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.DevicesFile = opt
+}
+
+func (opt DevicesFile) ApplyToFullReportOptions(opts *FullReportOptions) {
+	opts.DevicesFile = opt
+}
 
 func (opt DevicesFile) ApplyToArgs(args Arguments) error {
 	if opt == "" {
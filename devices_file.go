@@ -0,0 +1,469 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidDevicesFile        = errors.New("invalid devices file")
+	ErrInvalidDevicesFileVersion = errors.New("invalid devices file version")
+)
+
+const (
+	// DefaultDevicesDirectory is the directory lvm2 reads devices files from.
+	DefaultDevicesDirectory = "/etc/lvm/devices"
+	// DefaultDevicesFileLockDirectory is the directory of the lock files lvm2 uses while editing devices files.
+	DefaultDevicesFileLockDirectory = "/run/lock/lvm"
+	// InitialDevicesFileVersion is the version of a devices file that was not written yet.
+	InitialDevicesFileVersion = "1.1.0"
+)
+
+// HostRoot is the root directory of the host that the devices files of lvm2 are accessed through when running
+// containerized, as lvm2 commands run on the host and read its devices files, see CommandContext.
+const HostRoot = "/proc/1/root"
+
+// DevicesDirectory is the directory containing the devices files, DefaultDevicesDirectory if unset.
+type DevicesDirectory string
+
+// Path returns the path of the devices file in the directory.
+// SystemDevices is used if no devices file is given, absolute devices files are returned as is.
+func (opt DevicesDirectory) Path(file DevicesFile) string {
+	if file == "" {
+		file = SystemDevices
+	}
+	if filepath.IsAbs(string(file)) {
+		return string(file)
+	}
+	if opt == "" {
+		opt = DefaultDevicesDirectory
+	}
+	return filepath.Join(string(opt), string(file))
+}
+
+// DevicesFileContents is the content of an lvm2 devices file such as /etc/lvm/devices/system.devices.
+type DevicesFileContents struct {
+	// HostName is the host that last wrote the devices file.
+	HostName string
+	// Version is incremented by every write, e.g. 1.1.12.
+	Version string
+	// Headers are the other KEY=value lines outside of entries, e.g. the PRODUCT_UUID lvm2 uses
+	// to detect when device ids need to be refreshed. They are written back unchanged.
+	Headers []string
+	Entries DeviceList
+}
+
+// ParseDevicesFile parses the header and IDTYPE/IDNAME/DEVNAME/PVID/PART lines of a devices file.
+// Lines containing IDTYPE are entries, other KEY=value lines are headers.
+// Comments are dropped and unknown fields of entries are ignored.
+func ParseDevicesFile(r io.Reader) (*DevicesFileContents, error) {
+	contents := &DevicesFileContents{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if version, ok := strings.CutPrefix(line, "VERSION="); ok {
+			contents.Version = version
+			continue
+		}
+		if hostname, ok := strings.CutPrefix(line, "HOSTNAME="); ok {
+			contents.HostName = hostname
+			continue
+		}
+		if isDevicesFileHeader(line) {
+			contents.Headers = append(contents.Headers, line)
+			continue
+		}
+		entry, err := parseDevicesFileEntry(line)
+		if err != nil {
+			return nil, err
+		}
+		contents.Entries = append(contents.Entries, entry)
+	}
+	return contents, scanner.Err()
+}
+
+// isDevicesFileHeader returns true if the line is a single KEY=value field that is not an entry.
+func isDevicesFileHeader(line string) bool {
+	if strings.Contains(line, "IDTYPE=") || len(strings.Fields(line)) != 1 {
+		return false
+	}
+	key, _, ok := strings.Cut(line, "=")
+	return ok && key != ""
+}
+
+func parseDevicesFileEntry(line string) (DeviceListEntry, error) {
+	entry := DeviceListEntry{}
+	for _, field := range strings.Fields(line) {
+		key, value, ok := strings.Cut(field, "=")
+		if !ok {
+			return entry, fmt.Errorf("%w: invalid field %q in line %q", ErrInvalidDevicesFile, field, line)
+		}
+		// lvm2 writes a dot for empty values.
+		if value == "." {
+			value = ""
+		}
+		switch key {
+		case "IDTYPE":
+			entry.IDType = DeviceIDType(value)
+		case "IDNAME":
+			entry.IDName = value
+		case "DEVNAME":
+			entry.DevName = value
+		case "PVID":
+			entry.PVID = value
+		case "PART":
+			part, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return entry, fmt.Errorf("%w: invalid partition in line %q: %w", ErrInvalidDevicesFile, line, err)
+			}
+			entry.Part = part
+		}
+	}
+	if entry.IDType == "" {
+		return entry, fmt.Errorf("%w: missing IDTYPE in line %q", ErrInvalidDevicesFile, line)
+	}
+	return entry, nil
+}
+
+// WriteTo writes the devices file in the format of lvm2.
+func (contents *DevicesFileContents) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	b.WriteString("# LVM uses devices listed in this file.\n")
+	b.WriteString("# Created by lvm2go\n")
+	if contents.HostName != "" {
+		fmt.Fprintf(&b, "HOSTNAME=%s\n", contents.HostName)
+	}
+	fmt.Fprintf(&b, "VERSION=%s\n", contents.Version)
+	for _, header := range contents.Headers {
+		b.WriteString(header)
+		b.WriteString("\n")
+	}
+	dot := func(value string) string {
+		if value == "" {
+			return "."
+		}
+		return value
+	}
+	for _, entry := range contents.Entries {
+		fmt.Fprintf(&b, "IDTYPE=%s IDNAME=%s DEVNAME=%s PVID=%s",
+			entry.IDType, dot(entry.IDName), dot(entry.DevName), dot(entry.PVID))
+		if entry.Part > 0 {
+			fmt.Fprintf(&b, " PART=%d", entry.Part)
+		}
+		b.WriteString("\n")
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// IncrementVersion increments the counter in the last component of the version, as lvm2 does on every write.
+func (contents *DevicesFileContents) IncrementVersion() error {
+	if contents.Version == "" {
+		contents.Version = InitialDevicesFileVersion
+	}
+	idx := strings.LastIndexByte(contents.Version, '.')
+	if idx < 0 {
+		return fmt.Errorf("%w: %q", ErrInvalidDevicesFileVersion, contents.Version)
+	}
+	counter, err := strconv.ParseUint(contents.Version[idx+1:], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: %q: %w", ErrInvalidDevicesFileVersion, contents.Version, err)
+	}
+	contents.Version = fmt.Sprintf("%s.%d", contents.Version[:idx], counter+1)
+	return nil
+}
+
+// DevicesFileBatch are entries that are added to and removed from a devices file at once.
+type DevicesFileBatch struct {
+	// Add are added to the devices file, replacing entries of the same device.
+	Add DeviceList
+	// Remove are removed from the devices file.
+	Remove DeviceList
+}
+
+// Apply removes and then adds the entries of the batch.
+// Entries refer to the same device if their PVIDs match, or without PVID, if their device names match.
+func (contents *DevicesFileContents) Apply(batch DevicesFileBatch) {
+	for _, remove := range batch.Remove {
+		contents.Entries = slices.DeleteFunc(contents.Entries, remove.sameDevice)
+	}
+	for _, add := range batch.Add {
+		if idx := slices.IndexFunc(contents.Entries, add.sameDevice); idx >= 0 {
+			contents.Entries[idx] = add
+		} else {
+			contents.Entries = append(contents.Entries, add)
+		}
+	}
+}
+
+func (entry DeviceListEntry) sameDevice(other DeviceListEntry) bool {
+	if entry.PVID != "" && other.PVID != "" {
+		return entry.PVID == other.PVID
+	}
+	if entry.DevName != "" && other.DevName != "" {
+		return entry.DevName == other.DevName
+	}
+	return entry.IDType == other.IDType && entry.IDName == other.IDName
+}
+
+// DevicesFileDiff are the differences between a devices file and the physical volumes on the host.
+type DevicesFileDiff struct {
+	// Missing are physical volumes without an entry in the devices file.
+	Missing []*PhysicalVolume
+	// Stale are entries with a PVID that does not belong to any physical volume.
+	Stale DeviceList
+	// Renamed are entries of physical volumes that appear under a different device name,
+	// with the device name updated to the current one. Entries of DeviceIDTypeDevname
+	// also have their device id updated, as lvm2 matches them by it.
+	Renamed DeviceList
+}
+
+// Diff compares the entries of the devices file with the physical volumes.
+// Entries without PVID are devices that are not physical volumes yet and are never stale.
+func (contents *DevicesFileContents) Diff(pvs []*PhysicalVolume) *DevicesFileDiff {
+	diff := &DevicesFileDiff{}
+	byPVID := make(map[string]*PhysicalVolume, len(pvs))
+	for _, pv := range pvs {
+		byPVID[pvidOf(pv)] = pv
+	}
+	listed := make(map[string]bool, len(contents.Entries))
+	for _, entry := range contents.Entries {
+		if entry.PVID == "" {
+			continue
+		}
+		listed[entry.PVID] = true
+		pv, ok := byPVID[entry.PVID]
+		if !ok {
+			diff.Stale = append(diff.Stale, entry)
+		} else if entry.DevName != string(pv.Name) {
+			entry.DevName = string(pv.Name)
+			if entry.IDType == DeviceIDTypeDevname {
+				entry.IDName = string(pv.Name)
+			}
+			diff.Renamed = append(diff.Renamed, entry)
+		}
+	}
+	for _, pv := range pvs {
+		if !listed[pvidOf(pv)] {
+			diff.Missing = append(diff.Missing, pv)
+		}
+	}
+	return diff
+}
+
+// Batch returns the DevicesFileBatch that resolves the differences.
+// Missing physical volumes are added with DeviceIDTypeDevname, as their stable device ids are only known to lvm2,
+// use DevModify with AddDevice instead to let lvm2 choose the device id type.
+func (diff *DevicesFileDiff) Batch() DevicesFileBatch {
+	batch := DevicesFileBatch{Remove: diff.Stale}
+	batch.Add = append(batch.Add, diff.Renamed...)
+	for _, pv := range diff.Missing {
+		batch.Add = append(batch.Add, DeviceListEntry{
+			IDType:  DeviceIDTypeDevname,
+			IDName:  string(pv.Name),
+			DevName: string(pv.Name),
+			PVID:    pvidOf(pv),
+		})
+	}
+	return batch
+}
+
+// pvidOf returns the PVID of the physical volume as written in devices files, which is the UUID without dashes.
+func pvidOf(pv *PhysicalVolume) string {
+	return strings.ReplaceAll(pv.UUID, "-", "")
+}
+
+type (
+	DevicesFileOptions struct {
+		DevicesFile
+		DevicesDirectory
+
+		// SysfsRoot and DevRoot are used to discover the block devices that are compared with the devices file.
+		SysfsRoot
+		DevRoot
+	}
+	DevicesFileOption interface {
+		ApplyToDevicesFileOptions(opts *DevicesFileOptions)
+	}
+)
+
+func (opt DevicesDirectory) ApplyToDevicesFileOptions(opts *DevicesFileOptions) {
+	opts.DevicesDirectory = opt
+}
+
+func (opt SysfsRoot) ApplyToDevicesFileOptions(opts *DevicesFileOptions) {
+	opts.SysfsRoot = opt
+}
+
+func (opt DevRoot) ApplyToDevicesFileOptions(opts *DevicesFileOptions) {
+	opts.DevRoot = opt
+}
+
+func (opts *DevicesFileOptions) ApplyToDevicesFileOptions(new *DevicesFileOptions) {
+	*new = *opts
+}
+
+// paths returns the path of the devices file and the directory of its lock file.
+// The devices files of lvm2 are accessed through HostRoot when running containerized and no DevicesDirectory is given.
+func (opts *DevicesFileOptions) paths(ctx context.Context) (string, string) {
+	if opts.DevicesDirectory == "" && IsContainerized(ctx) {
+		dir := DevicesDirectory(filepath.Join(HostRoot, DefaultDevicesDirectory))
+		return dir.Path(opts.DevicesFile), filepath.Join(HostRoot, DefaultDevicesFileLockDirectory)
+	}
+	return opts.DevicesDirectory.Path(opts.DevicesFile), DefaultDevicesFileLockDirectory
+}
+
+func (c *client) ReadDevicesFile(ctx context.Context, opts ...DevicesFileOption) (*DevicesFileContents, error) {
+	options := DevicesFileOptions{}
+	for _, opt := range opts {
+		opt.ApplyToDevicesFileOptions(&options)
+	}
+	path, _ := options.paths(ctx)
+	return readDevicesFile(path)
+}
+
+func (c *client) WriteDevicesFile(ctx context.Context, contents *DevicesFileContents, opts ...DevicesFileOption) error {
+	options := DevicesFileOptions{}
+	for _, opt := range opts {
+		opt.ApplyToDevicesFileOptions(&options)
+	}
+	path, lockDir := options.paths(ctx)
+	unlock, err := lockDevicesFile(ctx, lockDir, options.DevicesFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return writeDevicesFile(path, contents)
+}
+
+func (c *client) DevModifyBatch(ctx context.Context, batch DevicesFileBatch, opts ...DevicesFileOption) error {
+	options := DevicesFileOptions{}
+	for _, opt := range opts {
+		opt.ApplyToDevicesFileOptions(&options)
+	}
+	path, lockDir := options.paths(ctx)
+	unlock, err := lockDevicesFile(ctx, lockDir, options.DevicesFile)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	contents, err := readDevicesFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		contents = &DevicesFileContents{}
+		contents.HostName, _ = os.Hostname()
+	} else if err != nil {
+		return err
+	}
+	contents.Apply(batch)
+	return writeDevicesFile(path, contents)
+}
+
+func (c *client) DiffDevicesFile(ctx context.Context, opts ...DevicesFileOption) (*DevicesFileDiff, error) {
+	options := DevicesFileOptions{SysfsRoot: DefaultSysfsRoot, DevRoot: DefaultDevRoot}
+	for _, opt := range opts {
+		opt.ApplyToDevicesFileOptions(&options)
+	}
+	path, _ := options.paths(ctx)
+	contents, err := readDevicesFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		contents = &DevicesFileContents{}
+	} else if err != nil {
+		return nil, err
+	}
+
+	devices, err := ReadBlockDevices(string(options.SysfsRoot), string(options.DevRoot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover block devices: %w", err)
+	}
+	paths := make(Devices, 0, len(devices))
+	for _, dev := range devices {
+		paths = append(paths, dev.Path)
+	}
+	// the devices override the devices file, so physical volumes missing in it are reported as well.
+	pvs, err := c.PVs(ctx, paths)
+	if err != nil {
+		return nil, err
+	}
+	return contents.Diff(pvs), nil
+}
+
+func readDevicesFile(path string) (*DevicesFileContents, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read devices file: %w", err)
+	}
+	defer file.Close()
+	contents, err := ParseDevicesFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse devices file %s: %w", path, err)
+	}
+	return contents, nil
+}
+
+// writeDevicesFile increments the version and atomically replaces the devices file,
+// keeping the permissions of an existing file.
+func writeDevicesFile(path string, contents *DevicesFileContents) error {
+	if err := contents.IncrementVersion(); err != nil {
+		return err
+	}
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create devices directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write devices file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := contents.WriteTo(tmp); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write devices file: %w", err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write devices file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write devices file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write devices file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write devices file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// devicesFileLockRetryInterval is the interval in which a lock held by another process is retried.
+const devicesFileLockRetryInterval = 100 * time.Millisecond
+
+// lockDevicesFile takes the lock file lvm2 holds while it edits the devices file.
+// A lock held by another process is retried until it is released or the context is done.
+// No lock is taken if the lock directory does not exist, as lvm2 is not running on the host then.
+func lockDevicesFile(ctx context.Context, dir string, file DevicesFile) (func(), error) {
+	if file == "" {
+		file = SystemDevices
+	}
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return func() {}, nil
+	}
+	path := filepath.Join(dir, "D_"+filepath.Base(string(file)))
+	lock, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open devices file lock %s: %w", path, err)
+	}
+
+	ticker := time.NewTicker(devicesFileLockRetryInterval)
+	defer ticker.Stop()
+	for {
+		err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, syscall.EWOULDBLOCK) && !errors.Is(err, syscall.EINTR) {
+			_ = lock.Close()
+			return nil, fmt.Errorf("failed to lock devices file %s: %w", path, err)
+		}
+		select {
+		case <-ctx.Done():
+			_ = lock.Close()
+			return nil, fmt.Errorf("failed to lock devices file %s: %w", path, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+
+	return func() {
+		_ = syscall.Flock(int(lock.Fd()), syscall.LOCK_UN)
+		_ = lock.Close()
+	}, nil
+}
@@ -0,0 +1,59 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestLockDevicesFile(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+
+	unlock, err := lockDevicesFile(context.Background(), dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*devicesFileLockRetryInterval)
+	defer cancel()
+	if _, err := lockDevicesFile(ctx, dir, SystemDevices); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected waiting for the held lock to stop with the context, got %v", err)
+	}
+
+	released := make(chan error, 1)
+	go func() {
+		unlock, err := lockDevicesFile(context.Background(), dir, SystemDevices)
+		if err == nil {
+			unlock()
+		}
+		released <- err
+	}()
+	time.Sleep(devicesFileLockRetryInterval)
+	unlock()
+	select {
+	case err := <-released:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * devicesFileLockRetryInterval):
+		t.Fatal("expected the lock to be taken after it was released")
+	}
+}
@@ -0,0 +1,179 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+const testDevicesFile = `# LVM uses devices listed in this file.
+# Created by LVM command lvmdevices pid 4242 at Mon Jan  1 00:00:00 2024
+HOSTNAME=host
+VERSION=1.1.7
+PRODUCT_UUID=4c4c4544-0042-3510-8052-b4c04f4e4d32
+IDTYPE=sys_wwid IDNAME=naa.5000c500a1b2c3d4 DEVNAME=/dev/sda PVID=Kd3sZk3UXcUAaUkbGjMfQYyJ9Tq1l4WZ PART=1
+IDTYPE=devname IDNAME=/dev/loop0 DEVNAME=/dev/loop0 PVID=.
+`
+
+func TestParseDevicesFile(t *testing.T) {
+	t.Parallel()
+	contents, err := ParseDevicesFile(strings.NewReader(testDevicesFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &DevicesFileContents{
+		HostName: "host",
+		Version:  "1.1.7",
+		Headers:  []string{"PRODUCT_UUID=4c4c4544-0042-3510-8052-b4c04f4e4d32"},
+		Entries: DeviceList{
+			{IDType: DeviceIDTypeSysWWID, IDName: "naa.5000c500a1b2c3d4", DevName: "/dev/sda", PVID: "Kd3sZk3UXcUAaUkbGjMfQYyJ9Tq1l4WZ", Part: 1},
+			{IDType: DeviceIDTypeDevname, IDName: "/dev/loop0", DevName: "/dev/loop0"},
+		},
+	}
+	if !reflect.DeepEqual(contents, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, contents)
+	}
+
+	var buf bytes.Buffer
+	if _, err := contents.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	written := buf.String()
+	for _, line := range []string{
+		"HOSTNAME=host\n",
+		"VERSION=1.1.7\n",
+		"PRODUCT_UUID=4c4c4544-0042-3510-8052-b4c04f4e4d32\n",
+		"IDTYPE=sys_wwid IDNAME=naa.5000c500a1b2c3d4 DEVNAME=/dev/sda PVID=Kd3sZk3UXcUAaUkbGjMfQYyJ9Tq1l4WZ PART=1\n",
+		"IDTYPE=devname IDNAME=/dev/loop0 DEVNAME=/dev/loop0 PVID=.\n",
+	} {
+		if !strings.Contains(written, line) {
+			t.Fatalf("expected %q in written devices file:\n%s", line, written)
+		}
+	}
+	reparsed, err := ParseDevicesFile(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reparsed, expected) {
+		t.Fatalf("expected %+v after writing, got %+v", expected, reparsed)
+	}
+
+	if err := contents.IncrementVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if contents.Version != "1.1.8" {
+		t.Fatalf("expected version 1.1.8, got %s", contents.Version)
+	}
+
+	if _, err := ParseDevicesFile(strings.NewReader("IDNAME=/dev/sda DEVNAME=/dev/sda\n")); !errors.Is(err, ErrInvalidDevicesFile) {
+		t.Fatalf("expected %v, got %v", ErrInvalidDevicesFile, err)
+	}
+}
+
+func TestDevicesFileDiff(t *testing.T) {
+	t.Parallel()
+	contents := &DevicesFileContents{Version: "1.1.1", Entries: DeviceList{
+		{IDType: DeviceIDTypeSysWWID, IDName: "wwid-a", DevName: "/dev/sda", PVID: "aaaa"},
+		{IDType: DeviceIDTypeSysWWID, IDName: "wwid-b", DevName: "/dev/sdb", PVID: "bbbb"},
+		{IDType: DeviceIDTypeSysWWID, IDName: "wwid-c", DevName: "/dev/sdc", PVID: "cccc"},
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sdf", DevName: "/dev/sdf", PVID: "ffff"},
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sdx", DevName: "/dev/sdx"},
+	}}
+	diff := contents.Diff([]*PhysicalVolume{
+		{Name: "/dev/sda", UUID: "aa-aa"},
+		{Name: "/dev/sdd", UUID: "bb-bb"},
+		{Name: "/dev/sdg", UUID: "ff-ff"},
+		{Name: "/dev/sde", UUID: "ee-ee"},
+	})
+
+	if len(diff.Missing) != 1 || diff.Missing[0].Name != "/dev/sde" {
+		t.Fatalf("expected /dev/sde to be missing, got %v", diff.Missing)
+	}
+	if len(diff.Stale) != 1 || diff.Stale[0].PVID != "cccc" {
+		t.Fatalf("expected /dev/sdc to be stale, got %v", diff.Stale)
+	}
+	if len(diff.Renamed) != 2 || diff.Renamed[0].DevName != "/dev/sdd" || diff.Renamed[0].IDName != "wwid-b" {
+		t.Fatalf("expected /dev/sdb to be renamed to /dev/sdd, got %v", diff.Renamed)
+	}
+	if diff.Renamed[1].DevName != "/dev/sdg" || diff.Renamed[1].IDName != "/dev/sdg" {
+		t.Fatalf("expected /dev/sdf to be renamed to /dev/sdg including its device id, got %v", diff.Renamed[1])
+	}
+
+	contents.Apply(diff.Batch())
+	expected := DeviceList{
+		{IDType: DeviceIDTypeSysWWID, IDName: "wwid-a", DevName: "/dev/sda", PVID: "aaaa"},
+		{IDType: DeviceIDTypeSysWWID, IDName: "wwid-b", DevName: "/dev/sdd", PVID: "bbbb"},
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sdg", DevName: "/dev/sdg", PVID: "ffff"},
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sdx", DevName: "/dev/sdx"},
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sde", DevName: "/dev/sde", PVID: "eeee"},
+	}
+	if !reflect.DeepEqual(contents.Entries, expected) {
+		t.Fatalf("expected %v, got %v", expected, contents.Entries)
+	}
+}
+
+func TestDevModifyBatch(t *testing.T) {
+	t.Parallel()
+	clnt := NewClient()
+	ctx := context.Background()
+	dir := DevicesDirectory(t.TempDir())
+	file := DevicesFile(strings.ToLower(t.Name()))
+
+	if err := clnt.DevModifyBatch(ctx, DevicesFileBatch{Add: DeviceList{
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sda", DevName: "/dev/sda", PVID: "aaaa"},
+		{IDType: DeviceIDTypeDevname, IDName: "/dev/sdb", DevName: "/dev/sdb", PVID: "bbbb"},
+	}}, dir, file); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.DevModifyBatch(ctx, DevicesFileBatch{
+		Add:    DeviceList{{IDType: DeviceIDTypeDevname, IDName: "/dev/sdc", DevName: "/dev/sdc", PVID: "cccc"}},
+		Remove: DeviceList{{DevName: "/dev/sda"}},
+	}, dir, file); err != nil {
+		t.Fatal(err)
+	}
+
+	contents, err := clnt.ReadDevicesFile(ctx, dir, file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contents.Version != "1.1.2" {
+		t.Fatalf("expected version 1.1.2 after two writes, got %s", contents.Version)
+	}
+	var devices []string
+	for _, entry := range contents.Entries {
+		devices = append(devices, entry.DevName)
+	}
+	if !reflect.DeepEqual(devices, []string{"/dev/sdb", "/dev/sdc"}) {
+		t.Fatalf("expected /dev/sdb and /dev/sdc in devices file, got %v", devices)
+	}
+
+	if _, err := os.Stat(filepath.Join(string(dir), string(file))); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clnt.ReadDevicesFile(ctx, dir, DevicesFile("missing.devices")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected %v, got %v", os.ErrNotExist, err)
+	}
+}
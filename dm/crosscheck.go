@@ -0,0 +1,187 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jakobmoellerdev/lvm2go"
+)
+
+var ErrAttributeMismatch = errors.New("device-mapper state does not match lv_attr")
+
+// Mismatch is a single difference between the lv_attr of a logical volume and its device-mapper state.
+type Mismatch struct {
+	// Check is the name of the compared property, e.g. suspended or needs_check.
+	Check string
+	// Attribute is what lv_attr reports.
+	Attribute string
+	// DeviceMapper is what device-mapper reports.
+	DeviceMapper string
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: lv_attr reports %s, device-mapper reports %s", m.Check, m.Attribute, m.DeviceMapper)
+}
+
+// CrossCheckError is returned by CrossCheck if lv_attr and the device-mapper state disagree.
+// This usually means that the state changed between the two reads or that lvm2 metadata and the kernel are out of sync.
+type CrossCheckError struct {
+	LogicalVolume lvm2go.LogicalVolumeName
+	Mismatches    []Mismatch
+}
+
+func (e *CrossCheckError) Error() string {
+	mismatches := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		mismatches[i] = m.String()
+	}
+	return fmt.Sprintf("%s of %s: %s", ErrAttributeMismatch, e.LogicalVolume, strings.Join(mismatches, "; "))
+}
+
+func (e *CrossCheckError) Unwrap() error {
+	return ErrAttributeMismatch
+}
+
+// State is the device-mapper state of a logical volume.
+// Info and Targets are nil if the logical volume is not active.
+type State struct {
+	Info    *Info
+	Targets []Target
+}
+
+// MappedSize returns the size of the live table of the device.
+func (s *State) MappedSize() lvm2go.Size {
+	return MappedSize(s.Targets)
+}
+
+// Inspect reads the device-mapper state of the logical volume based on its kernel major and minor number.
+func Inspect(ctx context.Context, clnt Client, lv *lvm2go.LogicalVolume) (*State, error) {
+	// lvm2 reports -1 as kernel major and minor number for inactive logical volumes
+	if lv.Major <= 0 && lv.Minor <= 0 {
+		return &State{}, nil
+	}
+	dev := DeviceFromLogicalVolume(lv)
+	info, err := clnt.Info(ctx, dev)
+	if errors.Is(err, ErrDeviceNotFound) {
+		return &State{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device-mapper info of %s: %w", lv.Name, err)
+	}
+	targets, err := clnt.Status(ctx, dev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device-mapper status of %s: %w", lv.Name, err)
+	}
+	return &State{Info: info, Targets: targets}, nil
+}
+
+// CrossCheck compares the device-mapper state with the lv_attr of the logical volume
+// and returns a CrossCheckError listing all differences.
+func (s *State) CrossCheck(lv *lvm2go.LogicalVolume) error {
+	attr := lv.Attr
+	var mismatches []Mismatch
+	check := func(name string, fromAttr, fromDM bool, attrDesc, dmDesc func(bool) string) {
+		if fromAttr != fromDM {
+			mismatches = append(mismatches, Mismatch{Check: name, Attribute: attrDesc(fromAttr), DeviceMapper: dmDesc(fromDM)})
+		}
+	}
+	yesNo := func(yes, no string) func(bool) string {
+		return func(b bool) string {
+			if b {
+				return yes
+			}
+			return no
+		}
+	}
+
+	attrActive := attr.State.IsActive()
+	if attr.State != lvm2go.StateUnknown {
+		check("active", attrActive, s.Info != nil, yesNo("active", "inactive"), yesNo("device present", "no device"))
+	}
+	if s.Info == nil {
+		return newCrossCheckError(lv, mismatches)
+	}
+
+	check("suspended", isSuspendedState(attr.State), s.Info.Suspended, yesNo("suspended", "not suspended"), yesNo("suspended", "not suspended"))
+	if attr.Open != lvm2go.OpenUnknown {
+		check("open", attr.Open == lvm2go.OpenTrue, s.Info.OpenCount > 0, yesNo("open", "not open"),
+			func(bool) string { return fmt.Sprintf("open count %d", s.Info.OpenCount) })
+	}
+	attrReadOnly := attr.LVPermissions == lvm2go.LVPermissionsReadOnly ||
+		attr.LVPermissions == lvm2go.LVPermissionsReadOnlyActivationOfNonReadOnlyVolume
+	check("read only", attrReadOnly, s.Info.ReadOnly, yesNo("read only", "writeable"), yesNo("read only", "writeable"))
+
+	health := attr.VolumeHealth
+	for _, target := range s.Targets {
+		switch status := target.Status.(type) {
+		case *ThinPoolStatus:
+			check("fail", health == lvm2go.VolumeHealthThinFailed, status.Fail, yesNo("failed", "not failed"), yesNo("Fail", "not failed"))
+			if status.Fail {
+				continue
+			}
+			check("needs_check", attr.State == lvm2go.StateThinPoolCheckNeeded || attr.State == lvm2go.StateSuspendedThinPoolCheckNeeded,
+				status.NeedsCheck, yesNo("check needed", "no check needed"), yesNo("needs_check", "-"))
+			check("out of data space", health == lvm2go.VolumeHealthThinPoolOutOfDataSpace, status.Mode == ThinPoolModeOutOfDataSpace,
+				yesNo("out of data space", "data space available"), func(bool) string { return string(status.Mode) })
+			check("metadata read only", health == lvm2go.VolumeHealthThinPoolMetadataReadOnly, status.Mode == ThinPoolModeReadOnly,
+				yesNo("metadata read only", "metadata writeable"), func(bool) string { return string(status.Mode) })
+		case *ThinStatus:
+			check("fail", health == lvm2go.VolumeHealthThinFailed, status.Fail, yesNo("failed", "not failed"), yesNo("Fail", "not failed"))
+		case *RAIDStatus:
+			// lvm2 reports dead devices either as refresh needed or, if the physical volume is missing, as partial
+			dead := status.DeadDevices() > 0
+			attrDead := health == lvm2go.VolumeHealthRAIDRefreshNeeded || health == lvm2go.VolumeHealthPartialActivation
+			check("dead devices", attrDead, dead, yesNo("refresh needed", "healthy"),
+				func(bool) string { return fmt.Sprintf("health %s", string(status.Health)) })
+			if health == lvm2go.VolumeHealthRAIDMismatchesExist && status.MismatchCount == 0 {
+				mismatches = append(mismatches, Mismatch{Check: "mismatches", Attribute: "mismatches exist", DeviceMapper: "mismatch count 0"})
+			}
+			if health == lvm2go.VolumeHealthRAIDReshaping && status.SyncAction != "reshape" {
+				mismatches = append(mismatches, Mismatch{Check: "reshaping", Attribute: "reshaping", DeviceMapper: "sync action " + status.SyncAction})
+			}
+		case *SnapshotStatus:
+			check("invalid snapshot", attr.State == lvm2go.StateInvalidSnapshot, status.Invalid,
+				yesNo("invalid", "valid"), yesNo("Invalid", "valid"))
+			check("snapshot merge failed", attr.State == lvm2go.StateSnapshotMergeFailed || attr.State == lvm2go.StateSuspendedSnapshotMergeFailed,
+				status.MergeFailed, yesNo("merge failed", "merge not failed"), yesNo("Merge failed", "merge not failed"))
+		}
+	}
+
+	return newCrossCheckError(lv, mismatches)
+}
+
+func newCrossCheckError(lv *lvm2go.LogicalVolume, mismatches []Mismatch) error {
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &CrossCheckError{LogicalVolume: lv.Name, Mismatches: mismatches}
+}
+
+func isSuspendedState(state lvm2go.State) bool {
+	switch state {
+	case lvm2go.StateSuspended,
+		lvm2go.StateSuspendedSnapshot,
+		lvm2go.StateSuspendedSnapshotMergeFailed,
+		lvm2go.StateSuspendedThinPoolCheckNeeded:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,334 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+// Package dm reads the device-mapper state of logical volumes.
+//
+// lvm2 reports most of the state of a logical volume through lv_attr, but some health
+// information such as the needs_check flag of thin pools, the actually mapped size of thin volumes,
+// the suspended state or the open count of a device are only visible through device-mapper.
+// This package wraps dmsetup info, status and table, parses the status of common targets
+// into typed structs and cross-checks them against the lvm2go.LVAttributes of a logical volume.
+package dm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jakobmoellerdev/lvm2go"
+)
+
+// ToolDMSetup is the dmsetup binary used by the exec client, see lvm2go.SetToolPath.
+const ToolDMSetup lvm2go.Tool = "dmsetup"
+
+var (
+	ErrDeviceRequired = errors.New("device name or major and minor number is required")
+	ErrDeviceNotFound = errors.New("device-mapper device not found")
+)
+
+// infoSeparator separates the columns of dmsetup info -c, it cannot be part of a device name.
+const infoSeparator = "|"
+
+// infoColumns are the columns requested from dmsetup info -c in the order they are parsed by ParseInfo.
+var infoColumns = []string{"name", "uuid", "major", "minor", "attr", "open", "segments", "events"}
+
+// Device identifies a device-mapper device either by name or by its major and minor number.
+type Device struct {
+	Name  string
+	Major int64
+	Minor int64
+}
+
+// DeviceFromLogicalVolume returns the device of an active logical volume based on its kernel major and minor number.
+func DeviceFromLogicalVolume(lv *lvm2go.LogicalVolume) Device {
+	return Device{Major: lv.Major, Minor: lv.Minor}
+}
+
+func (dev Device) args() ([]string, error) {
+	if dev.Major > 0 || dev.Minor > 0 {
+		return []string{"-j", strconv.FormatInt(dev.Major, 10), "-m", strconv.FormatInt(dev.Minor, 10)}, nil
+	}
+	if dev.Name != "" {
+		return []string{dev.Name}, nil
+	}
+	return nil, ErrDeviceRequired
+}
+
+func (dev Device) String() string {
+	if dev.Name != "" {
+		return dev.Name
+	}
+	return fmt.Sprintf("%d:%d", dev.Major, dev.Minor)
+}
+
+// Info is the device-mapper information of a device as reported by dmsetup info.
+type Info struct {
+	Name  string
+	UUID  string
+	Major int64
+	Minor int64
+
+	LiveTable     bool
+	InactiveTable bool
+	Suspended     bool
+	ReadOnly      bool
+
+	// OpenCount is the number of references to the device, e.g. mounts or processes holding it open.
+	OpenCount int64
+	// TargetCount is the number of targets in the live table.
+	TargetCount int64
+	EventNumber int64
+}
+
+// Client reads the state of device-mapper devices.
+type Client interface {
+	// List returns all device-mapper devices.
+	List(ctx context.Context) ([]Device, error)
+
+	// Info returns the device-mapper information of the device.
+	// If the device does not exist, ErrDeviceNotFound is returned.
+	Info(ctx context.Context, dev Device) (*Info, error)
+
+	// Status returns the targets of the live table of the device together with their parsed status.
+	Status(ctx context.Context, dev Device) ([]Target, error)
+
+	// Table returns the targets of the live table of the device together with their table parameters.
+	Table(ctx context.Context, dev Device) ([]Target, error)
+
+	// Close releases the resources held by the client, e.g. the control device.
+	// The client must not be used after it was closed.
+	Close() error
+}
+
+// NewClient returns a Client that queries device-mapper through the ioctl interface of DefaultControlPath
+// and falls back to dmsetup if the control device cannot be used, e.g. because of missing privileges.
+// The returned Client holds the control device open until it is closed.
+func NewClient() Client {
+	control, err := OpenControlDevice(DefaultControlPath)
+	if err != nil {
+		return NewExecClient()
+	}
+	return NewFallbackClient(NewIoctlClient(control), NewExecClient())
+}
+
+type fallbackClient struct {
+	primary  Client
+	fallback Client
+}
+
+// NewFallbackClient returns a Client that uses the primary client and retries every query that
+// fails for another reason than ErrDeviceNotFound with the fallback client.
+func NewFallbackClient(primary, fallback Client) Client {
+	return &fallbackClient{primary: primary, fallback: fallback}
+}
+
+func (c *fallbackClient) List(ctx context.Context) ([]Device, error) {
+	devices, err := c.primary.List(ctx)
+	if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+		return c.fallback.List(ctx)
+	}
+	return devices, err
+}
+
+func (c *fallbackClient) Info(ctx context.Context, dev Device) (*Info, error) {
+	info, err := c.primary.Info(ctx, dev)
+	if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+		return c.fallback.Info(ctx, dev)
+	}
+	return info, err
+}
+
+func (c *fallbackClient) Status(ctx context.Context, dev Device) ([]Target, error) {
+	targets, err := c.primary.Status(ctx, dev)
+	if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+		return c.fallback.Status(ctx, dev)
+	}
+	return targets, err
+}
+
+func (c *fallbackClient) Table(ctx context.Context, dev Device) ([]Target, error) {
+	targets, err := c.primary.Table(ctx, dev)
+	if err != nil && !errors.Is(err, ErrDeviceNotFound) {
+		return c.fallback.Table(ctx, dev)
+	}
+	return targets, err
+}
+
+func (c *fallbackClient) Close() error {
+	return errors.Join(c.primary.Close(), c.fallback.Close())
+}
+
+type execClient struct{}
+
+// NewExecClient returns a Client that calls dmsetup.
+// When running containerized, dmsetup is called on the host, see lvm2go.CommandContext.
+func NewExecClient() Client {
+	return &execClient{}
+}
+
+func (c *execClient) List(ctx context.Context) ([]Device, error) {
+	var devices []Device
+	err := run(ctx, func(out io.Reader) error {
+		var err error
+		devices, err = ParseList(out)
+		return err
+	}, "ls")
+	if err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func (c *execClient) Info(ctx context.Context, dev Device) (*Info, error) {
+	devArgs, err := dev.args()
+	if err != nil {
+		return nil, err
+	}
+	var info *Info
+	err = run(ctx, func(out io.Reader) error {
+		var err error
+		info, err = ParseInfo(out)
+		return err
+	}, append([]string{
+		"info", "-c", "--noheadings", "--separator", infoSeparator, "-o", strings.Join(infoColumns, ","),
+	}, devArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func (c *execClient) Status(ctx context.Context, dev Device) ([]Target, error) {
+	return c.targets(ctx, dev, "status", ParseStatus)
+}
+
+func (c *execClient) Table(ctx context.Context, dev Device) ([]Target, error) {
+	return c.targets(ctx, dev, "table", ParseTable)
+}
+
+func (c *execClient) Close() error {
+	return nil
+}
+
+func (c *execClient) targets(ctx context.Context, dev Device, cmd string, parse func(io.Reader) ([]Target, error)) ([]Target, error) {
+	devArgs, err := dev.args()
+	if err != nil {
+		return nil, err
+	}
+	var targets []Target
+	err = run(ctx, func(out io.Reader) error {
+		var err error
+		targets, err = parse(out)
+		return err
+	}, append([]string{cmd}, devArgs...)...)
+	if err != nil {
+		return nil, err
+	}
+	return targets, nil
+}
+
+func run(ctx context.Context, process lvm2go.RawOutputProcessor, args ...string) error {
+	cmd := lvm2go.CommandContext(ctx, lvm2go.GetToolPath(ToolDMSetup), args...)
+	out, err := lvm2go.StreamedCommand(ctx, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to execute dmsetup: %w", err)
+	}
+	err = errors.Join(process(out), out.Close())
+	if IsDeviceNotFound(err) {
+		return errors.Join(ErrDeviceNotFound, err)
+	}
+	return err
+}
+
+// IsDeviceNotFound returns true if dmsetup reported that the device does not exist.
+func IsDeviceNotFound(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrDeviceNotFound) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "No such device or address") || strings.Contains(msg, "Device does not exist")
+}
+
+var listPattern = regexp.MustCompile(`^(\S+)\s+\((\d+)(?::|,\s*)(\d+)\)$`)
+
+// ParseList parses the output of dmsetup ls, which lists devices as "name\t(major:minor)".
+func ParseList(out io.Reader) ([]Device, error) {
+	var devices []Device
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line == "No devices found" {
+			continue
+		}
+		matches := listPattern.FindStringSubmatch(line)
+		if matches == nil {
+			return nil, fmt.Errorf("%q is not a valid dmsetup ls entry", line)
+		}
+		dev := Device{Name: matches[1]}
+		dev.Major, _ = strconv.ParseInt(matches[2], 10, 64)
+		dev.Minor, _ = strconv.ParseInt(matches[3], 10, 64)
+		devices = append(devices, dev)
+	}
+	return devices, scanner.Err()
+}
+
+// ParseInfo parses the output of dmsetup info -c --noheadings with the columns
+// name, uuid, major, minor, attr, open, segments and events separated by "|".
+func ParseInfo(out io.Reader) (*Info, error) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, infoSeparator)
+		if len(fields) != len(infoColumns) {
+			return nil, fmt.Errorf("expected %d columns in dmsetup info but got %d: %q", len(infoColumns), len(fields), line)
+		}
+		info := &Info{Name: fields[0], UUID: fields[1]}
+		for i, fieldPtr := range []*int64{&info.Major, &info.Minor, nil, &info.OpenCount, &info.TargetCount, &info.EventNumber} {
+			if fieldPtr == nil {
+				continue
+			}
+			value, err := strconv.ParseInt(fields[i+2], 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a number in dmsetup info: %w", infoColumns[i+2], err)
+			}
+			*fieldPtr = value
+		}
+		attr := fields[4]
+		if len(attr) != 4 {
+			return nil, fmt.Errorf("%q is an invalid dmsetup attr", attr)
+		}
+		info.LiveTable = attr[0] == 'L'
+		info.InactiveTable = attr[1] == 'I'
+		info.Suspended = attr[2] == 's'
+		info.ReadOnly = attr[3] == 'r'
+		return info, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return nil, ErrDeviceNotFound
+}
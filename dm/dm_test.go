@@ -0,0 +1,297 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jakobmoellerdev/lvm2go"
+	. "github.com/jakobmoellerdev/lvm2go/dm"
+)
+
+func TestParseInfo(t *testing.T) {
+	t.Parallel()
+	info, err := ParseInfo(strings.NewReader("vg-thin|LVM-abc123-tpool|253|3|L-sw|2|1|0\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Info{
+		Name: "vg-thin", UUID: "LVM-abc123-tpool", Major: 253, Minor: 3,
+		LiveTable: true, Suspended: true, OpenCount: 2, TargetCount: 1,
+	}
+	if *info != expected {
+		t.Fatalf("expected %+v, got %+v", expected, *info)
+	}
+
+	if _, err := ParseInfo(strings.NewReader("")); !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("expected device not found, got %v", err)
+	}
+	if _, err := ParseInfo(strings.NewReader("vg-thin|253|3\n")); err == nil {
+		t.Fatal("expected error for missing columns")
+	}
+}
+
+func TestParseStatus(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name   string
+		status string
+		verify func(t *testing.T, status TargetStatus)
+	}{
+		{
+			name:   "thin-pool",
+			status: "0 204800 thin-pool 1 12/4096 30/1600 - rw no_discard_passdown queue_if_no_space needs_check 1024",
+			verify: func(t *testing.T, status TargetStatus) {
+				s := status.(*ThinPoolStatus)
+				if s.TransactionID != 1 || s.UsedMetadataBlocks != 12 || s.TotalDataBlocks != 1600 ||
+					s.Mode != ThinPoolModeReadWrite || s.DiscardPassdown || s.ErrorIfNoSpace || !s.NeedsCheck ||
+					s.MetadataLowWatermark != 1024 {
+					t.Fatalf("unexpected status %+v", s)
+				}
+				if s.DataPercent() != 1.875 {
+					t.Fatalf("unexpected data percent %v", s.DataPercent())
+				}
+			},
+		},
+		{
+			name:   "thin-pool failed",
+			status: "0 204800 thin-pool Fail",
+			verify: func(t *testing.T, status TargetStatus) {
+				if !status.(*ThinPoolStatus).Fail {
+					t.Fatal("expected failed thin pool")
+				}
+			},
+		},
+		{
+			name:   "thin",
+			status: "0 2097152 thin 20480 20479",
+			verify: func(t *testing.T, status TargetStatus) {
+				s := status.(*ThinStatus)
+				if s.MappedSectors != 20480 || s.HighestMappedSector != 20479 || s.MappedSize().Val != 20480*512 {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			},
+		},
+		{
+			name:   "thin empty",
+			status: "0 2097152 thin 0 -",
+			verify: func(t *testing.T, status TargetStatus) {
+				if s := status.(*ThinStatus); s.HighestMappedSector != -1 {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			},
+		},
+		{
+			name: "cache",
+			status: "0 409600 cache 8 27/2048 128 10/3200 5 6 7 8 0 10 2 1 writethrough 2 migration_threshold 2048 " +
+				"smq 0 rw -",
+			verify: func(t *testing.T, status TargetStatus) {
+				s := status.(*CacheStatus)
+				if s.UsedCacheBlocks != 10 || s.TotalCacheBlocks != 3200 || s.ReadHits != 5 || s.Promotions != 10 ||
+					s.DirtyBlocks != 2 || len(s.Features) != 1 || s.Features[0] != "writethrough" ||
+					len(s.CoreArgs) != 2 || s.Policy != "smq" || s.MetadataMode != "rw" || s.NeedsCheck {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			},
+		},
+		{
+			name:   "raid",
+			status: "0 20480 raid raid1 2 AD 20480/20480 idle 0 0 -",
+			verify: func(t *testing.T, status TargetStatus) {
+				s := status.(*RAIDStatus)
+				if s.RAIDType != "raid1" || s.Devices != 2 || s.DeadDevices() != 1 || s.InSync() || s.SyncAction != "idle" {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			},
+		},
+		{
+			name:   "vdo",
+			status: "0 20971520 vdo /dev/dm-3 normal - online online 1049 262144",
+			verify: func(t *testing.T, status TargetStatus) {
+				s := status.(*VDOStatus)
+				if s.Device != "/dev/dm-3" || s.ReadOnly() || s.InRecovery || s.UsedPhysicalBlocks != 1049 {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			},
+		},
+		{
+			name:   "snapshot",
+			status: "0 204800 snapshot 96/40960 16",
+			verify: func(t *testing.T, status TargetStatus) {
+				s := status.(*SnapshotStatus)
+				if s.AllocatedSectors != 96 || s.TotalSectors != 40960 || s.MetadataSectors != 16 || s.Invalid {
+					t.Fatalf("unexpected status %+v", s)
+				}
+			},
+		},
+		{
+			name:   "snapshot invalid",
+			status: "0 204800 snapshot Invalid",
+			verify: func(t *testing.T, status TargetStatus) {
+				if !status.(*SnapshotStatus).Invalid {
+					t.Fatal("expected invalid snapshot")
+				}
+			},
+		},
+		{
+			name:   "linear",
+			status: "0 204800 linear ",
+			verify: func(t *testing.T, status TargetStatus) {
+				if status != nil {
+					t.Fatalf("expected no typed status, got %+v", status)
+				}
+			},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			targets, err := ParseStatus(strings.NewReader(tc.status + "\n"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(targets) != 1 {
+				t.Fatalf("expected 1 target, got %d", len(targets))
+			}
+			tc.verify(t, targets[0].Status)
+		})
+	}
+}
+
+func TestParseTable(t *testing.T) {
+	t.Parallel()
+	targets, err := ParseTable(strings.NewReader("0 20480 linear 7:0 2048\n20480 20480 linear 7:1 2048\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 2 || targets[1].Start != 20480 || targets[1].Params != "7:1 2048" || targets[1].Status != nil {
+		t.Fatalf("unexpected targets %+v", targets)
+	}
+	if size := MappedSize(targets); size.Val != 40960*512 {
+		t.Fatalf("unexpected mapped size %v", size)
+	}
+}
+
+type fakeClient struct {
+	info    *Info
+	targets []Target
+}
+
+func (c *fakeClient) List(_ context.Context) ([]Device, error) {
+	if c.info == nil {
+		return nil, nil
+	}
+	return []Device{{Name: c.info.Name, Major: c.info.Major, Minor: c.info.Minor}}, nil
+}
+
+func (c *fakeClient) Info(_ context.Context, _ Device) (*Info, error) {
+	if c.info == nil {
+		return nil, ErrDeviceNotFound
+	}
+	return c.info, nil
+}
+
+func (c *fakeClient) Status(_ context.Context, _ Device) ([]Target, error) {
+	return c.targets, nil
+}
+
+func (c *fakeClient) Table(_ context.Context, _ Device) ([]Target, error) {
+	return c.targets, nil
+}
+
+func (c *fakeClient) Close() error {
+	return nil
+}
+
+func TestCrossCheck(t *testing.T) {
+	t.Parallel()
+	thinPool := func(params string) []Target {
+		targets, err := ParseStatus(strings.NewReader("0 204800 thin-pool " + params))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return targets
+	}
+	for _, tc := range []struct {
+		name   string
+		attr   string
+		client *fakeClient
+		checks []string
+	}{
+		{
+			name:   "consistent thin pool",
+			attr:   "twi-aotz--",
+			client: &fakeClient{&Info{LiveTable: true, OpenCount: 1}, thinPool("1 12/4096 30/1600 - rw discard_passdown queue_if_no_space - 1024")},
+		},
+		{
+			name:   "needs check",
+			attr:   "twi-aotz--",
+			client: &fakeClient{&Info{LiveTable: true, OpenCount: 1}, thinPool("1 12/4096 30/1600 - rw discard_passdown queue_if_no_space needs_check 1024")},
+			checks: []string{"needs_check"},
+		},
+		{
+			name:   "out of data space and suspended",
+			attr:   "twi-aotzD-",
+			client: &fakeClient{&Info{LiveTable: true, OpenCount: 1, Suspended: true}, thinPool("1 12/4096 1600/1600 - out_of_data_space discard_passdown queue_if_no_space - 1024")},
+			checks: []string{"suspended"},
+		},
+		{
+			name:   "inactive",
+			attr:   "-wi-------",
+			client: &fakeClient{},
+		},
+		{
+			name:   "missing device",
+			attr:   "-wi-a-----",
+			client: &fakeClient{},
+			checks: []string{"active"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			attr, err := lvm2go.ParseLVAttributes(tc.attr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lv := &lvm2go.LogicalVolume{Name: "lv", Attr: attr, Major: 253, Minor: 0}
+			state, err := Inspect(context.Background(), tc.client, lv)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = state.CrossCheck(lv)
+			if len(tc.checks) == 0 {
+				if err != nil {
+					t.Fatalf("expected no mismatches, got %v", err)
+				}
+				return
+			}
+			var crossCheckErr *CrossCheckError
+			if !errors.As(err, &crossCheckErr) || !errors.Is(err, ErrAttributeMismatch) {
+				t.Fatalf("expected cross check error, got %v", err)
+			}
+			var checks []string
+			for _, m := range crossCheckErr.Mismatches {
+				checks = append(checks, m.Check)
+			}
+			if strings.Join(checks, ",") != strings.Join(tc.checks, ",") {
+				t.Fatalf("expected mismatches %v, got %v", tc.checks, crossCheckErr.Mismatches)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+	"syscall"
+)
+
+// DefaultControlPath is the path of the device-mapper control device.
+const DefaultControlPath = "/dev/mapper/control"
+
+var ErrIoctlNotSupported = errors.New("device-mapper ioctls are not supported on this platform")
+
+// ControlDevice executes device-mapper ioctls. It is implemented by the control device opened with
+// OpenControlDevice and by FakeControlDevice.
+type ControlDevice interface {
+	// Ioctl executes the request with the buffer, which starts with a struct dm_ioctl header.
+	Ioctl(request uintptr, buf []byte) error
+	Close() error
+}
+
+// The layout of struct dm_ioctl and the structs in its data area, see linux/dm-ioctl.h.
+const (
+	dmIoctlType         = 0xfd
+	dmVersionMajor      = 4
+	dmHeaderSize        = 312
+	dmNameLen           = 128
+	dmUUIDLen           = 129
+	dmMaxTypeName       = 16
+	dmTargetSpecSize    = 40
+	dmNameListEntrySize = 12
+
+	dmOffsetDataSize    = 12
+	dmOffsetDataStart   = 16
+	dmOffsetTargetCount = 20
+	dmOffsetOpenCount   = 24
+	dmOffsetFlags       = 28
+	dmOffsetEventNr     = 32
+	dmOffsetDev         = 40
+	dmOffsetName        = 48
+	dmOffsetUUID        = dmOffsetName + dmNameLen
+
+	dmListDevicesCmd = 2
+	dmDevStatusCmd   = 7
+	dmTableStatusCmd = 12
+
+	dmReadOnlyFlag        = 1 << 0
+	dmSuspendFlag         = 1 << 1
+	dmStatusTableFlag     = 1 << 4
+	dmActivePresentFlag   = 1 << 5
+	dmInactivePresentFlag = 1 << 6
+	dmBufferFullFlag      = 1 << 8
+
+	dmInitialBufferSize = 16 * 1024
+	dmMaxBufferSize     = 16 * 1024 * 1024
+)
+
+var (
+	// DMListDevices is the DM_LIST_DEVICES ioctl request.
+	DMListDevices = dmIoctlRequest(dmListDevicesCmd)
+	// DMDevStatus is the DM_DEV_STATUS ioctl request.
+	DMDevStatus = dmIoctlRequest(dmDevStatusCmd)
+	// DMTableStatus is the DM_TABLE_STATUS ioctl request.
+	DMTableStatus = dmIoctlRequest(dmTableStatusCmd)
+)
+
+// dmIoctlRequest encodes _IOWR(DM_IOCTL, cmd, struct dm_ioctl).
+func dmIoctlRequest(cmd uintptr) uintptr {
+	return 3<<30 | dmHeaderSize<<16 | dmIoctlType<<8 | cmd
+}
+
+// encodeDev encodes major and minor numbers like makedev(3).
+func encodeDev(major, minor int64) uint64 {
+	ma, mi := uint64(major), uint64(minor)
+	return (ma&0xfffff000)<<32 | (ma&0xfff)<<8 | (mi&0xffffff00)<<12 | mi&0xff
+}
+
+// decodeDev decodes a device number into major and minor numbers like major(3) and minor(3).
+func decodeDev(dev uint64) (int64, int64) {
+	major := (dev>>8)&0xfff | (dev>>32)&^uint64(0xfff)
+	minor := dev&0xff | (dev>>12)&^uint64(0xff)
+	return int64(major & 0xffffffff), int64(minor & 0xffffffff)
+}
+
+type ioctlClient struct {
+	control ControlDevice
+}
+
+// NewIoctlClient returns a Client that queries device-mapper through the ioctl interface of the control device
+// instead of spawning dmsetup. It only supports read-only queries.
+func NewIoctlClient(control ControlDevice) Client {
+	return &ioctlClient{control: control}
+}
+
+// Close closes the control device of the client.
+func (c *ioctlClient) Close() error {
+	return c.control.Close()
+}
+
+func (c *ioctlClient) List(ctx context.Context) ([]Device, error) {
+	buf, err := c.ioctl(ctx, DMListDevices, Device{}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNameList(buf)
+}
+
+func (c *ioctlClient) Info(ctx context.Context, dev Device) (*Info, error) {
+	if _, err := dev.args(); err != nil {
+		return nil, err
+	}
+	buf, err := c.ioctl(ctx, DMDevStatus, dev, 0)
+	if err != nil {
+		return nil, err
+	}
+	return decodeInfo(buf), nil
+}
+
+func (c *ioctlClient) Status(ctx context.Context, dev Device) ([]Target, error) {
+	return c.targets(ctx, dev, 0)
+}
+
+func (c *ioctlClient) Table(ctx context.Context, dev Device) ([]Target, error) {
+	return c.targets(ctx, dev, dmStatusTableFlag)
+}
+
+func (c *ioctlClient) targets(ctx context.Context, dev Device, flags uint32) ([]Target, error) {
+	if _, err := dev.args(); err != nil {
+		return nil, err
+	}
+	buf, err := c.ioctl(ctx, DMTableStatus, dev, flags)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := decodeTargetSpecs(buf)
+	if err != nil {
+		return nil, err
+	}
+	if flags&dmStatusTableFlag == 0 {
+		for i := range targets {
+			if targets[i].Status, err = ParseTargetStatus(targets[i].Type, targets[i].Params); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return targets, nil
+}
+
+// ioctl executes the request and grows the buffer until the result fits.
+func (c *ioctlClient) ioctl(ctx context.Context, request uintptr, dev Device, flags uint32) ([]byte, error) {
+	for size := dmInitialBufferSize; size <= dmMaxBufferSize; size *= 2 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size)
+		if err := encodeHeader(buf, dev, flags); err != nil {
+			return nil, err
+		}
+		if err := c.control.Ioctl(request, buf); err != nil {
+			if errors.Is(err, syscall.ENXIO) {
+				return nil, fmt.Errorf("%w: %s", ErrDeviceNotFound, dev)
+			}
+			return nil, fmt.Errorf("device-mapper ioctl %#x failed: %w", request, err)
+		}
+		if binary.NativeEndian.Uint32(buf[dmOffsetFlags:])&dmBufferFullFlag == 0 {
+			return buf, nil
+		}
+	}
+	return nil, fmt.Errorf("device-mapper ioctl %#x result exceeds %d bytes", request, dmMaxBufferSize)
+}
+
+func encodeHeader(buf []byte, dev Device, flags uint32) error {
+	if len(dev.Name) >= dmNameLen {
+		return fmt.Errorf("device name %q exceeds %d characters", dev.Name, dmNameLen-1)
+	}
+	binary.NativeEndian.PutUint32(buf[0:], dmVersionMajor)
+	binary.NativeEndian.PutUint32(buf[dmOffsetDataSize:], uint32(len(buf)))
+	binary.NativeEndian.PutUint32(buf[dmOffsetDataStart:], dmHeaderSize)
+	binary.NativeEndian.PutUint32(buf[dmOffsetFlags:], flags)
+	if dev.Major > 0 || dev.Minor > 0 {
+		binary.NativeEndian.PutUint64(buf[dmOffsetDev:], encodeDev(dev.Major, dev.Minor))
+	} else {
+		copy(buf[dmOffsetName:dmOffsetName+dmNameLen], dev.Name)
+	}
+	return nil
+}
+
+func decodeInfo(buf []byte) *Info {
+	flags := binary.NativeEndian.Uint32(buf[dmOffsetFlags:])
+	info := &Info{
+		Name:          cString(buf[dmOffsetName : dmOffsetName+dmNameLen]),
+		UUID:          cString(buf[dmOffsetUUID : dmOffsetUUID+dmUUIDLen]),
+		LiveTable:     flags&dmActivePresentFlag != 0,
+		InactiveTable: flags&dmInactivePresentFlag != 0,
+		Suspended:     flags&dmSuspendFlag != 0,
+		ReadOnly:      flags&dmReadOnlyFlag != 0,
+		OpenCount:     int64(int32(binary.NativeEndian.Uint32(buf[dmOffsetOpenCount:]))),
+		TargetCount:   int64(binary.NativeEndian.Uint32(buf[dmOffsetTargetCount:])),
+		EventNumber:   int64(binary.NativeEndian.Uint32(buf[dmOffsetEventNr:])),
+	}
+	info.Major, info.Minor = decodeDev(binary.NativeEndian.Uint64(buf[dmOffsetDev:]))
+	return info
+}
+
+// data returns the data area of the result in buf.
+func data(buf []byte) ([]byte, error) {
+	start := binary.NativeEndian.Uint32(buf[dmOffsetDataStart:])
+	size := binary.NativeEndian.Uint32(buf[dmOffsetDataSize:])
+	if start < dmHeaderSize || size < start || int(size) > len(buf) {
+		return nil, fmt.Errorf("invalid device-mapper result data area %d-%d", start, size)
+	}
+	return buf[start:size], nil
+}
+
+// decodeNameList decodes the struct dm_name_list entries of DM_LIST_DEVICES.
+func decodeNameList(buf []byte) ([]Device, error) {
+	names, err := data(buf)
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for offset := 0; offset+dmNameListEntrySize <= len(names); {
+		entry := names[offset:]
+		dev := binary.NativeEndian.Uint64(entry)
+		if dev == 0 {
+			break
+		}
+		device := Device{Name: cString(entry[dmNameListEntrySize:])}
+		device.Major, device.Minor = decodeDev(dev)
+		devices = append(devices, device)
+
+		next := binary.NativeEndian.Uint32(entry[8:])
+		if next == 0 {
+			break
+		}
+		offset += int(next)
+	}
+	return devices, nil
+}
+
+// decodeTargetSpecs decodes the struct dm_target_spec entries of DM_TABLE_STATUS.
+func decodeTargetSpecs(buf []byte) ([]Target, error) {
+	specs, err := data(buf)
+	if err != nil {
+		return nil, err
+	}
+	count := int(binary.NativeEndian.Uint32(buf[dmOffsetTargetCount:]))
+	targets := make([]Target, 0, count)
+	for i, offset := 0, 0; i < count; i++ {
+		if offset+dmTargetSpecSize > len(specs) {
+			return nil, fmt.Errorf("device-mapper target %d exceeds the result data area", i)
+		}
+		spec := specs[offset:]
+		targets = append(targets, Target{
+			Start:  binary.NativeEndian.Uint64(spec[0:]),
+			Length: binary.NativeEndian.Uint64(spec[8:]),
+			Type:   TargetType(cString(spec[24 : 24+dmMaxTypeName])),
+			Params: cString(spec[dmTargetSpecSize:]),
+		})
+		offset = int(binary.NativeEndian.Uint32(spec[20:]))
+	}
+	return targets, nil
+}
+
+func cString(b []byte) string {
+	if idx := bytes.IndexByte(b, 0); idx >= 0 {
+		return string(b[:idx])
+	}
+	return string(b)
+}
+
+// FakeDevice is a device served by FakeControlDevice.
+type FakeDevice struct {
+	Info Info
+	// Status and Table are the targets returned for DM_TABLE_STATUS with and without DM_STATUS_TABLE_FLAG.
+	Status []Target
+	Table  []Target
+}
+
+// FakeControlDevice is a ControlDevice that serves DM_LIST_DEVICES, DM_DEV_STATUS and DM_TABLE_STATUS
+// for a set of fake devices, e.g. for tests.
+type FakeControlDevice struct {
+	mu      sync.Mutex
+	devices []FakeDevice
+	// Calls counts the ioctls executed per request.
+	Calls map[uintptr]int
+}
+
+var _ ControlDevice = &FakeControlDevice{}
+
+// NewFakeControlDevice creates a FakeControlDevice serving the given devices.
+func NewFakeControlDevice(devices ...FakeDevice) *FakeControlDevice {
+	return &FakeControlDevice{devices: devices, Calls: make(map[uintptr]int)}
+}
+
+func (f *FakeControlDevice) Ioctl(request uintptr, buf []byte) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.Calls[request]++
+
+	if len(buf) < dmHeaderSize || binary.NativeEndian.Uint32(buf[0:]) != dmVersionMajor {
+		return syscall.EINVAL
+	}
+
+	if request == DMListDevices {
+		return f.listDevices(buf)
+	}
+
+	var dev *FakeDevice
+	name := cString(buf[dmOffsetName : dmOffsetName+dmNameLen])
+	major, minor := decodeDev(binary.NativeEndian.Uint64(buf[dmOffsetDev:]))
+	for i := range f.devices {
+		info := f.devices[i].Info
+		if (name != "" && info.Name == name) || (name == "" && info.Major == major && info.Minor == minor) {
+			dev = &f.devices[i]
+			break
+		}
+	}
+	if dev == nil {
+		return syscall.ENXIO
+	}
+
+	switch request {
+	case DMDevStatus:
+		f.writeInfo(buf, dev, 0)
+		return nil
+	case DMTableStatus:
+		targets := dev.Status
+		if binary.NativeEndian.Uint32(buf[dmOffsetFlags:])&dmStatusTableFlag != 0 {
+			targets = dev.Table
+		}
+		return f.writeTargets(buf, dev, targets)
+	}
+	return syscall.ENOTTY
+}
+
+func (f *FakeControlDevice) Close() error {
+	return nil
+}
+
+func (f *FakeControlDevice) writeInfo(buf []byte, dev *FakeDevice, targets int) {
+	var flags uint32
+	for flag, set := range map[uint32]bool{
+		dmActivePresentFlag:   dev.Info.LiveTable,
+		dmInactivePresentFlag: dev.Info.InactiveTable,
+		dmSuspendFlag:         dev.Info.Suspended,
+		dmReadOnlyFlag:        dev.Info.ReadOnly,
+	} {
+		if set {
+			flags |= flag
+		}
+	}
+	flags |= binary.NativeEndian.Uint32(buf[dmOffsetFlags:]) & dmStatusTableFlag
+	if targets == 0 {
+		targets = int(dev.Info.TargetCount)
+	}
+	binary.NativeEndian.PutUint32(buf[dmOffsetFlags:], flags)
+	binary.NativeEndian.PutUint32(buf[dmOffsetOpenCount:], uint32(dev.Info.OpenCount))
+	binary.NativeEndian.PutUint32(buf[dmOffsetTargetCount:], uint32(targets))
+	binary.NativeEndian.PutUint32(buf[dmOffsetEventNr:], uint32(dev.Info.EventNumber))
+	binary.NativeEndian.PutUint64(buf[dmOffsetDev:], encodeDev(dev.Info.Major, dev.Info.Minor))
+	copy(buf[dmOffsetName:dmOffsetName+dmNameLen-1], dev.Info.Name)
+	copy(buf[dmOffsetUUID:dmOffsetUUID+dmUUIDLen-1], dev.Info.UUID)
+	binary.NativeEndian.PutUint32(buf[dmOffsetDataSize:], dmHeaderSize)
+}
+
+func (f *FakeControlDevice) writeTargets(buf []byte, dev *FakeDevice, targets []Target) error {
+	out := bytes.Buffer{}
+	for _, target := range targets {
+		spec := make([]byte, dmTargetSpecSize)
+		binary.NativeEndian.PutUint64(spec[0:], target.Start)
+		binary.NativeEndian.PutUint64(spec[8:], target.Length)
+		copy(spec[24:24+dmMaxTypeName-1], target.Type)
+		entry := append(spec, append([]byte(target.Params), 0)...)
+		next := align8(out.Len() + len(entry))
+		binary.NativeEndian.PutUint32(entry[20:], uint32(next))
+		out.Write(entry)
+		out.Write(make([]byte, next-out.Len()))
+	}
+	f.writeInfo(buf, dev, len(targets))
+	return f.writeData(buf, out.Bytes())
+}
+
+func (f *FakeControlDevice) listDevices(buf []byte) error {
+	out := bytes.Buffer{}
+	for i, dev := range f.devices {
+		entry := make([]byte, dmNameListEntrySize, dmNameListEntrySize+len(dev.Info.Name)+1)
+		binary.NativeEndian.PutUint64(entry, encodeDev(dev.Info.Major, dev.Info.Minor))
+		entry = append(entry, append([]byte(dev.Info.Name), 0)...)
+		if i < len(f.devices)-1 {
+			binary.NativeEndian.PutUint32(entry[8:], uint32(align8(len(entry))))
+		}
+		out.Write(entry)
+		out.Write(make([]byte, align8(len(entry))-len(entry)))
+	}
+	if out.Len() == 0 {
+		// a zero device number flags that there are no devices
+		out.Write(make([]byte, dmNameListEntrySize))
+	}
+	return f.writeData(buf, out.Bytes())
+}
+
+func (f *FakeControlDevice) writeData(buf []byte, data []byte) error {
+	if dmHeaderSize+len(data) > len(buf) {
+		flags := binary.NativeEndian.Uint32(buf[dmOffsetFlags:])
+		binary.NativeEndian.PutUint32(buf[dmOffsetFlags:], flags|dmBufferFullFlag)
+		return nil
+	}
+	copy(buf[dmHeaderSize:], data)
+	binary.NativeEndian.PutUint32(buf[dmOffsetDataSize:], uint32(dmHeaderSize+len(data)))
+	binary.NativeEndian.PutUint32(buf[dmOffsetDataStart:], dmHeaderSize)
+	return nil
+}
+
+func align8(n int) int {
+	return (n + 7) &^ 7
+}
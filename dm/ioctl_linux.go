@@ -0,0 +1,57 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+type controlDevice struct {
+	file *os.File
+}
+
+// OpenControlDevice opens the device-mapper control device for ioctls, usually DefaultControlPath.
+func OpenControlDevice(path string) (ControlDevice, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &controlDevice{file: file}, nil
+}
+
+func (c *controlDevice) Ioctl(request uintptr, buf []byte) error {
+	conn, err := c.file.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var errno syscall.Errno
+	if err := conn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, request, uintptr(unsafe.Pointer(&buf[0])))
+	}); err != nil {
+		return err
+	}
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func (c *controlDevice) Close() error {
+	return c.file.Close()
+}
@@ -0,0 +1,245 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/jakobmoellerdev/lvm2go"
+	. "github.com/jakobmoellerdev/lvm2go/dm"
+)
+
+func fakeDevices(t testing.TB) []FakeDevice {
+	t.Helper()
+	status, err := ParseStatus(strings.NewReader("0 204800 thin-pool 1 12/4096 30/1600 - rw discard_passdown queue_if_no_space - 1024"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	table, err := ParseTable(strings.NewReader("0 204800 thin-pool 253:1 253:2 128 0 1 skip_block_zeroing"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range status {
+		status[i].Status = nil
+	}
+	return []FakeDevice{
+		{
+			Info: Info{
+				Name: "vg-pool-tpool", UUID: "LVM-abc-tpool", Major: 253, Minor: 3,
+				LiveTable: true, OpenCount: 1, TargetCount: 1, EventNumber: 2,
+			},
+			Status: status,
+			Table:  table,
+		},
+		{
+			Info: Info{
+				Name: "vg-lv", UUID: "LVM-abc", Major: 253, Minor: 4096,
+				LiveTable: true, ReadOnly: true, Suspended: true, TargetCount: 1,
+			},
+			Status: []Target{{Start: 0, Length: 8192, Type: TargetTypeLinear}},
+			Table:  []Target{{Start: 0, Length: 8192, Type: TargetTypeLinear, Params: "7:0 2048"}},
+		},
+	}
+}
+
+func TestIoctlClient(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	devices := fakeDevices(t)
+	clnt := NewIoctlClient(NewFakeControlDevice(devices...))
+
+	list, err := clnt.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Device{{Name: "vg-pool-tpool", Major: 253, Minor: 3}, {Name: "vg-lv", Major: 253, Minor: 4096}}
+	if !reflect.DeepEqual(list, expected) {
+		t.Fatalf("expected %v, got %v", expected, list)
+	}
+
+	for _, dev := range []Device{{Name: "vg-lv"}, {Major: 253, Minor: 4096}} {
+		info, err := clnt.Info(ctx, dev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(*info, devices[1].Info) {
+			t.Fatalf("expected %+v, got %+v", devices[1].Info, *info)
+		}
+	}
+
+	status, err := clnt.Status(ctx, Device{Name: "vg-pool-tpool"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status) != 1 || status[0].Length != 204800 {
+		t.Fatalf("unexpected status %+v", status)
+	}
+	pool, ok := status[0].Status.(*ThinPoolStatus)
+	if !ok {
+		t.Fatalf("expected thin pool status, got %T", status[0].Status)
+	}
+	if pool.DataPercent() != 30.0/1600*100 {
+		t.Fatalf("unexpected data percent %v", pool.DataPercent())
+	}
+
+	table, err := clnt.Table(ctx, Device{Major: 253, Minor: 4096})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(table, devices[1].Table) {
+		t.Fatalf("expected %+v, got %+v", devices[1].Table, table)
+	}
+
+	if _, err := clnt.Info(ctx, Device{Name: "missing"}); !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("expected device not found, got %v", err)
+	}
+	if _, err := clnt.Table(ctx, Device{}); !errors.Is(err, ErrDeviceRequired) {
+		t.Fatalf("expected device required, got %v", err)
+	}
+}
+
+func TestIoctlClientEmptyList(t *testing.T) {
+	t.Parallel()
+	list, err := NewIoctlClient(NewFakeControlDevice()).List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 0 {
+		t.Fatalf("expected no devices, got %v", list)
+	}
+}
+
+func TestIoctlClientBufferFull(t *testing.T) {
+	t.Parallel()
+	params := strings.Repeat("x", 20*1024)
+	control := NewFakeControlDevice(FakeDevice{
+		Info:  Info{Name: "big", Major: 253, Minor: 1, LiveTable: true, TargetCount: 1},
+		Table: []Target{{Length: 8, Type: TargetTypeLinear, Params: params}},
+	})
+	table, err := NewIoctlClient(control).Table(context.Background(), Device{Name: "big"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table) != 1 || table[0].Params != params {
+		t.Fatalf("unexpected table %+v", table)
+	}
+	if calls := control.Calls[DMTableStatus]; calls != 2 {
+		t.Fatalf("expected a retry with a larger buffer, got %d calls", calls)
+	}
+}
+
+type failingClient struct{ err error }
+
+func (c failingClient) List(context.Context) ([]Device, error) { return nil, c.err }
+func (c failingClient) Info(context.Context, Device) (*Info, error) {
+	return nil, c.err
+}
+func (c failingClient) Status(context.Context, Device) ([]Target, error) { return nil, c.err }
+func (c failingClient) Table(context.Context, Device) ([]Target, error)  { return nil, c.err }
+func (c failingClient) Close() error                                     { return c.err }
+
+func TestFallbackClient(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	fallback := NewIoctlClient(NewFakeControlDevice(fakeDevices(t)...))
+
+	clnt := NewFallbackClient(failingClient{errors.New("permission denied")}, fallback)
+	if info, err := clnt.Info(ctx, Device{Name: "vg-lv"}); err != nil || info.Name != "vg-lv" {
+		t.Fatalf("expected fallback to be used, got %v, %v", info, err)
+	}
+
+	clnt = NewFallbackClient(failingClient{ErrDeviceNotFound}, fallback)
+	if _, err := clnt.Info(ctx, Device{Name: "vg-lv"}); !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("expected device not found without fallback, got %v", err)
+	}
+	if err := clnt.Close(); !errors.Is(err, ErrDeviceNotFound) {
+		t.Fatalf("expected both clients to be closed, got %v", err)
+	}
+}
+
+func TestParseList(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		out      string
+		expected []Device
+		err      bool
+	}{
+		{name: "colon", out: "vg-lv\t(253:0)\nvg-pool\t(253:1)\n", expected: []Device{{Name: "vg-lv", Major: 253, Minor: 0}, {Name: "vg-pool", Major: 253, Minor: 1}}},
+		{name: "comma", out: "vg-lv\t(253, 0)\n", expected: []Device{{Name: "vg-lv", Major: 253, Minor: 0}}},
+		{name: "empty", out: "No devices found\n"},
+		{name: "invalid", out: "vg-lv\n", err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			devices, err := ParseList(strings.NewReader(tc.out))
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if !reflect.DeepEqual(devices, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, devices)
+			}
+		})
+	}
+}
+
+// BenchmarkClient compares the ioctl backend with the dmsetup backend.
+// The ioctl backend is skipped if the control device cannot be opened.
+func BenchmarkClient(b *testing.B) {
+	ctx := context.Background()
+	control, controlErr := OpenControlDevice(DefaultControlPath)
+	if controlErr == nil {
+		defer control.Close()
+	}
+
+	for _, bc := range []struct {
+		name   string
+		client Client
+	}{
+		{name: "ioctl", client: NewIoctlClient(control)},
+		{name: "exec", client: NewExecClient()},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			if bc.name == "ioctl" && controlErr != nil {
+				b.Skipf("%s is not available: %v", DefaultControlPath, controlErr)
+			}
+			if bc.name == "exec" {
+				if _, err := exec.LookPath(lvm2go.GetToolPath(ToolDMSetup)); err != nil {
+					b.Skipf("%s is not available: %v", ToolDMSetup, err)
+				}
+			}
+			devices, err := bc.client.List(ctx)
+			if err != nil {
+				b.Skipf("cannot list devices: %v", err)
+			}
+			if len(devices) == 0 {
+				b.Skip("no device-mapper devices")
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := bc.client.Status(ctx, devices[i%len(devices)]); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
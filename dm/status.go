@@ -0,0 +1,499 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jakobmoellerdev/lvm2go"
+)
+
+// TargetStatus is the parsed status of a device-mapper target.
+type TargetStatus interface {
+	TargetType() TargetType
+}
+
+// ParseTargetStatus parses the status of a target of the given type.
+// For target types without a typed status, nil is returned.
+func ParseTargetStatus(targetType TargetType, params string) (TargetStatus, error) {
+	var status TargetStatus
+	var err error
+	switch targetType {
+	case TargetTypeThinPool:
+		status, err = ParseThinPoolStatus(params)
+	case TargetTypeThin:
+		status, err = ParseThinStatus(params)
+	case TargetTypeCache:
+		status, err = ParseCacheStatus(params)
+	case TargetTypeRAID:
+		status, err = ParseRAIDStatus(params)
+	case TargetTypeVDO:
+		status, err = ParseVDOStatus(params)
+	case TargetTypeSnapshot, TargetTypeSnapshotMerge:
+		status, err = ParseSnapshotStatus(params)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %s status: %w", params, targetType, err)
+	}
+	return status, nil
+}
+
+// ThinPoolMode is the operating mode of a thin pool.
+type ThinPoolMode string
+
+const (
+	ThinPoolModeReadWrite      ThinPoolMode = "rw"
+	ThinPoolModeReadOnly       ThinPoolMode = "ro"
+	ThinPoolModeOutOfDataSpace ThinPoolMode = "out_of_data_space"
+)
+
+// ThinPoolStatus is the status of a thin-pool target.
+type ThinPoolStatus struct {
+	TransactionID       uint64
+	UsedMetadataBlocks  uint64
+	TotalMetadataBlocks uint64
+	UsedDataBlocks      uint64
+	TotalDataBlocks     uint64
+	// HeldMetadataRoot is the metadata root held for userspace access or "-" if none is held.
+	HeldMetadataRoot string
+	Mode             ThinPoolMode
+	DiscardPassdown  bool
+	ErrorIfNoSpace   bool
+	// NeedsCheck is true if the metadata has to be repaired with thin_check before the pool can be written again.
+	NeedsCheck           bool
+	MetadataLowWatermark uint64
+	// Fail is true if the pool encountered a failure and no I/O is permitted.
+	Fail bool
+}
+
+func (s *ThinPoolStatus) TargetType() TargetType {
+	return TargetTypeThinPool
+}
+
+// DataPercent returns the used data blocks in percent.
+func (s *ThinPoolStatus) DataPercent() float64 {
+	return percent(s.UsedDataBlocks, s.TotalDataBlocks)
+}
+
+// MetadataPercent returns the used metadata blocks in percent.
+func (s *ThinPoolStatus) MetadataPercent() float64 {
+	return percent(s.UsedMetadataBlocks, s.TotalMetadataBlocks)
+}
+
+// ParseThinPoolStatus parses "<transaction id> <used>/<total metadata blocks> <used>/<total data blocks>
+// <held metadata root> ro|rw|out_of_data_space [no_]discard_passdown error|queue_if_no_space needs_check|- [<metadata low watermark>]".
+func ParseThinPoolStatus(params string) (*ThinPoolStatus, error) {
+	if isFail(params) {
+		return &ThinPoolStatus{Fail: true}, nil
+	}
+	fields := strings.Fields(params)
+	if len(fields) < 8 {
+		return nil, fmt.Errorf("expected at least 8 fields but got %d", len(fields))
+	}
+	status := &ThinPoolStatus{
+		HeldMetadataRoot: fields[3],
+		Mode:             ThinPoolMode(fields[4]),
+		DiscardPassdown:  fields[5] == "discard_passdown",
+		ErrorIfNoSpace:   fields[6] == "error_if_no_space",
+		NeedsCheck:       fields[7] == "needs_check",
+	}
+	var err error
+	if status.TransactionID, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return nil, err
+	}
+	if status.UsedMetadataBlocks, status.TotalMetadataBlocks, err = parseRatio(fields[1]); err != nil {
+		return nil, err
+	}
+	if status.UsedDataBlocks, status.TotalDataBlocks, err = parseRatio(fields[2]); err != nil {
+		return nil, err
+	}
+	if len(fields) > 8 {
+		if status.MetadataLowWatermark, err = strconv.ParseUint(fields[8], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// ThinStatus is the status of a thin target.
+type ThinStatus struct {
+	// MappedSectors is the number of sectors that are actually allocated in the thin pool.
+	MappedSectors uint64
+	// HighestMappedSector is the last mapped sector or -1 if nothing is mapped.
+	HighestMappedSector int64
+	// Fail is true if the thin pool of the volume failed and no I/O is permitted.
+	Fail bool
+}
+
+func (s *ThinStatus) TargetType() TargetType {
+	return TargetTypeThin
+}
+
+// MappedSize returns the size that is actually allocated in the thin pool.
+func (s *ThinStatus) MappedSize() lvm2go.Size {
+	return lvm2go.NewSize(float64(s.MappedSectors*SectorSize), lvm2go.UnitBytes)
+}
+
+// ParseThinStatus parses "<nr mapped sectors> <highest mapped sector>".
+func ParseThinStatus(params string) (*ThinStatus, error) {
+	if isFail(params) {
+		return &ThinStatus{Fail: true, HighestMappedSector: -1}, nil
+	}
+	fields := strings.Fields(params)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected 2 fields but got %d", len(fields))
+	}
+	status := &ThinStatus{HighestMappedSector: -1}
+	var err error
+	if status.MappedSectors, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return nil, err
+	}
+	if fields[1] != "-" {
+		if status.HighestMappedSector, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+// CacheStatus is the status of a cache target.
+type CacheStatus struct {
+	// MetadataBlockSize and CacheBlockSize are in sectors.
+	MetadataBlockSize   uint64
+	UsedMetadataBlocks  uint64
+	TotalMetadataBlocks uint64
+	CacheBlockSize      uint64
+	UsedCacheBlocks     uint64
+	TotalCacheBlocks    uint64
+	ReadHits            uint64
+	ReadMisses          uint64
+	WriteHits           uint64
+	WriteMisses         uint64
+	Demotions           uint64
+	Promotions          uint64
+	DirtyBlocks         uint64
+
+	Features   []string
+	CoreArgs   []string
+	Policy     string
+	PolicyArgs []string
+
+	// MetadataMode is rw or ro.
+	MetadataMode string
+	NeedsCheck   bool
+	// Fail is true if the cache encountered a failure and no I/O is permitted.
+	Fail bool
+}
+
+func (s *CacheStatus) TargetType() TargetType {
+	return TargetTypeCache
+}
+
+// ParseCacheStatus parses "<metadata block size> <used>/<total metadata blocks> <cache block size>
+// <used>/<total cache blocks> <read hits> <read misses> <write hits> <write misses> <demotions> <promotions>
+// <dirty> <#features> <features>* <#core args> <core args>* <policy name> <#policy args> <policy args>*
+// <cache metadata mode> <needs_check>".
+func ParseCacheStatus(params string) (*CacheStatus, error) {
+	if isFail(params) {
+		return &CacheStatus{Fail: true}, nil
+	}
+	fields := strings.Fields(params)
+	if len(fields) < 12 {
+		return nil, fmt.Errorf("expected at least 12 fields but got %d", len(fields))
+	}
+	status := &CacheStatus{}
+	var err error
+	if status.MetadataBlockSize, err = strconv.ParseUint(fields[0], 10, 64); err != nil {
+		return nil, err
+	}
+	if status.UsedMetadataBlocks, status.TotalMetadataBlocks, err = parseRatio(fields[1]); err != nil {
+		return nil, err
+	}
+	if status.CacheBlockSize, err = strconv.ParseUint(fields[2], 10, 64); err != nil {
+		return nil, err
+	}
+	if status.UsedCacheBlocks, status.TotalCacheBlocks, err = parseRatio(fields[3]); err != nil {
+		return nil, err
+	}
+	for i, fieldPtr := range []*uint64{
+		&status.ReadHits, &status.ReadMisses, &status.WriteHits, &status.WriteMisses,
+		&status.Demotions, &status.Promotions, &status.DirtyBlocks,
+	} {
+		if *fieldPtr, err = strconv.ParseUint(fields[4+i], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+
+	rest := fields[11:]
+	if status.Features, rest, err = parseCountedArgs(rest); err != nil {
+		return nil, fmt.Errorf("invalid features: %w", err)
+	}
+	if status.CoreArgs, rest, err = parseCountedArgs(rest); err != nil {
+		return nil, fmt.Errorf("invalid core args: %w", err)
+	}
+	if len(rest) == 0 {
+		return nil, fmt.Errorf("missing policy")
+	}
+	status.Policy = rest[0]
+	if status.PolicyArgs, rest, err = parseCountedArgs(rest[1:]); err != nil {
+		return nil, fmt.Errorf("invalid policy args: %w", err)
+	}
+	if len(rest) > 0 {
+		status.MetadataMode = rest[0]
+	}
+	if len(rest) > 1 {
+		status.NeedsCheck = rest[1] == "needs_check"
+	}
+	return status, nil
+}
+
+// RAIDDeviceHealth is the health of a single RAID device.
+type RAIDDeviceHealth rune
+
+const (
+	// RAIDDeviceHealthAlive is an alive and in-sync device.
+	RAIDDeviceHealthAlive RAIDDeviceHealth = 'A'
+	// RAIDDeviceHealthAliveNotInSync is an alive device that is not in-sync yet.
+	RAIDDeviceHealthAliveNotInSync RAIDDeviceHealth = 'a'
+	// RAIDDeviceHealthDead is a dead or failed device.
+	RAIDDeviceHealthDead RAIDDeviceHealth = 'D'
+	// RAIDDeviceHealthJournal is an alive write journal device.
+	RAIDDeviceHealthJournal RAIDDeviceHealth = 'j'
+)
+
+// RAIDStatus is the status of a raid target.
+type RAIDStatus struct {
+	RAIDType string
+	Devices  int
+	// Health contains a RAIDDeviceHealth for every device.
+	Health        []RAIDDeviceHealth
+	SyncedSectors uint64
+	TotalSectors  uint64
+	// SyncAction is the current sync action, e.g. idle, frozen, resync, recover, check, repair or reshape.
+	SyncAction    string
+	MismatchCount uint64
+	DataOffset    uint64
+	// Journal is the health of the write journal or cache device, "-" if there is none.
+	Journal string
+}
+
+func (s *RAIDStatus) TargetType() TargetType {
+	return TargetTypeRAID
+}
+
+// DeadDevices returns the number of dead or failed devices.
+func (s *RAIDStatus) DeadDevices() int {
+	count := 0
+	for _, health := range s.Health {
+		if health == RAIDDeviceHealthDead {
+			count++
+		}
+	}
+	return count
+}
+
+// InSync returns true if all devices are in-sync.
+func (s *RAIDStatus) InSync() bool {
+	return s.SyncedSectors == s.TotalSectors && s.DeadDevices() == 0
+}
+
+// SyncPercent returns the synchronized sectors in percent.
+func (s *RAIDStatus) SyncPercent() float64 {
+	return percent(s.SyncedSectors, s.TotalSectors)
+}
+
+// ParseRAIDStatus parses "<raid type> <#raid disks> <health chars> <sync ratio> <sync action> <mismatch count>
+// [<data offset> [<journal char>]]".
+func ParseRAIDStatus(params string) (*RAIDStatus, error) {
+	fields := strings.Fields(params)
+	if len(fields) < 4 {
+		return nil, fmt.Errorf("expected at least 4 fields but got %d", len(fields))
+	}
+	status := &RAIDStatus{RAIDType: fields[0], Journal: "-"}
+	var err error
+	if status.Devices, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, err
+	}
+	for _, health := range fields[2] {
+		status.Health = append(status.Health, RAIDDeviceHealth(health))
+	}
+	if status.SyncedSectors, status.TotalSectors, err = parseRatio(fields[3]); err != nil {
+		return nil, err
+	}
+	if len(fields) > 4 {
+		status.SyncAction = fields[4]
+	}
+	if len(fields) > 5 {
+		if status.MismatchCount, err = strconv.ParseUint(fields[5], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) > 6 {
+		if status.DataOffset, err = strconv.ParseUint(fields[6], 10, 64); err != nil {
+			return nil, err
+		}
+	}
+	if len(fields) > 7 {
+		status.Journal = fields[7]
+	}
+	return status, nil
+}
+
+// VDOStatus is the status of a vdo target.
+type VDOStatus struct {
+	Device string
+	// OperatingMode is normal, recovering or read-only.
+	OperatingMode       string
+	InRecovery          bool
+	IndexState          string
+	CompressionState    string
+	UsedPhysicalBlocks  uint64
+	TotalPhysicalBlocks uint64
+}
+
+func (s *VDOStatus) TargetType() TargetType {
+	return TargetTypeVDO
+}
+
+// ReadOnly returns true if VDO entered read-only mode after an unrecoverable error.
+func (s *VDOStatus) ReadOnly() bool {
+	return s.OperatingMode == "read-only"
+}
+
+// UsedPercent returns the used physical blocks in percent.
+func (s *VDOStatus) UsedPercent() float64 {
+	return percent(s.UsedPhysicalBlocks, s.TotalPhysicalBlocks)
+}
+
+// ParseVDOStatus parses "<device> <operating mode> <in recovery> <index state> <compression state>
+// <physical blocks used> <total physical blocks>".
+func ParseVDOStatus(params string) (*VDOStatus, error) {
+	fields := strings.Fields(params)
+	if len(fields) != 7 {
+		return nil, fmt.Errorf("expected 7 fields but got %d", len(fields))
+	}
+	status := &VDOStatus{
+		Device:           fields[0],
+		OperatingMode:    fields[1],
+		InRecovery:       fields[2] == "recovering",
+		IndexState:       fields[3],
+		CompressionState: fields[4],
+	}
+	var err error
+	if status.UsedPhysicalBlocks, err = strconv.ParseUint(fields[5], 10, 64); err != nil {
+		return nil, err
+	}
+	if status.TotalPhysicalBlocks, err = strconv.ParseUint(fields[6], 10, 64); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SnapshotStatus is the status of a snapshot or snapshot-merge target.
+type SnapshotStatus struct {
+	AllocatedSectors uint64
+	TotalSectors     uint64
+	MetadataSectors  uint64
+	// Invalid is true if the snapshot was invalidated, e.g. because it ran out of space.
+	Invalid bool
+	// Overflow is true if the snapshot ran out of space while merging.
+	Overflow    bool
+	MergeFailed bool
+}
+
+func (s *SnapshotStatus) TargetType() TargetType {
+	return TargetTypeSnapshot
+}
+
+// UsedPercent returns the allocated sectors of the snapshot in percent.
+func (s *SnapshotStatus) UsedPercent() float64 {
+	return percent(s.AllocatedSectors, s.TotalSectors)
+}
+
+// ParseSnapshotStatus parses "<allocated sectors>/<total sectors> <metadata sectors>",
+// "Invalid", "Overflow" or "Merge failed".
+func ParseSnapshotStatus(params string) (*SnapshotStatus, error) {
+	switch strings.TrimSpace(params) {
+	case "Invalid":
+		return &SnapshotStatus{Invalid: true}, nil
+	case "Overflow":
+		return &SnapshotStatus{Overflow: true}, nil
+	case "Merge failed":
+		return &SnapshotStatus{MergeFailed: true}, nil
+	}
+	fields := strings.Fields(params)
+	if len(fields) != 2 {
+		return nil, fmt.Errorf("expected 2 fields but got %d", len(fields))
+	}
+	status := &SnapshotStatus{}
+	var err error
+	if status.AllocatedSectors, status.TotalSectors, err = parseRatio(fields[0]); err != nil {
+		return nil, err
+	}
+	if status.MetadataSectors, err = strconv.ParseUint(fields[1], 10, 64); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func isFail(params string) bool {
+	return strings.TrimSpace(params) == "Fail"
+}
+
+func parseRatio(raw string) (uint64, uint64, error) {
+	used, total, found := strings.Cut(raw, "/")
+	if !found {
+		return 0, 0, fmt.Errorf("%q is not a ratio", raw)
+	}
+	usedValue, err := strconv.ParseUint(used, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	totalValue, err := strconv.ParseUint(total, 10, 64)
+	if err != nil {
+		return 0, 0, err
+	}
+	return usedValue, totalValue, nil
+}
+
+func parseCountedArgs(fields []string) ([]string, []string, error) {
+	if len(fields) == 0 {
+		return nil, nil, fmt.Errorf("missing argument count")
+	}
+	count, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, nil, err
+	}
+	if count < 0 || len(fields) < count+1 {
+		return nil, nil, fmt.Errorf("expected %d arguments but got %d", count, len(fields)-1)
+	}
+	if count == 0 {
+		return nil, fields[1:], nil
+	}
+	return fields[1 : count+1], fields[count+1:], nil
+}
+
+func percent(used, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(used) / float64(total) * 100
+}
@@ -0,0 +1,129 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package dm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/jakobmoellerdev/lvm2go"
+)
+
+// SectorSize is the size of a device-mapper sector in bytes.
+const SectorSize = 512
+
+// TargetType is the type of a device-mapper target.
+type TargetType string
+
+const (
+	TargetTypeLinear        TargetType = "linear"
+	TargetTypeStriped       TargetType = "striped"
+	TargetTypeError         TargetType = "error"
+	TargetTypeZero          TargetType = "zero"
+	TargetTypeThinPool      TargetType = "thin-pool"
+	TargetTypeThin          TargetType = "thin"
+	TargetTypeCache         TargetType = "cache"
+	TargetTypeWriteCache    TargetType = "writecache"
+	TargetTypeRAID          TargetType = "raid"
+	TargetTypeMirror        TargetType = "mirror"
+	TargetTypeVDO           TargetType = "vdo"
+	TargetTypeSnapshot      TargetType = "snapshot"
+	TargetTypeSnapshotMerge TargetType = "snapshot-merge"
+	TargetTypeSnapshotOrig  TargetType = "snapshot-origin"
+)
+
+// Target is a single line of a device-mapper table or status.
+type Target struct {
+	// Start and Length are the location of the target in the device in sectors.
+	Start  uint64
+	Length uint64
+	Type   TargetType
+	// Params are the raw table parameters or status of the target.
+	Params string
+	// Status is the parsed status of the target. It is nil for tables and for targets without a typed status.
+	Status TargetStatus
+}
+
+// Size returns the size of the target.
+func (t Target) Size() lvm2go.Size {
+	return lvm2go.NewSize(float64(t.Length*SectorSize), lvm2go.UnitBytes)
+}
+
+// MappedSize returns the size of all targets, which is the size of the device.
+func MappedSize(targets []Target) lvm2go.Size {
+	var sectors uint64
+	for _, t := range targets {
+		sectors += t.Length
+	}
+	return lvm2go.NewSize(float64(sectors*SectorSize), lvm2go.UnitBytes)
+}
+
+// ParseTable parses the output of dmsetup table for a single device.
+func ParseTable(out io.Reader) ([]Target, error) {
+	return parseTargets(out, false)
+}
+
+// ParseStatus parses the output of dmsetup status for a single device including the typed target status.
+func ParseStatus(out io.Reader) ([]Target, error) {
+	return parseTargets(out, true)
+}
+
+func parseTargets(out io.Reader, status bool) ([]Target, error) {
+	var targets []Target
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		target, err := ParseTarget(line)
+		if err != nil {
+			return nil, err
+		}
+		if status {
+			if target.Status, err = ParseTargetStatus(target.Type, target.Params); err != nil {
+				return nil, err
+			}
+		}
+		targets = append(targets, target)
+	}
+	return targets, scanner.Err()
+}
+
+// ParseTarget parses a line in the form of "<start> <length> <type> <params>".
+func ParseTarget(line string) (Target, error) {
+	fields := strings.SplitN(strings.TrimSpace(line), " ", 4)
+	if len(fields) < 3 {
+		return Target{}, fmt.Errorf("%q is not a valid device-mapper target", line)
+	}
+	start, err := strconv.ParseUint(fields[0], 10, 64)
+	if err != nil {
+		return Target{}, fmt.Errorf("%q is not a valid device-mapper target: %w", line, err)
+	}
+	length, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return Target{}, fmt.Errorf("%q is not a valid device-mapper target: %w", line, err)
+	}
+	target := Target{Start: start, Length: length, Type: TargetType(fields[2])}
+	if len(fields) == 4 {
+		target.Params = strings.TrimSpace(fields[3])
+	}
+	return target, nil
+}
@@ -0,0 +1,90 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrDuplicatePV = errors.New("physical volumes with the same uuid were found on multiple devices")
+
+// DuplicatePV is a physical volume whose uuid was found on more than one device,
+// e.g. because a cloned LUN or snapshot is visible next to its origin.
+type DuplicatePV struct {
+	UUID string
+	// Device is the device lvm2 did not use for the physical volume, if reported.
+	Device string
+}
+
+// DuplicatePVError is returned if a command reported duplicate physical volumes.
+// Use VGImportClone to assign new uuids to the duplicates.
+type DuplicatePVError struct {
+	Duplicates []DuplicatePV
+	// Succeeded is true if the command completed and only warned about the duplicates.
+	Succeeded bool
+	Err       error
+}
+
+func (e *DuplicatePVError) Error() string {
+	uuids := make([]string, 0, len(e.Duplicates))
+	for _, dup := range e.Duplicates {
+		if !slices.Contains(uuids, dup.UUID) {
+			uuids = append(uuids, dup.UUID)
+		}
+	}
+	if e.Succeeded {
+		return fmt.Sprintf("%s (%s), command succeeded with warnings: %v", ErrDuplicatePV, strings.Join(uuids, ", "), e.Err)
+	}
+	return fmt.Sprintf("%s (%s): %v", ErrDuplicatePV, strings.Join(uuids, ", "), e.Err)
+}
+
+func (e *DuplicatePVError) Unwrap() []error {
+	return []error{ErrDuplicatePV, e.Err}
+}
+
+// NewDuplicatePVError wraps the error into a DuplicatePVError if its stderr reports duplicate physical volumes.
+// Otherwise, the error is returned as is. Commands that exited successfully report the duplicates as warnings
+// on stderr, which are surfaced as a DuplicatePVError with Succeeded set.
+func NewDuplicatePVError(err error) error {
+	stdErr, ok := AsLVMStdErr(err)
+	if !ok {
+		return err
+	}
+
+	var duplicates []DuplicatePV
+	for _, line := range stdErr.Lines(true) {
+		if matches := DuplicatePVNotUsingDevicePattern.FindSubmatch(line); matches != nil {
+			duplicates = append(duplicates, DuplicatePV{UUID: string(matches[2]), Device: string(matches[1])})
+		} else if matches := DuplicatePVPrefersDevicePattern.FindSubmatch(line); matches != nil {
+			duplicates = append(duplicates, DuplicatePV{UUID: string(matches[1])})
+		}
+	}
+	if len(duplicates) == 0 {
+		return err
+	}
+
+	_, failed := AsExitCodeError(err)
+	return &DuplicatePVError{Duplicates: duplicates, Succeeded: !failed, Err: err}
+}
+
+// IsDuplicatePV returns true if the error reports physical volumes with the same uuid on multiple devices.
+func IsDuplicatePV(err error) bool {
+	return errors.Is(err, ErrDuplicatePV) || IsLVMError(err, DuplicatePVPattern)
+}
@@ -201,12 +201,12 @@ func (opt PrefixedExtents) Validate() error {
 }
 
 func (opt PrefixedExtents) ApplyToArgs(args Arguments) error {
-	if err := opt.Validate(); err != nil {
-		return err
-	}
 	if opt.Val == 0 {
 		return nil
 	}
+	if err := opt.Validate(); err != nil {
+		return err
+	}
 
 	args.AddOrReplace(fmt.Sprintf("--extents=%s%s%s",
 		map[bool]string{
@@ -226,3 +226,7 @@ func (opt PrefixedExtents) ApplyToArgs(args Arguments) error {
 func (opt PrefixedExtents) ApplyToLVExtendOptions(opts *LVExtendOptions) {
 	opts.PrefixedExtents = opt
 }
+
+func (opt PrefixedExtents) ApplyToLVReduceOptions(opts *LVReduceOptions) {
+	opts.PrefixedExtents = opt
+}
@@ -0,0 +1,194 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrDeviceHasSignature = errors.New("device already contains a signature")
+
+// Tools used by Format, Mount and Unmount.
+const (
+	ToolMkfsExt4 Tool = "mkfs.ext4"
+	ToolMkfsXFS  Tool = "mkfs.xfs"
+	ToolMount    Tool = "mount"
+	ToolUmount   Tool = "umount"
+)
+
+// FSType is a filesystem type that can be created with Format.
+type FSType string
+
+const (
+	FSTypeExt4 FSType = "ext4"
+	FSTypeXFS  FSType = "xfs"
+)
+
+// LazyInit controls whether ext4 initializes its inode tables and journal while the filesystem is created.
+type LazyInit int
+
+const (
+	// LazyInitDefault keeps the default of mkfs, which depends on its configuration and the kernel.
+	LazyInitDefault LazyInit = iota
+	// LazyInitEnabled defers the initialization of the inode tables and the journal to the kernel after mount.
+	LazyInitEnabled
+	// LazyInitDisabled initializes the inode tables and the journal while the filesystem is created.
+	LazyInitDisabled
+)
+
+// FSSpec describes the filesystem created by Format.
+type FSSpec struct {
+	Type  FSType
+	Label string
+
+	// NoDiscard skips discarding the blocks of the device before the filesystem is created,
+	// which is slow on large or thin provisioned logical volumes.
+	NoDiscard bool
+	// LazyInit controls the initialization of the inode tables and the journal of ext4.
+	LazyInit LazyInit
+	// Force creates the filesystem even if the device already contains a signature.
+	Force bool
+
+	// Options are passed to mkfs in addition to the options derived from the spec.
+	Options []string
+}
+
+// Command returns the mkfs tool and its arguments to create the filesystem on the device.
+// The path of the tool is resolved with GetToolPath.
+func (spec FSSpec) Command(device string) (Tool, []string, error) {
+	var tool Tool
+	var args, extended []string
+	switch spec.Type {
+	case FSTypeExt4:
+		tool = ToolMkfsExt4
+		if spec.Force {
+			args = append(args, "-F")
+		}
+		if spec.NoDiscard {
+			extended = append(extended, "nodiscard")
+		}
+		switch spec.LazyInit {
+		case LazyInitEnabled:
+			extended = append(extended, "lazy_itable_init=1", "lazy_journal_init=1")
+		case LazyInitDisabled:
+			extended = append(extended, "lazy_itable_init=0", "lazy_journal_init=0")
+		}
+		if len(extended) > 0 {
+			args = append(args, "-E", strings.Join(extended, ","))
+		}
+	case FSTypeXFS:
+		tool = ToolMkfsXFS
+		if spec.Force {
+			args = append(args, "-f")
+		}
+		if spec.NoDiscard {
+			args = append(args, "-K")
+		}
+		if spec.LazyInit == LazyInitEnabled {
+			return "", nil, fmt.Errorf("lazy initialization is only supported for %s", FSTypeExt4)
+		}
+	default:
+		return "", nil, fmt.Errorf("%w: cannot create %q", ErrFileSystemUnsupported, spec.Type)
+	}
+	if spec.Label != "" {
+		args = append(args, "-L", spec.Label)
+	}
+	args = append(args, spec.Options...)
+	return tool, append(args, device), nil
+}
+
+// devicePath returns LogicalVolume.Path or, if lvm2 did not report it, the path udev creates for the logical volume.
+func (lv *LogicalVolume) devicePath() string {
+	if lv.Path != "" {
+		return lv.Path
+	}
+	return (&FQLogicalVolumeName{VolumeGroupName: lv.VolumeGroupName, LogicalVolumeName: lv.Name}).DevicePath()
+}
+
+// IsMounted returns true if the logical volume had mounts when Mounts was last read.
+func (lv *LogicalVolume) IsMounted() bool {
+	return len(lv.Mounts) > 0
+}
+
+// ReadMounts updates Mounts with ReadMountInfo, which reads the mounts of the host when running containerized.
+func (lv *LogicalVolume) ReadMounts(ctx context.Context) error {
+	major, minor := lv.Major, lv.Minor
+	if m, n, err := DeviceNumber(lv.devicePath()); err == nil {
+		major, minor = m, n
+	}
+	if major <= 0 {
+		lv.Mounts = nil
+		return nil
+	}
+	mounts, err := ReadMountInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to read mounts of %s: %w", lv.devicePath(), err)
+	}
+	lv.Mounts = mounts.OfDevice(major, minor)
+	return nil
+}
+
+// Format creates the filesystem of the spec on the logical volume once its device node appeared.
+// It refuses devices that already contain a signature with ErrDeviceHasSignature unless Force is set.
+func Format(ctx context.Context, lv *LogicalVolume, spec FSSpec) error {
+	device := lv.devicePath()
+	tool, args, err := spec.Command(device)
+	if err != nil {
+		return err
+	}
+	if err := waitForDeviceNode(ctx, device, -1, -1, WaitForDeviceOptions{UdevSettle: true}); err != nil {
+		return err
+	}
+	if !spec.Force {
+		signatures, err := ProbeSignaturesFromPath(device)
+		if err != nil {
+			return fmt.Errorf("failed to probe signatures of %s: %w", device, err)
+		}
+		if len(signatures) > 0 {
+			return fmt.Errorf("%w: %v on %s", ErrDeviceHasSignature, signatures, device)
+		}
+	}
+	return runFileSystemTool(ctx, tool, args...)
+}
+
+// Mount mounts the logical volume at the existing target directory with the mount options
+// once its device node appeared and updates Mounts of the logical volume.
+func Mount(ctx context.Context, lv *LogicalVolume, target string, opts ...string) error {
+	device := lv.devicePath()
+	if err := waitForDeviceNode(ctx, device, -1, -1, WaitForDeviceOptions{UdevSettle: true}); err != nil {
+		return err
+	}
+	var args []string
+	if len(opts) > 0 {
+		args = append(args, "-o", strings.Join(opts, ","))
+	}
+	if err := runFileSystemTool(ctx, ToolMount, append(args, device, target)...); err != nil {
+		return err
+	}
+	return lv.ReadMounts(ctx)
+}
+
+// Unmount unmounts the logical volume from the target and updates Mounts of the logical volume.
+func Unmount(ctx context.Context, lv *LogicalVolume, target string) error {
+	if err := runFileSystemTool(ctx, ToolUmount, target); err != nil {
+		return err
+	}
+	return lv.ReadMounts(ctx)
+}
@@ -0,0 +1,140 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestFSSpecCommand(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		spec     FSSpec
+		tool     Tool
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "ext4",
+			spec:     FSSpec{Type: FSTypeExt4},
+			tool:     ToolMkfsExt4,
+			expected: []string{"/dev/vg/lv"},
+		},
+		{
+			name:     "ext4 with all options",
+			spec:     FSSpec{Type: FSTypeExt4, Label: "data", NoDiscard: true, LazyInit: LazyInitEnabled, Force: true, Options: []string{"-m", "0"}},
+			tool:     ToolMkfsExt4,
+			expected: []string{"-F", "-E", "nodiscard,lazy_itable_init=1,lazy_journal_init=1", "-L", "data", "-m", "0", "/dev/vg/lv"},
+		},
+		{
+			name:     "ext4 without lazy init",
+			spec:     FSSpec{Type: FSTypeExt4, LazyInit: LazyInitDisabled},
+			tool:     ToolMkfsExt4,
+			expected: []string{"-E", "lazy_itable_init=0,lazy_journal_init=0", "/dev/vg/lv"},
+		},
+		{
+			name:     "xfs",
+			spec:     FSSpec{Type: FSTypeXFS, Label: "data", NoDiscard: true, Force: true},
+			tool:     ToolMkfsXFS,
+			expected: []string{"-f", "-K", "-L", "data", "/dev/vg/lv"},
+		},
+		{
+			name: "xfs lazy init",
+			spec: FSSpec{Type: FSTypeXFS, LazyInit: LazyInitEnabled},
+			err:  true,
+		},
+		{
+			name: "unsupported",
+			spec: FSSpec{Type: "ntfs"},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			tool, args, err := tc.spec.Command("/dev/vg/lv")
+			if (err != nil) != tc.err {
+				t.Fatalf("unexpected error %v", err)
+			}
+			if tool != tc.tool || !slices.Equal(args, tc.expected) {
+				t.Fatalf("expected %s %v, got %s %v", tc.tool, tc.expected, tool, args)
+			}
+		})
+	}
+}
+
+func TestFormatPreconditions(t *testing.T) {
+	t.Parallel()
+	path := filepath.Join(t.TempDir(), "image")
+	content := make([]byte, 128*1024)
+	copy(content, "XFSB")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	lv := &LogicalVolume{Path: path}
+	if err := Format(context.Background(), lv, FSSpec{Type: FSTypeExt4}); !errors.Is(err, ErrDeviceHasSignature) {
+		t.Fatalf("expected existing signature to be refused, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	missing := &LogicalVolume{Path: filepath.Join(t.TempDir(), "missing")}
+	if err := Format(ctx, missing, FSSpec{Type: FSTypeExt4}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to time out waiting for the device node, got %v", err)
+	}
+}
+
+func TestFormatMount(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{
+		LoopDevices: []Size{MustParseSize("100M")},
+		Volumes:     []TestLogicalVolume{{Options: LVCreateOptionList{MustParseSize("64M")}}},
+	}.SetupDevicesAndVolumeGroup(t)
+
+	lv, err := clnt.LV(ctx, infra.volumeGroup.Name, infra.lvs[0].LogicalVolumeName())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Format(ctx, lv, FSSpec{Type: FSTypeExt4, NoDiscard: true, LazyInit: LazyInitEnabled}); err != nil {
+		t.Fatal(err)
+	}
+	target := t.TempDir()
+	if err := Mount(ctx, lv, target, "noatime"); err != nil {
+		t.Fatal(err)
+	}
+	if !lv.IsMounted() || lv.Mounts[0].MountPoint != target {
+		t.Fatalf("expected %s to be mounted at %s, got %+v", lv.Path, target, lv.Mounts)
+	}
+	if err := Unmount(ctx, lv, target); err != nil {
+		t.Fatal(err)
+	}
+	if lv.IsMounted() {
+		t.Fatalf("expected %s to be unmounted, got %+v", lv.Path, lv.Mounts)
+	}
+}
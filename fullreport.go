@@ -0,0 +1,325 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var (
+	// DefaultFullReportVGColumnOptions are the columns requested for the vg sub report of FullReport.
+	DefaultFullReportVGColumnOptions = ColumnOptions{"vg_all"}
+	// DefaultFullReportPVColumnOptions are the columns requested for the pv sub report of FullReport.
+	DefaultFullReportPVColumnOptions = ColumnOptions{"pv_all", "vg_name"}
+	// DefaultFullReportLVColumnOptions are the columns requested for the lv sub report of FullReport.
+	DefaultFullReportLVColumnOptions = ColumnOptions{"lv_all", "vg_name"}
+	// DefaultFullReportPVSegColumnOptions are the columns requested for the pvseg sub report of FullReport.
+	// The uuids are required to link the segments to their physical and logical volumes.
+	DefaultFullReportPVSegColumnOptions = ColumnOptions{"pvseg_all", "pv_uuid", "lv_uuid"}
+	// DefaultFullReportSegColumnOptions are the columns requested for the seg sub report of FullReport.
+	// The uuid is required to link the segments to their logical volume.
+	DefaultFullReportSegColumnOptions = ColumnOptions{"seg_all", "lv_uuid"}
+)
+
+// FullReport is a consistent snapshot of the lvm2 state of the host, as reported by a single lvm fullreport call.
+// In contrast to separate calls to VGs, PVs and LVs, all objects in a FullReport
+// are read while holding the volume group lock, so they belong to the same metadata sequence number.
+type FullReport struct {
+	VolumeGroups []*FullReportVolumeGroup
+	// OrphanPhysicalVolumes are physical volumes that are not part of any volume group.
+	OrphanPhysicalVolumes []*FullReportPhysicalVolume
+}
+
+// FullReportVolumeGroup is a volume group in a FullReport with its physical and logical volumes.
+type FullReportVolumeGroup struct {
+	*VolumeGroup
+	PhysicalVolumes []*FullReportPhysicalVolume
+	LogicalVolumes  []*FullReportLogicalVolume
+}
+
+// FullReportPhysicalVolume is a physical volume in a FullReport with its segments
+// and references to the volume group and logical volumes that are allocated on it.
+type FullReportPhysicalVolume struct {
+	*PhysicalVolume
+	// VolumeGroup is nil for orphan physical volumes.
+	VolumeGroup *FullReportVolumeGroup
+	Segments    []*PVSegment
+	// LogicalVolumes are the logical volumes allocated on the physical volume,
+	// including the top-level logical volumes of allocated sub volumes.
+	LogicalVolumes []*FullReportLogicalVolume
+}
+
+// FullReportLogicalVolume is a logical volume in a FullReport with its segments
+// and references to the volume group, the physical volumes it is allocated on, its pool and its origin.
+// Internal sub volumes such as RAID images or thin pool data and metadata are part of the report
+// and reference the logical volume they belong to.
+type FullReportLogicalVolume struct {
+	*LogicalVolume
+	VolumeGroup *FullReportVolumeGroup
+	Segments    []*LVSegment
+	// PhysicalVolumes are the physical volumes the logical volume or any of its sub volumes are allocated on.
+	PhysicalVolumes []*FullReportPhysicalVolume
+	// Parent is the logical volume an internal sub volume belongs to, nil for top-level logical volumes.
+	Parent *FullReportLogicalVolume
+	// SubVolumes are the internal logical volumes referenced by the devices and metadata devices of the segments.
+	SubVolumes []*FullReportLogicalVolume
+	// Pool is the thin or cache pool of the logical volume if it has one.
+	Pool *FullReportLogicalVolume
+	// OriginVolume is the origin of a snapshot if the logical volume is one.
+	OriginVolume *FullReportLogicalVolume
+}
+
+// SeqNos returns the metadata sequence numbers of all volume groups in the report.
+// They can be compared with VolumeGroup.SeqNo of a later report to detect concurrent modifications.
+func (report *FullReport) SeqNos() map[VolumeGroupName]int64 {
+	seqNos := make(map[VolumeGroupName]int64, len(report.VolumeGroups))
+	for _, vg := range report.VolumeGroups {
+		seqNos[vg.Name] = vg.SeqNo
+	}
+	return seqNos
+}
+
+// VolumeGroup returns the volume group with the given name or nil if it is not part of the report.
+func (report *FullReport) VolumeGroup(name VolumeGroupName) *FullReportVolumeGroup {
+	for _, vg := range report.VolumeGroups {
+		if vg.Name == name {
+			return vg
+		}
+	}
+	return nil
+}
+
+// LogicalVolume returns the logical volume with the given name or nil if it is not part of the volume group.
+// Internal volumes can be referenced with or without the surrounding brackets lvm2 uses in reports.
+func (vg *FullReportVolumeGroup) LogicalVolume(name LogicalVolumeName) *FullReportLogicalVolume {
+	for _, lv := range vg.LogicalVolumes {
+		if internalLogicalVolumeName(string(lv.Name)) == internalLogicalVolumeName(string(name)) {
+			return lv
+		}
+	}
+	return nil
+}
+
+// PhysicalVolume returns the physical volume with the given name or nil if it is not part of the volume group.
+func (vg *FullReportVolumeGroup) PhysicalVolume(name PhysicalVolumeName) *FullReportPhysicalVolume {
+	for _, pv := range vg.PhysicalVolumes {
+		if pv.Name == name {
+			return pv
+		}
+	}
+	return nil
+}
+
+func (report *FullReport) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Report []struct {
+			VG    []*VolumeGroup    `json:"vg"`
+			PV    []*PhysicalVolume `json:"pv"`
+			LV    []*LogicalVolume  `json:"lv"`
+			PVSeg []*PVSegment      `json:"pvseg"`
+			Seg   []*LVSegment      `json:"seg"`
+		} `json:"report"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*report = FullReport{}
+	for _, entry := range raw.Report {
+		var vg *FullReportVolumeGroup
+		if len(entry.VG) > 0 {
+			vg = &FullReportVolumeGroup{VolumeGroup: entry.VG[0]}
+			report.VolumeGroups = append(report.VolumeGroups, vg)
+		}
+
+		pvsByUUID := make(map[string]*FullReportPhysicalVolume, len(entry.PV))
+		for _, pv := range entry.PV {
+			fpv := &FullReportPhysicalVolume{PhysicalVolume: pv, VolumeGroup: vg}
+			pvsByUUID[pv.UUID] = fpv
+			if vg != nil {
+				vg.PhysicalVolumes = append(vg.PhysicalVolumes, fpv)
+			} else {
+				report.OrphanPhysicalVolumes = append(report.OrphanPhysicalVolumes, fpv)
+			}
+		}
+
+		if vg == nil {
+			continue
+		}
+
+		lvsByUUID := make(map[string]*FullReportLogicalVolume, len(entry.LV))
+		for _, lv := range entry.LV {
+			flv := &FullReportLogicalVolume{LogicalVolume: lv, VolumeGroup: vg}
+			lvsByUUID[lv.UUID] = flv
+			vg.LogicalVolumes = append(vg.LogicalVolumes, flv)
+		}
+
+		for _, lv := range vg.LogicalVolumes {
+			if lv.PoolLogicalVolume != "" {
+				lv.Pool = vg.LogicalVolume(LogicalVolumeName(lv.PoolLogicalVolume))
+			}
+			if lv.Origin != "" {
+				lv.OriginVolume = vg.LogicalVolume(LogicalVolumeName(lv.Origin))
+			}
+		}
+
+		for _, seg := range entry.PVSeg {
+			pv, ok := pvsByUUID[seg.PhysicalVolumeUUID]
+			if !ok {
+				return fmt.Errorf("physical volume segment references unknown physical volume %q", seg.PhysicalVolumeUUID)
+			}
+			seg.PhysicalVolumeName, seg.VolumeGroupName = pv.Name, vg.Name
+			if lv, ok := lvsByUUID[seg.LogicalVolumeUUID]; ok {
+				seg.LogicalVolumeName = lv.Name
+			}
+			pv.Segments = append(pv.Segments, seg)
+		}
+
+		for _, seg := range entry.Seg {
+			lv, ok := lvsByUUID[seg.LogicalVolumeUUID]
+			if !ok {
+				return fmt.Errorf("logical volume segment references unknown logical volume %q", seg.LogicalVolumeUUID)
+			}
+			seg.LogicalVolumeName, seg.VolumeGroupName = lv.Name, vg.Name
+			lv.Segments = append(lv.Segments, seg)
+		}
+
+		graph := newSegmentGraph(entry.Seg)
+		for _, name := range graph.order {
+			parent := vg.LogicalVolume(name)
+			for _, dev := range graph.devices[name] {
+				if sub := internalLogicalVolumeName(dev); graph.isLogicalVolume(sub) {
+					if lv := vg.LogicalVolume(sub); lv != nil && lv.Parent == nil {
+						lv.Parent = parent
+						parent.SubVolumes = append(parent.SubVolumes, lv)
+					}
+				}
+			}
+		}
+
+		for _, lv := range vg.LogicalVolumes {
+			for _, seg := range lv.Segments {
+				for _, pvName := range seg.PERanges.PhysicalVolumeNames() {
+					pv := vg.PhysicalVolume(pvName)
+					if pv == nil {
+						continue
+					}
+					for owner := lv; owner != nil; owner = owner.Parent {
+						if !slices.Contains(owner.PhysicalVolumes, pv) {
+							owner.PhysicalVolumes = append(owner.PhysicalVolumes, pv)
+						}
+						if !slices.Contains(pv.LogicalVolumes, owner) {
+							pv.LogicalVolumes = append(pv.LogicalVolumes, owner)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+type (
+	FullReportOptions struct {
+		VolumeGroupName
+		Unit
+
+		CommonOptions
+	}
+	FullReportOption interface {
+		ApplyToFullReportOptions(opts *FullReportOptions)
+	}
+	FullReportOptionsList []FullReportOption
+)
+
+var (
+	_ ArgumentGenerator = FullReportOptionsList{}
+	_ Argument          = (*FullReportOptions)(nil)
+)
+
+func (c *client) FullReport(ctx context.Context, opts ...FullReportOption) (*FullReport, error) {
+	res := new(FullReport)
+
+	// internal logical volumes are always reported, as they are required to resolve
+	// the physical volumes of thin pools, RAID and cache volumes.
+	args := []string{
+		"fullreport", "--reportformat", "json", "--all",
+	}
+	argsFromOpts, err := FullReportOptionsList(opts).AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.RunLVMInto(ctx, res, append(args, argsFromOpts.GetRaw()...)...); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (list FullReportOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := FullReportOptions{}
+	for _, opt := range list {
+		opt.ApplyToFullReportOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *FullReportOptions) ApplyToFullReportOptions(new *FullReportOptions) {
+	*new = *opts
+}
+
+func (opts *FullReportOptions) ApplyToArgs(args Arguments) error {
+	// every sub report is configured separately, the arguments are passed as single values
+	// as they would otherwise be deduplicated.
+	for _, sub := range []struct {
+		name    ReportType
+		columns ColumnOptions
+	}{
+		{ReportTypeVG, DefaultFullReportVGColumnOptions},
+		{ReportTypePV, DefaultFullReportPVColumnOptions},
+		{ReportTypeLV, DefaultFullReportLVColumnOptions},
+		{ReportTypePVSeg, DefaultFullReportPVSegColumnOptions},
+		{ReportTypeSeg, DefaultFullReportSegColumnOptions},
+	} {
+		args.AddOrReplace(
+			fmt.Sprintf("--configreport=%s", sub.name),
+			fmt.Sprintf("--options=%s", strings.Join(sub.columns, ",")),
+		)
+	}
+
+	for _, arg := range []Argument{
+		opts.VolumeGroupName,
+		opts.Unit,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,128 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+const testFullReport = `{
+  "report": [
+    {
+      "vg": [{"vg_name":"vg1","vg_uuid":"vg-uuid","vg_attr":"wz--n-","vg_seqno":"7","vg_extent_size":"4.00m"}],
+      "pv": [
+        {"pv_name":"/dev/loop0","pv_uuid":"pv0","vg_name":"vg1","pv_attr":"a--"},
+        {"pv_name":"/dev/loop1","pv_uuid":"pv1","vg_name":"vg1","pv_attr":"a--"}
+      ],
+      "lv": [
+        {"lv_name":"pool","lv_uuid":"lv-pool","vg_name":"vg1","lv_attr":"twi-a-tz--"},
+        {"lv_name":"thin","lv_uuid":"lv-thin","vg_name":"vg1","lv_attr":"Vwi-a-tz--","pool_lv":"pool"},
+        {"lv_name":"[pool_tdata]","lv_uuid":"lv-tdata","vg_name":"vg1","lv_attr":"Twi-ao----"},
+        {"lv_name":"[pool_tmeta]","lv_uuid":"lv-tmeta","vg_name":"vg1","lv_attr":"ewi-ao----"}
+      ],
+      "pvseg": [
+        {"pv_uuid":"pv0","lv_uuid":"lv-tdata","pvseg_start":"0","pvseg_size":"10"},
+        {"pv_uuid":"pv0","lv_uuid":"","pvseg_start":"10","pvseg_size":"5"},
+        {"pv_uuid":"pv1","lv_uuid":"lv-tmeta","pvseg_start":"0","pvseg_size":"1"},
+        {"pv_uuid":"pv1","lv_uuid":"","pvseg_start":"1","pvseg_size":"14"}
+      ],
+      "seg": [
+        {"lv_uuid":"lv-pool","segtype":"thin-pool","seg_start_pe":"0","seg_size_pe":"10","devices":"pool_tdata(0)","metadata_devices":"pool_tmeta(0)"},
+        {"lv_uuid":"lv-thin","segtype":"thin","seg_start_pe":"0","seg_size_pe":"0"},
+        {"lv_uuid":"lv-tdata","segtype":"linear","seg_start_pe":"0","seg_size_pe":"10","seg_pe_ranges":"/dev/loop0:0-9","devices":"/dev/loop0(0)"},
+        {"lv_uuid":"lv-tmeta","segtype":"linear","seg_start_pe":"0","seg_size_pe":"1","seg_pe_ranges":"/dev/loop1:0-0","devices":"/dev/loop1(0)"}
+      ]
+    },
+    {
+      "vg": [],
+      "pv": [{"pv_name":"/dev/loop2","pv_uuid":"pv2","vg_name":"","pv_attr":"---"}],
+      "lv": [],
+      "pvseg": [],
+      "seg": []
+    }
+  ]
+}`
+
+func TestFullReport_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	var report FullReport
+	if err := json.Unmarshal([]byte(testFullReport), &report); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.VolumeGroups) != 1 {
+		t.Fatalf("expected 1 volume group, got %d", len(report.VolumeGroups))
+	}
+	if len(report.OrphanPhysicalVolumes) != 1 || report.OrphanPhysicalVolumes[0].VolumeGroup != nil {
+		t.Fatalf("expected 1 orphan physical volume without volume group, got %v", report.OrphanPhysicalVolumes)
+	}
+	if seqNo := report.SeqNos()["vg1"]; seqNo != 7 {
+		t.Fatalf("expected seqno 7, got %d", seqNo)
+	}
+
+	vg := report.VolumeGroup("vg1")
+	if vg == nil || len(vg.PhysicalVolumes) != 2 || len(vg.LogicalVolumes) != 4 {
+		t.Fatalf("unexpected volume group %+v", vg)
+	}
+
+	thin := vg.LogicalVolume("thin")
+	if thin == nil || thin.Pool == nil || thin.Pool.Name != "pool" {
+		t.Fatalf("expected thin volume to reference its pool, got %+v", thin)
+	}
+
+	tdata := vg.LogicalVolume("pool_tdata")
+	if tdata == nil || len(tdata.Segments) != 1 || tdata.Segments[0].LogicalVolumeName != "[pool_tdata]" {
+		t.Fatalf("expected internal data volume with linked segment, got %+v", tdata)
+	}
+	if len(tdata.PhysicalVolumes) != 1 || tdata.PhysicalVolumes[0].Name != "/dev/loop0" {
+		t.Fatalf("expected data volume to be allocated on /dev/loop0, got %v", tdata.PhysicalVolumes)
+	}
+
+	pool := vg.LogicalVolume("pool")
+	if tdata.Parent != pool || pool.Parent != nil || len(pool.SubVolumes) != 2 {
+		t.Fatalf("expected data and metadata volumes to belong to the pool, got %+v", pool)
+	}
+	if len(pool.PhysicalVolumes) != 2 || pool.PhysicalVolumes[0].Name != "/dev/loop0" || pool.PhysicalVolumes[1].Name != "/dev/loop1" {
+		t.Fatalf("expected pool to be allocated on /dev/loop0 and /dev/loop1, got %v", pool.PhysicalVolumes)
+	}
+
+	loop0 := vg.PhysicalVolume("/dev/loop0")
+	if loop0 == nil || len(loop0.Segments) != 2 || len(loop0.LogicalVolumes) != 2 || loop0.LogicalVolumes[1] != pool {
+		t.Fatalf("unexpected physical volume %+v", loop0)
+	}
+	if loop0.Segments[0].LogicalVolumeName != "[pool_tdata]" || !loop0.Segments[1].IsFree() {
+		t.Fatalf("unexpected physical volume segments %v", loop0.Segments)
+	}
+	if loop1 := vg.PhysicalVolume("/dev/loop1"); loop1.Segments[0].LogicalVolumeName != "[pool_tmeta]" {
+		t.Fatalf("expected metadata volume on /dev/loop1, got %v", loop1.Segments)
+	}
+	if loop0.VolumeGroup != vg {
+		t.Fatal("expected physical volume to reference its volume group")
+	}
+}
+
+func TestFullReport_UnmarshalJSON_UnknownReference(t *testing.T) {
+	t.Parallel()
+	raw := `{"report":[{"vg":[{"vg_name":"vg1"}],"pv":[],"lv":[],"pvseg":[],"seg":[{"lv_uuid":"missing"}]}]}`
+	var report FullReport
+	if err := json.Unmarshal([]byte(raw), &report); err == nil {
+		t.Fatal("expected error due to unknown logical volume reference")
+	}
+}
@@ -37,6 +37,32 @@ func unmarshalToStringAndParseInt64(raw map[string]json.RawMessage, key string,
 	})
 }
 
+// unmarshalToStringAndParseBinary parses binary report fields with parseBinary.
+func unmarshalToStringAndParseBinary(raw map[string]json.RawMessage, key string, fieldPtr *bool) error {
+	return unmarshalToStringAndParse(raw, key, fieldPtr, func(str string) (bool, error) {
+		return parseBinary(key, str), nil
+	})
+}
+
+// parseBinary parses the value of the binary report field.
+// Without --binary, lvm2 reports set binary fields with their name and unset fields as empty string,
+// with --binary as "1" and "0". Values that could not be determined ("unknown" or "-1") are reported as unset.
+func parseBinary(field, str string) bool {
+	return str == "1" || str == binaryFieldName(field)
+}
+
+// binaryFieldName is the name lvm2 reports set binary fields with,
+// the field without the prefix of its report type, e.g. "active locally" for lv_active_locally.
+func binaryFieldName(field string) string {
+	for _, t := range []ReportType{ReportTypeLV, ReportTypeVG, ReportTypePV, ReportTypeSeg} {
+		if name, ok := strings.CutPrefix(field, t.Prefix()); ok {
+			field = name
+			break
+		}
+	}
+	return strings.ReplaceAll(field, "_", " ")
+}
+
 func unmarshalToStringAndParseFloat64(raw map[string]json.RawMessage, key string, fieldPtr *float64) error {
 	return unmarshalToStringAndParse(raw, key, fieldPtr, func(str string) (float64, error) {
 		return strconv.ParseFloat(str, 64)
@@ -0,0 +1,54 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"fmt"
+)
+
+// LockType is the lock manager lvmlockd uses for a shared volume group.
+// See man lvmlockd for more information.
+type LockType string
+
+const (
+	LockTypeSanlock LockType = "sanlock"
+	LockTypeDLM     LockType = "dlm"
+	LockTypeIDM     LockType = "idm"
+	// LockTypeNone changes a shared volume group back to a local volume group.
+	LockTypeNone LockType = "none"
+)
+
+// IsShared returns true if the lock type requires lvmlockd.
+func (opt LockType) IsShared() bool {
+	return opt != "" && opt != LockTypeNone
+}
+
+func (opt LockType) ApplyToVGCreateOptions(opts *VGCreateOptions) {
+	opts.LockType = opt
+}
+
+func (opt LockType) ApplyToVGChangeOptions(opts *VGChangeOptions) {
+	opts.LockType = opt
+}
+
+func (opt LockType) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--locktype=%s", string(opt)))
+	return nil
+}
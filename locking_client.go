@@ -35,12 +35,30 @@ func (l *lockingClient) LVs(ctx context.Context, opts ...LVsOption) ([]*LogicalV
 	return l.clnt.LVs(ctx, opts...)
 }
 
+func (l *lockingClient) LVSegments(ctx context.Context, opts ...LVsOption) ([]*LVSegment, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.LVSegments(ctx, opts...)
+}
+
 func (l *lockingClient) LVCreate(ctx context.Context, opts ...LVCreateOption) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
 	return l.clnt.LVCreate(ctx, opts...)
 }
 
+func (l *lockingClient) ThinPoolAllocations(ctx context.Context, opts ...LVsOption) ([]*ThinPoolAllocation, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.ThinPoolAllocations(ctx, opts...)
+}
+
+func (l *lockingClient) RepairThinPool(ctx context.Context, pool *ThinPool, opts ...RepairThinPoolOption) (*ThinPoolRepair, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.RepairThinPool(ctx, pool, opts...)
+}
+
 func (l *lockingClient) LVRemove(ctx context.Context, opts ...LVRemoveOption) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -65,6 +83,12 @@ func (l *lockingClient) LVReduce(ctx context.Context, opts ...LVReduceOption) er
 	return l.clnt.LVReduce(ctx, opts...)
 }
 
+func (l *lockingClient) WaitForDevice(ctx context.Context, fq *FQLogicalVolumeName, opts ...WaitForDeviceOption) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.WaitForDevice(ctx, fq, opts...)
+}
+
 func (l *lockingClient) LVRename(ctx context.Context, opts ...LVRenameOption) error {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -77,6 +101,19 @@ func (l *lockingClient) LVChange(ctx context.Context, opts ...LVChangeOption) er
 	return l.clnt.LVChange(ctx, opts...)
 }
 
+func (l *lockingClient) Poll(ctx context.Context, opts ...LVPollOption) error {
+	// polling can take a long time, so only a read lock is held to not block other readers
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.Poll(ctx, opts...)
+}
+
+func (l *lockingClient) PollingOperations(ctx context.Context, opts ...LVsOption) ([]*PollingOperation, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.PollingOperations(ctx, opts...)
+}
+
 func (l *lockingClient) VG(ctx context.Context, opts ...VGsOption) (*VolumeGroup, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -89,6 +126,30 @@ func (l *lockingClient) VGs(ctx context.Context, opts ...VGsOption) ([]*VolumeGr
 	return l.clnt.VGs(ctx, opts...)
 }
 
+func (l *lockingClient) Planner(ctx context.Context, vg VolumeGroupName) (*Planner, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.Planner(ctx, vg)
+}
+
+func (l *lockingClient) PlanRecovery(ctx context.Context, vg VolumeGroupName) (*RecoveryPlan, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.PlanRecovery(ctx, vg)
+}
+
+func (l *lockingClient) Recover(ctx context.Context, plan *RecoveryPlan) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.Recover(ctx, plan)
+}
+
+func (l *lockingClient) FullReport(ctx context.Context, opts ...FullReportOption) (*FullReport, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.FullReport(ctx, opts...)
+}
+
 func (l *lockingClient) VGCreate(ctx context.Context, opts ...VGCreateOption) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -131,6 +192,18 @@ func (l *lockingClient) PVs(ctx context.Context, opts ...PVsOption) ([]*Physical
 	return l.clnt.PVs(ctx, opts...)
 }
 
+func (l *lockingClient) SelectPhysicalVolumes(ctx context.Context, opts ...SelectPhysicalVolumesOption) (PhysicalVolumeNames, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.SelectPhysicalVolumes(ctx, opts...)
+}
+
+func (l *lockingClient) PVSegments(ctx context.Context, opts ...PVsOption) ([]*PVSegment, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.PVSegments(ctx, opts...)
+}
+
 func (l *lockingClient) PVCreate(ctx context.Context, opts ...PVCreateOption) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -161,6 +234,24 @@ func (l *lockingClient) PVMove(ctx context.Context, opts ...PVMoveOption) error
 	return l.clnt.PVMove(ctx, opts...)
 }
 
+func (l *lockingClient) Decommission(ctx context.Context, pv PhysicalVolumeName, opts ...DecommissionOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.Decommission(ctx, pv, opts...)
+}
+
+func (l *lockingClient) PVScan(ctx context.Context, opts ...PVScanOption) (*PVScanResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.PVScan(ctx, opts...)
+}
+
+func (l *lockingClient) PVCheck(ctx context.Context, opts ...PVCheckOption) (*PVCheckResult, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.PVCheck(ctx, opts...)
+}
+
 func (l *lockingClient) DevList(ctx context.Context, opts ...DevListOption) ([]DeviceListEntry, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -185,6 +276,36 @@ func (l *lockingClient) DevModify(ctx context.Context, opts ...DevModifyOption)
 	return l.clnt.DevModify(ctx, opts...)
 }
 
+func (l *lockingClient) ReadDevicesFile(ctx context.Context, opts ...DevicesFileOption) (*DevicesFileContents, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.ReadDevicesFile(ctx, opts...)
+}
+
+func (l *lockingClient) WriteDevicesFile(ctx context.Context, contents *DevicesFileContents, opts ...DevicesFileOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.WriteDevicesFile(ctx, contents, opts...)
+}
+
+func (l *lockingClient) DiffDevicesFile(ctx context.Context, opts ...DevicesFileOption) (*DevicesFileDiff, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.DiffDevicesFile(ctx, opts...)
+}
+
+func (l *lockingClient) DevModifyBatch(ctx context.Context, batch DevicesFileBatch, opts ...DevicesFileOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.DevModifyBatch(ctx, batch, opts...)
+}
+
+func (l *lockingClient) Candidates(ctx context.Context, opts ...CandidatesOption) ([]*Candidate, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.Candidates(ctx, opts...)
+}
+
 func (l *lockingClient) Version(ctx context.Context, opts ...VersionOption) (Version, error) {
 	l.mu.RLock()
 	defer l.mu.RUnlock()
@@ -203,6 +324,18 @@ func (l *lockingClient) ReadAndDecodeConfig(ctx context.Context, v any, opts ...
 	return l.clnt.ReadAndDecodeConfig(ctx, v, opts...)
 }
 
+func (l *lockingClient) SystemID(ctx context.Context) (*HostSystemID, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.SystemID(ctx)
+}
+
+func (l *lockingClient) ReportInto(ctx context.Context, v any, reportType ReportType, opts ...ReportOption) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.ReportInto(ctx, v, reportType, opts...)
+}
+
 func (l *lockingClient) WriteAndEncodeConfig(ctx context.Context, v any, writer io.Writer) error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -248,3 +381,57 @@ func (l *lockingClient) GetProfileDirectory(ctx context.Context) (string, error)
 	// no locking needed
 	return l.clnt.GetProfileDirectory(ctx)
 }
+
+func (l *lockingClient) VGExport(ctx context.Context, opts ...VGExportOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGExport(ctx, opts...)
+}
+
+func (l *lockingClient) VGImport(ctx context.Context, opts ...VGImportOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGImport(ctx, opts...)
+}
+
+func (l *lockingClient) VGImportClone(ctx context.Context, opts ...VGImportCloneOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGImportClone(ctx, opts...)
+}
+
+func (l *lockingClient) VGCheck(ctx context.Context, opts ...VGCheckOption) (*VGCheckResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGCheck(ctx, opts...)
+}
+
+func (l *lockingClient) VGSplit(ctx context.Context, opts ...VGSplitOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGSplit(ctx, opts...)
+}
+
+func (l *lockingClient) VGMerge(ctx context.Context, opts ...VGMergeOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGMerge(ctx, opts...)
+}
+
+func (l *lockingClient) VGLockStart(ctx context.Context, opts ...VGChangeOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGLockStart(ctx, opts...)
+}
+
+func (l *lockingClient) VGLockStop(ctx context.Context, opts ...VGChangeOption) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.clnt.VGLockStop(ctx, opts...)
+}
+
+func (l *lockingClient) Lockspaces(ctx context.Context) ([]*Lockspace, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.clnt.Lockspaces(ctx)
+}
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path/filepath"
 )
 
 var ErrVolumeGroupNameRequired = errors.New("VolumeGroupName is required for a fully qualified logical volume")
@@ -46,6 +47,15 @@ type LogicalVolume struct {
 
 	DataPercent     float64 `json:"data_percent"`
 	MetadataPercent float64 `json:"metadata_percent"`
+	CopyPercent     float64 `json:"copy_percent"`
+	SyncPercent     float64 `json:"sync_percent"`
+
+	MovePV     string `json:"move_pv"`
+	Merging    bool   `json:"lv_merging"`
+	Converting bool   `json:"lv_converting"`
+
+	// Mounts are not reported by lvm2 but read with ReadMounts, e.g. by Mount.
+	Mounts MountInfos `json:"-"`
 }
 
 func (lv *LogicalVolume) UnmarshalJSON(data []byte) error {
@@ -61,6 +71,7 @@ func (lv *LogicalVolume) UnmarshalJSON(data []byte) error {
 		"lv_path":      &lv.Path,
 		"origin":       &lv.Origin,
 		"pool_lv":      &lv.PoolLogicalVolume,
+		"move_pv":      &lv.MovePV,
 		"vg_name":      (*string)(&lv.VolumeGroupName),
 	} {
 		if val, ok := raw[key]; !ok {
@@ -90,12 +101,23 @@ func (lv *LogicalVolume) UnmarshalJSON(data []byte) error {
 	for key, fieldPtr := range map[string]*float64{
 		"data_percent":     &lv.DataPercent,
 		"metadata_percent": &lv.MetadataPercent,
+		"copy_percent":     &lv.CopyPercent,
+		"sync_percent":     &lv.SyncPercent,
 	} {
 		if err := unmarshalToStringAndParseFloat64(raw, key, fieldPtr); err != nil {
 			return err
 		}
 	}
 
+	for key, fieldPtr := range map[string]*bool{
+		"lv_merging":    &lv.Merging,
+		"lv_converting": &lv.Converting,
+	} {
+		if err := unmarshalToStringAndParseBinary(raw, key, fieldPtr); err != nil {
+			return err
+		}
+	}
+
 	for key, fieldPtr := range map[string]*Size{
 		"lv_size":     &lv.Size,
 		"origin_size": &lv.OriginSize,
@@ -154,6 +176,14 @@ func (opt LogicalVolumeName) ApplyToPVMoveOptions(opts *PVMoveOptions) {
 	opts.LogicalVolumeName = opt
 }
 
+func (opt LogicalVolumeName) ApplyToVGSplitOptions(opts *VGSplitOptions) {
+	opts.LogicalVolumeName = opt
+}
+
+func (opt LogicalVolumeName) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.LogicalVolumeName = opt
+}
+
 type FQLogicalVolumeName struct {
 	VolumeGroupName
 	LogicalVolumeName
@@ -192,6 +222,10 @@ func (opt *FQLogicalVolumeName) ApplyToLVsOptions(opts *LVsOptions) {
 	opts.VolumeGroupName, opts.LogicalVolumeName = opt.VolumeGroupName, opt.LogicalVolumeName
 }
 
+func (opt *FQLogicalVolumeName) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.VolumeGroupName, opts.LogicalVolumeName = opt.VolumeGroupName, opt.LogicalVolumeName
+}
+
 func (opt *FQLogicalVolumeName) Split() (VolumeGroupName, LogicalVolumeName) {
 	return opt.VolumeGroupName, opt.LogicalVolumeName
 }
@@ -206,6 +240,11 @@ func (opt *FQLogicalVolumeName) Validate() error {
 	return nil
 }
 
+// DevicePath returns the path of the device node udev creates for the active logical volume, /dev/vg/lv.
+func (opt *FQLogicalVolumeName) DevicePath() string {
+	return filepath.Join(DefaultDevRoot, string(opt.VolumeGroupName), string(opt.LogicalVolumeName))
+}
+
 func (opt *FQLogicalVolumeName) String() string {
 	return fmt.Sprintf("%s/%s", opt.VolumeGroupName, opt.LogicalVolumeName)
 }
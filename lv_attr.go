@@ -67,6 +67,9 @@ const (
 	VolumeTypeThinPool                   VolumeType = 't'
 	VolumeTypeThinPoolData               VolumeType = 'T'
 	VolumeTypeThinPoolMetadata           VolumeType = 'e'
+	VolumeTypeCache                      VolumeType = 'C'
+	VolumeTypeVDOPool                    VolumeType = 'd'
+	VolumeTypeVDOPoolData                VolumeType = 'D'
 	VolumeTypeNone                       VolumeType = '-'
 )
 
@@ -120,6 +123,12 @@ const (
 	StateUnknown                               State = 'X'
 )
 
+// IsActive returns true if the logical volume has a device-mapper device in any state,
+// e.g. suspended or with a thin pool check needed. An unknown state is not reported as active.
+func (state State) IsActive() bool {
+	return state != StateNone && state != StateHistorical && state != StateUnknown
+}
+
 type Open rune
 
 const (
@@ -210,3 +210,20 @@ func TestVerifyHealth(t *testing.T) {
 		})
 	}
 }
+
+func TestStateIsActive(t *testing.T) {
+	t.Parallel()
+	for state, active := range map[State]bool{
+		StateActive:                       true,
+		StateSuspended:                    true,
+		StateThinPoolCheckNeeded:          true,
+		StateSuspendedThinPoolCheckNeeded: true,
+		StateNone:                         false,
+		StateHistorical:                   false,
+		StateUnknown:                      false,
+	} {
+		if state.IsActive() != active {
+			t.Errorf("expected state %q to be active: %v", state, active)
+		}
+	}
+}
@@ -45,6 +45,8 @@ type (
 		*Compression
 		AutoActivation
 
+		*WaitForDeviceOptions
+
 		CommonOptions
 	}
 	LVChangeOption interface {
@@ -59,12 +61,24 @@ var (
 )
 
 func (c *client) LVChange(ctx context.Context, opts ...LVChangeOption) error {
-	args, err := LVChangeOptionsList(opts).AsArgs()
+	options := LVChangeOptions{}
+	for _, opt := range opts {
+		opt.ApplyToLVChangeOptions(&options)
+	}
+
+	args, err := LVChangeOptionsList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
-	return c.RunLVM(ctx, append([]string{"lvchange"}, args.GetRaw()...)...)
+	if err := c.RunLVM(ctx, append([]string{"lvchange"}, args.GetRaw()...)...); err != nil {
+		return err
+	}
+
+	if options.WaitForDeviceOptions == nil || !options.ActivationState.IsActivating() {
+		return nil
+	}
+	return c.waitForActivatedDevices(ctx, options.VolumeGroupName, options.LogicalVolumeName, *options.WaitForDeviceOptions)
 }
 
 func (opts *LVChangeOptions) ApplyToLVChangeOptions(new *LVChangeOptions) {
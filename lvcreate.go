@@ -38,11 +38,18 @@ type (
 		Type
 		Thin
 		*ThinPool
+		MaxOvercommit
 
 		Stripes
 		Mirrors
 		StripeSize
 
+		// PhysicalVolumeNames and PERanges restrict the allocation to the given physical volumes or extent ranges.
+		PhysicalVolumeNames
+		PERanges
+
+		*WaitForDeviceOptions
+
 		CommonOptions
 	}
 	LVCreateOption interface {
@@ -63,12 +70,34 @@ var (
 )
 
 func (c *client) LVCreate(ctx context.Context, opts ...LVCreateOption) error {
-	args, err := LVCreateOptionList(opts).AsArgs()
+	options := LVCreateOptions{}
+	for _, opt := range opts {
+		opt.ApplyToLVCreateOptions(&options)
+	}
+
+	args, err := LVCreateOptionList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
-	return c.RunLVM(ctx, append([]string{"lvcreate"}, args.GetRaw()...)...)
+	if options.MaxOvercommit > 0 && options.ThinPool != nil && options.VirtualSize.Val > 0 {
+		if err := c.checkThinPoolOvercommit(ctx, options.ThinPool, options.VirtualSize, options.MaxOvercommit); err != nil {
+			return err
+		}
+	}
+
+	if err := c.RunLVM(ctx, append([]string{"lvcreate"}, args.GetRaw()...)...); err != nil {
+		return err
+	}
+
+	if options.WaitForDeviceOptions == nil || options.ActivationState == Deactivate {
+		return nil
+	}
+	vg := options.VolumeGroupName
+	if options.ThinPool != nil {
+		vg = options.ThinPool.VolumeGroupName
+	}
+	return c.waitForActivatedDevices(ctx, vg, options.LogicalVolumeName, *options.WaitForDeviceOptions)
 }
 
 func (list LVCreateOptionList) AsArgs() (Arguments, error) {
@@ -128,6 +157,8 @@ func (opts *LVCreateOptions) ApplyToArgs(args Arguments) error {
 		opts.Zero,
 		opts.Tags,
 		opts.CommonOptions,
+		opts.PhysicalVolumeNames,
+		opts.PERanges,
 	) {
 		if err := arg.ApplyToArgs(args); err != nil {
 			return err
@@ -31,6 +31,14 @@ type (
 		PrefixedSize
 		PrefixedExtents
 
+		ResizeFS
+		ResizeFSMode
+
+		AllocationPolicy
+		// PhysicalVolumeNames and PERanges restrict the allocation to the given physical volumes or extent ranges.
+		PhysicalVolumeNames
+		PERanges
+
 		CommonOptions
 	}
 	LVExtendOption interface {
@@ -46,12 +54,26 @@ var (
 )
 
 func (c *client) LVExtend(ctx context.Context, opts ...LVExtendOption) error {
-	args, err := LVExtendOptionsList(opts).AsArgs()
+	options := LVExtendOptions{}
+	for _, opt := range opts {
+		opt.ApplyToLVExtendOptions(&options)
+	}
+
+	args, err := LVExtendOptionsList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
-	return c.RunLVM(ctx, append([]string{"lvextend"}, args.GetRaw()...)...)
+	if err := c.RunLVM(ctx, append([]string{"lvextend"}, args.GetRaw()...)...); err != nil {
+		return err
+	}
+
+	if options.ResizeFSMode == ResizeFSModeGo {
+		id := &FQLogicalVolumeName{VolumeGroupName: options.VolumeGroupName, LogicalVolumeName: options.LogicalVolumeName}
+		return GrowFileSystem(ctx, id.DevicePath())
+	}
+
+	return nil
 }
 
 func (opts *LVExtendOptions) ApplyToLVExtendOptions(new *LVExtendOptions) {
@@ -95,12 +117,21 @@ func (opts *LVExtendOptions) ApplyToArgs(args Arguments) error {
 		return errors.New("PoolMetadataPrefixedSize, Size or Extents is required")
 	}
 
+	if err := validateResizeFS(opts.ResizeFS, opts.ResizeFSMode); err != nil {
+		return err
+	}
+
 	for _, arg := range []Argument{
 		id,
 		opts.PrefixedSize,
 		opts.PrefixedExtents,
 		opts.PoolMetadataPrefixedSize,
+		opts.ResizeFS,
+		opts.ResizeFSMode,
+		opts.AllocationPolicy,
 		opts.CommonOptions,
+		opts.PhysicalVolumeNames,
+		opts.PERanges,
 	} {
 		if err := arg.ApplyToArgs(args); err != nil {
 			return err
@@ -64,6 +64,38 @@ var (
 	NoFreeExtentsPattern = regexp.MustCompile(`No free extents on physical volume "(.*?)"`)
 
 	ConfigurationSectionNotCustomizableByProfilePattern = regexp.MustCompile(`Configuration section "(.*?)" is not customizable by a profile\.`)
+
+	// LVMLockdNotRunningPattern is a regular expression that matches the error message when a shared volume group is accessed without lvmlockd.
+	LVMLockdNotRunningPattern = regexp.MustCompile(`lvmlockd (?:process )?is not running|check that lvmlockd is running|with lock type \S+ that requires lvmlockd`)
+
+	// LockManagerNotRunningPattern is a regular expression that matches the error message when the lock manager (sanlock, dlm or idm) used by lvmlockd is not running.
+	LockManagerNotRunningPattern = regexp.MustCompile(`lock manager (\S+) is not running`)
+
+	// LockspaceNotStartedPattern is a regular expression that matches the error message when the lockspace of a shared volume group is not (yet) started.
+	LockspaceNotStartedPattern = regexp.MustCompile(`lock start in progress|lockspace (?:is )?not (?:started|found)`)
+
+	// LockHeldByOtherHostPattern is a regular expression that matches the error message when a lock of a shared volume group is held by another host.
+	LockHeldByOtherHostPattern = regexp.MustCompile(`(?:VG|LV) (.*?) lock failed: held by other host`)
+
+	// ForeignVolumeGroupPattern is a regular expression that matches the error message when a volume group is owned by another host.
+	ForeignVolumeGroupPattern = regexp.MustCompile(`Cannot access VG (\S+) with system ID (\S+) with (?:local system ID (\S+?)\.?$|unknown local system ID)`)
+
+	// DuplicatePVNotUsingDevicePattern is a regular expression that matches the warning when lvm2 ignores a device because it holds a duplicate physical volume.
+	DuplicatePVNotUsingDevicePattern = regexp.MustCompile(`Not using device (\S+) for PV (\S+?)\.?$`)
+
+	// DuplicatePVPrefersDevicePattern is a regular expression that matches the warning when lvm2 chooses one of multiple devices holding the same physical volume.
+	DuplicatePVPrefersDevicePattern = regexp.MustCompile(`PV (\S+) prefers device (\S+)`)
+
+	// DuplicatePVPattern is a regular expression that matches any message about physical volumes found on multiple devices.
+	DuplicatePVPattern = regexp.MustCompile(fmt.Sprintf(`%s|%s|Cannot use device (\S+) with duplicates|[Dd]uplicate PVs? `,
+		DuplicatePVNotUsingDevicePattern, DuplicatePVPrefersDevicePattern))
+
+	// InconsistentMetadataPattern is a regular expression that matches the messages lvm2 reports
+	// when copies of the volume group metadata are corrupted or disagree with each other.
+	InconsistentMetadataPattern = regexp.MustCompile(`Inconsistent metadata found for VG (\S+)|ignoring metadata seqno \d+ on \S+ for seqno \d+|Checksum error at offset \d+|[Bb]ad metadata header`)
+
+	// LockFailedPattern is a regular expression that matches any failure to acquire a lock from lvmlockd.
+	LockFailedPattern = regexp.MustCompile(`(?:Global|VG \S+|LV \S+) lock failed|Cannot access VG (.*?) due to failed lock`)
 )
 
 // IsLVMError returns true if the error is an LVM error with a specific exit code and matches a specific pattern.
@@ -158,3 +190,55 @@ func IsNoFreeExtents(err error) bool {
 func IsConfigurationSectionNotCustomizableByProfile(err error) bool {
 	return IsLVMError(err, ConfigurationSectionNotCustomizableByProfilePattern)
 }
+
+// IsLVMLockdNotRunning returns true if a shared volume group was accessed while lvmlockd is not running or not enabled.
+func IsLVMLockdNotRunning(err error) bool {
+	return IsLVMError(err, LVMLockdNotRunningPattern)
+}
+
+// IsLockManagerNotRunning returns true if the lock manager used by lvmlockd is not running.
+func IsLockManagerNotRunning(err error) bool {
+	return IsLVMError(err, LockManagerNotRunningPattern)
+}
+
+// IsLockspaceNotStarted returns true if the lockspace of a shared volume group has to be started with LockStart first.
+func IsLockspaceNotStarted(err error) bool {
+	return IsLVMError(err, LockspaceNotStartedPattern)
+}
+
+// IsLockHeldByOtherHost returns true if a volume group or logical volume lock is held by another host,
+// e.g. because the logical volume is activated exclusively there.
+func IsLockHeldByOtherHost(err error) bool {
+	return IsLVMError(err, LockHeldByOtherHostPattern)
+}
+
+// IsLockFailed returns true if any lock could not be acquired from lvmlockd.
+func IsLockFailed(err error) bool {
+	return IsLVMError(err, LockFailedPattern)
+}
+
+// IsInconsistentMetadata returns true if a command found corrupted or inconsistent volume group metadata.
+// Use VGCheck with UpdateMetadata to repair it and PVCheck to inspect the metadata areas of a physical volume.
+func IsInconsistentMetadata(err error) bool {
+	return IsLVMError(err, InconsistentMetadataPattern)
+}
+
+// IsForeignVolumeGroup returns true if a command was refused because the volume group is owned by another host.
+func IsForeignVolumeGroup(err error) bool {
+	return IsLVMError(err, ForeignVolumeGroupPattern)
+}
+
+// ForeignVolumeGroupDetails returns the volume group, its system ID and the local system ID from a foreign volume group error.
+// The local system ID is empty if the host has no system ID.
+func ForeignVolumeGroupDetails(err error) (vg string, systemID string, localSystemID string, ok bool) {
+	stdErr, isStdErr := AsLVMStdErr(err)
+	if !isStdErr {
+		return "", "", "", false
+	}
+	for _, line := range stdErr.Lines(true) {
+		if submatches := ForeignVolumeGroupPattern.FindSubmatch(line); submatches != nil {
+			return string(submatches[1]), string(submatches[2]), string(submatches[3]), true
+		}
+	}
+	return "", "", "", false
+}
@@ -21,6 +21,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 )
 
@@ -59,6 +60,7 @@ type DeviceListEntry struct {
 	IDName  string       `json:"id_name"`
 	DevName string       `json:"dev_name"`
 	PVID    string       `json:"pvid"`
+	Part    int64        `json:"part"`
 }
 
 type (
@@ -113,6 +115,12 @@ func (c *client) DevList(ctx context.Context, opts ...DevListOption) ([]DeviceLi
 					entry.DevName = v
 				case "PVID":
 					entry.PVID = v
+				case "PART":
+					part, err := strconv.ParseInt(v, 10, 64)
+					if err != nil {
+						return fmt.Errorf("invalid device list partition: %q", v)
+					}
+					entry.Part = part
 				}
 			}
 			devList = append(devList, entry)
@@ -0,0 +1,253 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestParseLockspaces(t *testing.T) {
+	t.Parallel()
+	out := `VG vg1 lock_type=sanlock zk4bNP-cpbq-RTXi-TGyA-VJn0-xWez-t1ihyI
+LS sanlock lvm_vg1
+LK VG sh ver 4
+LK LV ex Jc5X2v-mBuh-2e1N-ZoRq-T6zd-rp8p-KgL2qh
+
+VG vg2 lock_type=dlm
+LS dlm lvm_vg2
+`
+	lockspaces, err := ParseLockspaces(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lockspaces) != 2 {
+		t.Fatalf("expected 2 lockspaces, got %d", len(lockspaces))
+	}
+
+	vg1 := lockspaces[0]
+	if vg1.VolumeGroupName != "vg1" || vg1.LockType != LockTypeSanlock || vg1.Name != "lvm_vg1" ||
+		vg1.VolumeGroupUUID != "zk4bNP-cpbq-RTXi-TGyA-VJn0-xWez-t1ihyI" {
+		t.Fatalf("unexpected lockspace %+v", vg1)
+	}
+	if lock := vg1.Lock("LV", "Jc5X2v-mBuh-2e1N-ZoRq-T6zd-rp8p-KgL2qh"); lock == nil || lock.Mode != LockspaceLockModeExclusive {
+		t.Fatalf("expected exclusive lv lock, got %+v", lock)
+	}
+	if lock := vg1.Lock("VG", ""); lock == nil || lock.Mode != LockspaceLockModeShared {
+		t.Fatalf("expected shared vg lock, got %+v", lock)
+	}
+	if vg2 := lockspaces[1]; vg2.LockType != LockTypeDLM || len(vg2.Locks) != 0 {
+		t.Fatalf("unexpected lockspace %+v", vg2)
+	}
+
+	if _, err := ParseLockspaces(strings.NewReader("LK VG sh ver 4\n")); err == nil {
+		t.Fatal("expected error for lock without lockspace")
+	}
+}
+
+func TestVGChangeOptionsList_Locking(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts VGChangeOptionsList
+		args []string
+		err  error
+	}{
+		{"lockstart all", VGChangeOptionsList{LockStart(true)}, []string{"--lockstart", "--yes"}, nil},
+		{"lockstart vg", VGChangeOptionsList{VolumeGroupName("vg"), LockStart(true), LockOptNoWait},
+			[]string{"vg", "--lockstart", "--lockopt=nowait", "--yes"}, nil},
+		{"lockstop all", VGChangeOptionsList{LockStop(true)}, []string{"--lockstop", "--yes"}, nil},
+		{"lockstart and lockstop", VGChangeOptionsList{LockStart(true), LockStop(true)}, nil, ErrLockStartAndLockStop},
+		{"locktype", VGChangeOptionsList{VolumeGroupName("vg"), LockTypeNone}, []string{"vg", "--locktype=none", "--yes"}, nil},
+		{"exclusive activation", VGChangeOptionsList{VolumeGroupName("vg"), ActivateExclusive},
+			[]string{"vg", "--activate", "ey", "--yes"}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestLockErrors(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		stderr string
+		is     func(error) bool
+	}{
+		{"  Cannot access VG vg1 with lock type sanlock that requires lvmlockd.", IsLVMLockdNotRunning},
+		{"  VG vg1 lock failed: lock manager sanlock is not running", IsLockManagerNotRunning},
+		{"  VG vg1 lock failed: lock start in progress", IsLockspaceNotStarted},
+		{"  LV vg1/lv1 lock failed: held by other host.", IsLockHeldByOtherHost},
+		{"  LV vg1/lv1 lock failed: held by other host.", IsLockFailed},
+		{"  Global lock failed: check that lvmlockd is running.", IsLVMLockdNotRunning},
+	} {
+		t.Run(tc.stderr, func(t *testing.T) {
+			t.Parallel()
+			if err := NewLVMStdErr([]byte(tc.stderr)); !tc.is(err) {
+				t.Fatalf("expected %q to match", tc.stderr)
+			}
+		})
+	}
+}
+
+// lvmlockdSocket is the socket lvmlockd listens on for requests of lvm commands and lvmlockctl.
+const lvmlockdSocket = "/run/lvm/lvmlockd.socket"
+
+// startTestLockManager starts lvmlockd in test mode, in which it does not call a real lock manager,
+// and returns a context that enables lvmlockd for all lvm commands run with it.
+func startTestLockManager(t *testing.T) context.Context {
+	t.Helper()
+
+	// lvmlockd and all lvm commands run on the host when containerized,
+	// where neither the lvm.conf nor the socket of this process are visible.
+	if IsContainerized(context.Background()) {
+		t.Skip("lvmlockd cannot be started in test mode when containerized")
+	}
+
+	systemDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(systemDir, "lvm.conf"), []byte("global {\n\tuse_lvmlockd = 1\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := WithCustomEnvironment(context.Background(), map[string]string{LVMSystemDirEnv: systemDir})
+
+	// lvm commands and lvmlockctl connect to whichever lvmlockd listens on the socket,
+	// so a running lvmlockd of the host or a stale socket would be used instead of the test daemon.
+	if _, err := os.Stat(lvmlockdSocket); err == nil {
+		t.Skipf("%s already exists, lvmlockd is running on the host or did not clean up its socket", lvmlockdSocket)
+	}
+
+	daemonCtx, cancel := context.WithCancel(ctx)
+	daemon := CommandContext(daemonCtx, "lvmlockd", "--test", "--foreground")
+	if err := daemon.Start(); err != nil {
+		cancel()
+		if errors.Is(err, exec.ErrNotFound) {
+			t.Skip("lvmlockd is not available")
+		}
+		t.Fatal(err)
+	}
+	exited := make(chan struct{})
+	var exitErr error
+	go func() {
+		exitErr = daemon.Wait()
+		close(exited)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-exited
+	})
+
+	// the daemon is ready once it answers requests on its socket.
+	clnt := NewClient()
+	deadline := time.After(10 * time.Second)
+	for {
+		if _, err := clnt.Lockspaces(ctx); err == nil {
+			break
+		}
+		select {
+		case <-exited:
+			t.Fatalf("lvmlockd exited before it was ready: %v", exitErr)
+		case <-deadline:
+			t.Fatal("lvmlockd did not start in time")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	return ctx
+}
+
+func TestSharedVolumeGroup(t *testing.T) {
+	SkipOrFailTestIfNotRoot(t)
+
+	ctx := startTestLockManager(t)
+	clnt := NewClient()
+
+	// sanlock requires a lease volume of 256MiB in every shared volume group.
+	loop := MakeTestLoopbackDevice(t, MustParseSize("512M"))
+	vgName := VolumeGroupName(NewNonDeterministicTestID(t))
+	lvName := LogicalVolumeName(NewNonDeterministicTestID(t))
+
+	if err := clnt.VGCreate(ctx, vgName, PhysicalVolumeNames{PhysicalVolumeName(loop.Device())},
+		Shared(true), LockTypeSanlock, PhysicalExtentSize(TestExtentSize)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := clnt.VGLockStop(ctx, vgName); err != nil {
+			t.Logf("failed to stop lockspace of %s: %v", vgName, err)
+		}
+		if err := clnt.VGRemove(ctx, vgName, Force(true)); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := clnt.VGLockStart(ctx, vgName); err != nil {
+		t.Fatal(err)
+	}
+
+	vg, err := clnt.VG(ctx, vgName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vg.IsShared() {
+		t.Fatalf("expected volume group %s to be shared, lock type is %q", vgName, vg.LockType)
+	}
+
+	if err := clnt.LVCreate(ctx, vgName, lvName, MustParseSize("8M"), ActivateExclusive); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.LVChange(ctx, vgName, lvName, Deactivate); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.LVChange(ctx, vgName, lvName, ActivateShared); err != nil {
+		t.Fatal(err)
+	}
+
+	lockspaces, err := clnt.Lockspaces(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.ContainsFunc(lockspaces, func(ls *Lockspace) bool {
+		return ls.VolumeGroupName == vgName
+	}) {
+		t.Fatalf("expected a started lockspace for %s, got %v", vgName, lockspaces)
+	}
+
+	if err := clnt.LVChange(ctx, vgName, lvName, Deactivate); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.LVRemove(ctx, vgName, lvName); err != nil {
+		t.Fatal(err)
+	}
+}
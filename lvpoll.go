@@ -0,0 +1,334 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrPollOperationRequired = errors.New("PollOperation is required for polling a logical volume")
+
+// DefaultPollInterval is the interval used by Poll if no PollInterval is given.
+// It matches the lvm2 default of activation/polling_interval.
+var DefaultPollInterval = 15 * time.Second
+
+// PollProgressPattern matches the progress lines printed by lvpoll, e.g. "vg/pvmove0: Moved: 42.00%".
+var PollProgressPattern = regexp.MustCompile(`^(\S+): (\w+): (\d+(?:\.\d+)?)%$`)
+
+type PollOperation string
+
+const (
+	PollOperationPVMove    PollOperation = "pvmove"
+	PollOperationConvert   PollOperation = "convert"
+	PollOperationMerge     PollOperation = "merge"
+	PollOperationMergeThin PollOperation = "merge_thin"
+)
+
+func (opt PollOperation) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.PollOperation = opt
+}
+
+func (opt PollOperation) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--polloperation=%s", string(opt)))
+	return nil
+}
+
+// PollInterval is the interval in which lvpoll checks the progress of an operation.
+// lvm2 only supports whole seconds, so the interval is rounded up.
+type PollInterval time.Duration
+
+func (opt PollInterval) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.PollInterval = opt
+}
+
+func (opt PollInterval) ApplyToPVMoveOptions(opts *PVMoveOptions) {
+	opts.PollInterval = opt
+}
+
+func (opt PollInterval) ApplyToArgs(args Arguments) error {
+	if opt <= 0 {
+		return nil
+	}
+	seconds := int64(math.Ceil(time.Duration(opt).Seconds()))
+	args.AddOrReplace(fmt.Sprintf("--interval=%d", seconds))
+	return nil
+}
+
+// AbortPoll aborts the polled operation instead of waiting for its completion.
+type AbortPoll bool
+
+func (opt AbortPoll) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.AbortPoll = opt
+}
+
+func (opt AbortPoll) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--abort")
+	}
+	return nil
+}
+
+// HandleMissingPVs allows the polled operation to continue while physical volumes are missing.
+type HandleMissingPVs bool
+
+func (opt HandleMissingPVs) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.HandleMissingPVs = opt
+}
+
+func (opt HandleMissingPVs) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--handlemissingpvs")
+	}
+	return nil
+}
+
+// PollProgress is a single progress update reported by lvpoll.
+type PollProgress struct {
+	// Name is the name of the polled logical volume in the form of vg/lv.
+	Name string
+	// Title is the kind of progress reported, e.g. "Moved", "Converted" or "Merged".
+	Title string
+	// Percent is the progress of the operation between 0 and 100.
+	Percent float64
+}
+
+// ParsePollProgress parses a progress line as printed by lvpoll.
+// If the line is not a progress line, false is returned.
+func ParsePollProgress(line string) (PollProgress, bool) {
+	matches := PollProgressPattern.FindStringSubmatch(strings.TrimSpace(line))
+	if matches == nil {
+		return PollProgress{}, false
+	}
+	percent, err := strconv.ParseFloat(matches[3], 64)
+	if err != nil {
+		return PollProgress{}, false
+	}
+	return PollProgress{Name: matches[1], Title: matches[2], Percent: percent}, true
+}
+
+// PollProgressHandler is called for every progress update reported while polling.
+type PollProgressHandler func(progress PollProgress)
+
+func (opt PollProgressHandler) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.PollProgressHandler = opt
+}
+
+func (opt PollProgressHandler) ApplyToPVMoveOptions(opts *PVMoveOptions) {
+	opts.PollProgressHandler = opt
+}
+
+type (
+	LVPollOptions struct {
+		VolumeGroupName
+		LogicalVolumeName
+
+		PollOperation
+		PollInterval
+		AbortPoll
+		HandleMissingPVs
+
+		PollProgressHandler
+
+		CommonOptions
+	}
+	LVPollOption interface {
+		ApplyToLVPollOptions(opts *LVPollOptions)
+	}
+	LVPollOptionsList []LVPollOption
+)
+
+var (
+	_ ArgumentGenerator = LVPollOptionsList{}
+	_ Argument          = (*LVPollOptions)(nil)
+)
+
+func (c *client) Poll(ctx context.Context, opts ...LVPollOption) error {
+	options := LVPollOptions{}
+	for _, opt := range opts {
+		opt.ApplyToLVPollOptions(&options)
+	}
+
+	args := NewArgs(ArgsTypeGeneric)
+	if err := options.ApplyToArgs(args); err != nil {
+		return err
+	}
+
+	return c.RunLVMRaw(ctx, pollProgressProcessor(ctx, options.PollProgressHandler), append([]string{"lvpoll"}, args.GetRaw()...)...)
+}
+
+// pollProgressProcessor passes the progress lines printed by lvpoll and pvmove to the handler, which may be nil.
+func pollProgressProcessor(ctx context.Context, handler PollProgressHandler) RawOutputProcessor {
+	return func(out io.Reader) error {
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if progress, ok := ParsePollProgress(line); ok {
+				slog.DebugContext(ctx, "poll progress",
+					slog.String("lv", progress.Name),
+					slog.String("title", progress.Title),
+					slog.Float64("percent", progress.Percent),
+				)
+				if handler != nil {
+					handler(progress)
+				}
+			} else if len(line) > 0 {
+				slog.InfoContext(ctx, line)
+			}
+		}
+		return scanner.Err()
+	}
+}
+
+func (list LVPollOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := LVPollOptions{}
+	for _, opt := range list {
+		opt.ApplyToLVPollOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *LVPollOptions) ApplyToLVPollOptions(new *LVPollOptions) {
+	*new = *opts
+}
+
+func (opts *LVPollOptions) ApplyToArgs(args Arguments) error {
+	id, err := NewFQLogicalVolumeName(opts.VolumeGroupName, opts.LogicalVolumeName)
+	if err != nil {
+		return err
+	}
+
+	if opts.PollOperation == "" {
+		return ErrPollOperationRequired
+	}
+
+	interval := opts.PollInterval
+	if interval <= 0 {
+		interval = PollInterval(DefaultPollInterval)
+	}
+
+	for _, arg := range []Argument{
+		opts.PollOperation,
+		interval,
+		opts.AbortPoll,
+		opts.HandleMissingPVs,
+		id,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PollingOperation is an operation that is currently in progress in the background
+// and polled by lvm2 (either through lvmpolld or a background lvpoll process).
+type PollingOperation struct {
+	PollOperation
+	// LogicalVolume is the logical volume that is subject to the operation.
+	// For pvmove operations this is the internal pvmove volume.
+	LogicalVolume *LogicalVolume
+	// Percent is the progress as reported by copy_percent or sync_percent.
+	// Merges do not report their progress through these fields and report 0.
+	Percent float64
+}
+
+// PollingOperationsColumnOptions are the report columns required to detect in-flight polling operations.
+var PollingOperationsColumnOptions = ColumnOptions{
+	"lv_uuid", "lv_name", "lv_full_name", "vg_name", "lv_attr", "move_pv",
+	"copy_percent", "sync_percent", "lv_merging", "lv_converting",
+}
+
+// PollingOperationsLVsOptions returns the options PollingOperations reports the logical volumes with.
+// The columns required to detect polling operations are added to the columns requested in the options.
+func PollingOperationsLVsOptions(opts ...LVsOption) LVsOptionsList {
+	options := LVsOptions{ShowInternal: true}
+	for _, opt := range opts {
+		opt.ApplyToLVsOptions(&options)
+	}
+	columns := slices.Clone(options.ColumnOptions)
+	for _, column := range PollingOperationsColumnOptions {
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	options.ColumnOptions = columns
+	return LVsOptionsList{&options}
+}
+
+func (c *client) PollingOperations(ctx context.Context, opts ...LVsOption) ([]*PollingOperation, error) {
+	lvs, err := c.LVs(ctx, PollingOperationsLVsOptions(opts...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	ops := make([]*PollingOperation, 0)
+	for _, lv := range lvs {
+		if op := NewPollingOperation(lv); op != nil {
+			ops = append(ops, op)
+		}
+	}
+	return ops, nil
+}
+
+// NewPollingOperation determines the in-flight polling operation of the logical volume based on its report fields.
+// If no operation is in progress, nil is returned.
+func NewPollingOperation(lv *LogicalVolume) *PollingOperation {
+	var operation PollOperation
+	switch {
+	case lv.Attr.VolumeType == VolumeTypePVMove || lv.MovePV != "":
+		operation = PollOperationPVMove
+	case lv.Merging && lv.Attr.VolumeType == VolumeTypeThinVolume:
+		operation = PollOperationMergeThin
+	case lv.Merging:
+		operation = PollOperationMerge
+	case lv.Converting || lv.Attr.VolumeType == VolumeTypeUnderConversion:
+		operation = PollOperationConvert
+	default:
+		return nil
+	}
+
+	percent := lv.CopyPercent
+	if percent == 0 {
+		percent = lv.SyncPercent
+	}
+
+	return &PollingOperation{
+		PollOperation: operation,
+		LogicalVolume: lv,
+		Percent:       percent,
+	}
+}
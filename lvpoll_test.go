@@ -0,0 +1,169 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestParsePollProgress(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name string
+		line string
+		want PollProgress
+		ok   bool
+	}{
+		{"pvmove", "  vg/pvmove0: Moved: 42.50%", PollProgress{Name: "vg/pvmove0", Title: "Moved", Percent: 42.5}, true},
+		{"convert", "vg/lv: Converted: 100.00%", PollProgress{Name: "vg/lv", Title: "Converted", Percent: 100}, true},
+		{"merge", "vg/snap: Merged: 0%", PollProgress{Name: "vg/snap", Title: "Merged", Percent: 0}, true},
+		{"other output", "Polling LV vg/lv", PollProgress{}, false},
+		{"empty", "", PollProgress{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, ok := ParsePollProgress(tt.line)
+			if ok != tt.ok {
+				t.Fatalf("ParsePollProgress() ok = %v, want %v", ok, tt.ok)
+			}
+			if got != tt.want {
+				t.Fatalf("ParsePollProgress() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLVPollOptionsList_AsArgs(t *testing.T) {
+	t.Parallel()
+
+	args, err := LVPollOptionsList{
+		MustNewFQLogicalVolumeName("vg", "pvmove0"),
+		PollOperationPVMove,
+		PollInterval(1500 * time.Millisecond),
+	}.AsArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"--polloperation=pvmove", "--interval=2", "vg/pvmove0"} {
+		if !slices.Contains(args.GetRaw(), expected) {
+			t.Fatalf("expected %q in %v", expected, args.GetRaw())
+		}
+	}
+
+	if _, err := (LVPollOptionsList{MustNewFQLogicalVolumeName("vg", "lv")}).AsArgs(); err == nil {
+		t.Fatal("expected error due to missing poll operation")
+	}
+}
+
+func TestPollingOperationsLVsOptions(t *testing.T) {
+	t.Parallel()
+
+	args, err := PollingOperationsLVsOptions(ColumnOptions{"lv_name", "lv_size"}, MustNewFQLogicalVolumeName("vg", "lv")).AsArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw := args.GetRaw()
+	if !slices.Contains(raw, "--all") {
+		t.Fatalf("expected internal logical volumes to be reported in %v", raw)
+	}
+	expected := "lv_name,lv_size,lv_uuid,lv_full_name,vg_name,lv_attr,move_pv,copy_percent,sync_percent,lv_merging,lv_converting"
+	if idx := slices.Index(raw, "--options"); idx < 0 || idx+1 >= len(raw) || raw[idx+1] != expected {
+		t.Fatalf("expected the requested and required columns %q in %v", expected, raw)
+	}
+}
+
+func TestNewPollingOperation(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name    string
+		lv      *LogicalVolume
+		want    PollOperation
+		percent float64
+	}{
+		{"pvmove", &LogicalVolume{Attr: LVAttributes{VolumeType: VolumeTypePVMove}, MovePV: "/dev/sdb", CopyPercent: 12.5}, PollOperationPVMove, 12.5},
+		{"thin merge", &LogicalVolume{Attr: LVAttributes{VolumeType: VolumeTypeThinVolume}, Merging: true}, PollOperationMergeThin, 0},
+		{"snapshot merge", &LogicalVolume{Attr: LVAttributes{VolumeType: VolumeTypeOriginWithMergingSnapshot}, Merging: true}, PollOperationMerge, 0},
+		{"convert", &LogicalVolume{Attr: LVAttributes{VolumeType: VolumeTypeMirrored}, Converting: true, SyncPercent: 50}, PollOperationConvert, 50},
+		{"idle", &LogicalVolume{Attr: LVAttributes{VolumeType: VolumeTypeNone}}, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			op := NewPollingOperation(tt.lv)
+			if tt.want == "" {
+				if op != nil {
+					t.Fatalf("expected no polling operation, got %v", op.PollOperation)
+				}
+				return
+			}
+			if op == nil {
+				t.Fatalf("expected polling operation %q, got none", tt.want)
+			}
+			if op.PollOperation != tt.want {
+				t.Fatalf("expected polling operation %q, got %q", tt.want, op.PollOperation)
+			}
+			if op.Percent != tt.percent {
+				t.Fatalf("expected percent %v, got %v", tt.percent, op.Percent)
+			}
+		})
+	}
+}
+
+func TestLogicalVolumeBinaryFields(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		value  string
+		expect bool
+	}{
+		{"merging", true},
+		{"1", true},
+		{"", false},
+		{"0", false},
+		{"-1", false},
+		{"unknown", false},
+		{"converting", false},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			t.Parallel()
+			lv := &LogicalVolume{}
+			if err := json.Unmarshal([]byte(fmt.Sprintf(`{"lv_merging":%q}`, tc.value)), lv); err != nil {
+				t.Fatal(err)
+			}
+			if lv.Merging != tc.expect {
+				t.Fatalf("expected merging to be %t for %q", tc.expect, tc.value)
+			}
+
+			// the generic report decoder has to decode binary fields the same way
+			var reported struct {
+				Merging bool `lvm:"lv_merging"`
+			}
+			if err := DecodeReportRow(map[string]string{"lv_merging": tc.value}, &reported); err != nil {
+				t.Fatal(err)
+			}
+			if reported.Merging != tc.expect {
+				t.Fatalf("expected reported merging to be %t for %q", tc.expect, tc.value)
+			}
+		})
+	}
+}
@@ -26,6 +26,13 @@ type (
 	LVReduceOptions struct {
 		VolumeGroupName
 		LogicalVolumeName
+
+		PrefixedSize
+		PrefixedExtents
+
+		ResizeFS
+		ResizeFSMode
+
 		CommonOptions
 	}
 	LVReduceOption interface {
@@ -37,21 +44,89 @@ type (
 var (
 	_ ArgumentGenerator = LVReduceOptionsList{}
 	_ Argument          = (*LVReduceOptions)(nil)
+	_ LVReduceOption    = (*LVReduceOptions)(nil)
 )
 
 func (c *client) LVReduce(ctx context.Context, opts ...LVReduceOption) error {
-	args, err := LVReduceOptionsList(opts).AsArgs()
+	options := LVReduceOptions{}
+	for _, opt := range opts {
+		opt.ApplyToLVReduceOptions(&options)
+	}
+
+	args, err := LVReduceOptionsList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
+	if options.ResizeFSMode == ResizeFSModeGo {
+		if options.Extents.Val > 0 {
+			return fmt.Errorf("%s requires a size instead of extents", ResizeFSModeGo)
+		}
+		id := &FQLogicalVolumeName{VolumeGroupName: options.VolumeGroupName, LogicalVolumeName: options.LogicalVolumeName}
+		shrink, target, err := resizeFileSystemTarget(id.DevicePath(), options.PrefixedSize)
+		if err != nil {
+			return err
+		}
+		if shrink {
+			if err := ShrinkFileSystem(ctx, id.DevicePath(), target); err != nil {
+				return err
+			}
+		}
+	}
+
 	return c.RunLVM(ctx, append([]string{"lvreduce"}, args.GetRaw()...)...)
 }
 
+func (opts *LVReduceOptions) ApplyToLVReduceOptions(new *LVReduceOptions) {
+	*new = *opts
+}
+
 func (list LVReduceOptionsList) AsArgs() (Arguments, error) {
-	return nil, fmt.Errorf("not implemented: %w", errors.ErrUnsupported)
+	args := NewArgs(ArgsTypeGeneric)
+	options := LVReduceOptions{}
+	for _, opt := range list {
+		opt.ApplyToLVReduceOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
 }
 
-func (opts *LVReduceOptions) ApplyToArgs(_ Arguments) error {
-	return fmt.Errorf("not implemented: %w", errors.ErrUnsupported)
+func (opts *LVReduceOptions) ApplyToArgs(args Arguments) error {
+	id, err := NewFQLogicalVolumeName(opts.VolumeGroupName, opts.LogicalVolumeName)
+	if err != nil {
+		return err
+	}
+
+	if opts.Extents.Val > 0 && opts.PrefixedSize.Val > 0 {
+		return fmt.Errorf("size and extents are mutually exclusive")
+	} else if opts.Extents.Val <= 0 && opts.PrefixedSize.Val <= 0 {
+		return errors.New("Size or Extents is required")
+	}
+
+	if opts.PrefixedSize.SizePrefix == SizePrefixPlus {
+		return fmt.Errorf("size prefix must be negative")
+	} else if opts.PrefixedExtents.SizePrefix == SizePrefixPlus {
+		return fmt.Errorf("extents prefix must be negative")
+	}
+
+	if err := validateResizeFS(opts.ResizeFS, opts.ResizeFSMode); err != nil {
+		return err
+	}
+
+	for _, arg := range []Argument{
+		id,
+		opts.PrefixedSize,
+		opts.PrefixedExtents,
+		opts.ResizeFS,
+		opts.ResizeFSMode,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
@@ -27,6 +27,14 @@ type (
 
 		PrefixedSize
 
+		ResizeFS
+		ResizeFSMode
+
+		AllocationPolicy
+		// PhysicalVolumeNames and PERanges restrict the allocation to the given physical volumes or extent ranges.
+		PhysicalVolumeNames
+		PERanges
+
 		CommonOptions
 	}
 	LVResizeOption interface {
@@ -41,12 +49,37 @@ var (
 )
 
 func (c *client) LVResize(ctx context.Context, opts ...LVResizeOption) error {
-	args, err := LVResizeOptionsList(opts).AsArgs()
+	options := LVResizeOptions{}
+	for _, opt := range opts {
+		opt.ApplyToLVResizeOptions(&options)
+	}
+
+	args, err := LVResizeOptionsList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
-	return c.RunLVM(ctx, append([]string{"lvresize"}, args.GetRaw()...)...)
+	if options.ResizeFSMode != ResizeFSModeGo {
+		return c.RunLVM(ctx, append([]string{"lvresize"}, args.GetRaw()...)...)
+	}
+
+	id := &FQLogicalVolumeName{VolumeGroupName: options.VolumeGroupName, LogicalVolumeName: options.LogicalVolumeName}
+	shrink, target, err := resizeFileSystemTarget(id.DevicePath(), options.PrefixedSize)
+	if err != nil {
+		return err
+	}
+	if shrink {
+		if err := ShrinkFileSystem(ctx, id.DevicePath(), target); err != nil {
+			return err
+		}
+	}
+	if err := c.RunLVM(ctx, append([]string{"lvresize"}, args.GetRaw()...)...); err != nil {
+		return err
+	}
+	if !shrink {
+		return GrowFileSystem(ctx, id.DevicePath())
+	}
+	return nil
 }
 
 func (opts *LVResizeOptions) ApplyToLVResizeOptions(new *LVResizeOptions) {
@@ -71,10 +104,19 @@ func (opts *LVResizeOptions) ApplyToArgs(args Arguments) error {
 		return err
 	}
 
+	if err := validateResizeFS(opts.ResizeFS, opts.ResizeFSMode); err != nil {
+		return err
+	}
+
 	for _, opt := range []Argument{
 		id,
 		opts.PrefixedSize,
+		opts.ResizeFS,
+		opts.ResizeFSMode,
+		opts.AllocationPolicy,
 		opts.CommonOptions,
+		opts.PhysicalVolumeNames,
+		opts.PERanges,
 	} {
 		if err := opt.ApplyToArgs(args); err != nil {
 			return err
@@ -27,6 +27,8 @@ type (
 		Tags
 		Unit
 		Select
+		ShowInternal
+		Foreign
 
 		ColumnOptions
 		CommonOptions
@@ -126,6 +128,8 @@ func (opts *LVsOptions) ApplyToArgs(args Arguments) error {
 		opts.CommonOptions,
 		opts.ColumnOptions,
 		opts.Select,
+		opts.ShowInternal,
+		opts.Foreign,
 	} {
 		if err := arg.ApplyToArgs(args); err != nil {
 			return err
@@ -0,0 +1,92 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+)
+
+// LVSegments returns the segments of all logical volumes that match the given options.
+// If no segments are found, nil is returned.
+// It is a wrapper around the `lvs --segments --reportformat json` command.
+func (c *client) LVSegments(ctx context.Context, opts ...LVsOption) ([]*LVSegment, error) {
+	type segReport struct {
+		Report []struct {
+			Seg []*LVSegment `json:"seg"`
+		} `json:"report"`
+	}
+
+	var res = new(segReport)
+
+	args := []string{
+		"lvs", "--segments", "--reportformat", "json",
+	}
+	argsFromOpts, err := LVSegmentsOptionsList(opts).AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.RunLVMInto(ctx, res, append(args, argsFromOpts.GetRaw()...)...)
+
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Report) == 0 {
+		return nil, nil
+	}
+
+	segs := res.Report[0].Seg
+
+	if len(segs) == 0 {
+		return nil, nil
+	}
+
+	return segs, nil
+}
+
+// LVSegmentsOptionsList generates arguments for lvs --segments from LVsOption.
+// In comparison to LVsOptionsList, a logical volume name can be used to only report the segments of that volume.
+type LVSegmentsOptionsList []LVsOption
+
+var _ ArgumentGenerator = LVSegmentsOptionsList{}
+
+func (list LVSegmentsOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeLVSegments)
+	options := LVsOptions{}
+	for _, opt := range list {
+		opt.ApplyToLVsOptions(&options)
+	}
+	if options.LogicalVolumeName != "" {
+		id, err := NewFQLogicalVolumeName(options.VolumeGroupName, options.LogicalVolumeName)
+		if err != nil {
+			return nil, err
+		}
+		if err := id.ApplyToArgs(args); err != nil {
+			return nil, err
+		}
+		options.VolumeGroupName = ""
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
@@ -0,0 +1,171 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// MountInfoPath is the mountinfo file of the current mount namespace that is read to find mounted devices.
+const MountInfoPath = "/proc/self/mountinfo"
+
+// HostMountInfoPath is the mountinfo file of the host mount namespace that is read instead of MountInfoPath
+// when running containerized, as filesystem tools are run on the host as well, see CommandContext.
+const HostMountInfoPath = "/proc/1/mountinfo"
+
+// MountInfo is a mount as listed in the mountinfo file, see man 5 proc_pid_mountinfo.
+type MountInfo struct {
+	MountID  int64
+	ParentID int64
+	Major    int64
+	Minor    int64
+	// Root is the path of the directory in the filesystem that is mounted at MountPoint, e.g. for bind mounts.
+	Root         string
+	MountPoint   string
+	MountOptions []string
+	FSType       string
+	Source       string
+	SuperOptions []string
+}
+
+// IsReadOnly returns true if the mount is read-only.
+func (m MountInfo) IsReadOnly() bool {
+	for _, opt := range m.MountOptions {
+		if opt == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+// MountInfos are the mounts of a mount namespace.
+type MountInfos []MountInfo
+
+// OfDevice returns the mounts of the device with the major and minor number.
+// Filesystems such as btrfs report an anonymous device number in mountinfo, their mounts are
+// matched by the device number of the resolved Source instead.
+func (mounts MountInfos) OfDevice(major, minor int64) MountInfos {
+	var result MountInfos
+	for _, mount := range mounts {
+		if mount.Major == major && mount.Minor == minor {
+			result = append(result, mount)
+			continue
+		}
+		if !strings.HasPrefix(mount.Source, "/") {
+			continue
+		}
+		if m, n, err := DeviceNumber(mount.Source); err == nil && m == major && n == minor {
+			result = append(result, mount)
+		}
+	}
+	return result
+}
+
+// ReadMountInfo reads and parses MountInfoPath, or HostMountInfoPath when running containerized.
+func ReadMountInfo(ctx context.Context) (MountInfos, error) {
+	path := MountInfoPath
+	if IsContainerized(ctx) {
+		path = HostMountInfoPath
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return ParseMountInfo(f)
+}
+
+// ParseMountInfo parses the lines of a mountinfo file, e.g.
+// 36 35 98:0 /mnt1 /mnt2 rw,noatime master:1 - ext3 /dev/root rw,errors=continue
+func ParseMountInfo(r io.Reader) (MountInfos, error) {
+	var mounts MountInfos
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		separator := -1
+		for i := 6; i < len(fields); i++ {
+			if fields[i] == "-" {
+				separator = i
+				break
+			}
+		}
+		if len(fields) < 7 || separator < 0 || separator+2 >= len(fields) {
+			return nil, fmt.Errorf("invalid mountinfo line %q", line)
+		}
+
+		mount := MountInfo{
+			Root:         unescapeMountInfo(fields[3]),
+			MountPoint:   unescapeMountInfo(fields[4]),
+			MountOptions: strings.Split(fields[5], ","),
+			FSType:       fields[separator+1],
+			Source:       unescapeMountInfo(fields[separator+2]),
+		}
+		if separator+3 < len(fields) {
+			mount.SuperOptions = strings.Split(fields[separator+3], ",")
+		}
+		var err error
+		if mount.MountID, err = strconv.ParseInt(fields[0], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid mount id in mountinfo line %q: %w", line, err)
+		}
+		if mount.ParentID, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid parent id in mountinfo line %q: %w", line, err)
+		}
+		major, minor, ok := strings.Cut(fields[2], ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid device number in mountinfo line %q", line)
+		}
+		if mount.Major, err = strconv.ParseInt(major, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid major number in mountinfo line %q: %w", line, err)
+		}
+		if mount.Minor, err = strconv.ParseInt(minor, 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid minor number in mountinfo line %q: %w", line, err)
+		}
+		mounts = append(mounts, mount)
+	}
+	return mounts, scanner.Err()
+}
+
+// unescapeMountInfo replaces the octal escapes of space, tab, newline and backslash in mountinfo paths.
+func unescapeMountInfo(field string) string {
+	if !strings.Contains(field, `\`) {
+		return field
+	}
+	var b strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] == '\\' && i+3 < len(field) {
+			if value, err := strconv.ParseUint(field[i+1:i+4], 8, 8); err == nil {
+				b.WriteByte(byte(value))
+				i += 3
+				continue
+			}
+		}
+		b.WriteByte(field[i])
+	}
+	return b.String()
+}
@@ -54,3 +54,34 @@ var resolveLVMPathFromHost = sync.OnceValue(func() string {
 		return path
 	}
 })
+
+// Tool is an external binary that is run besides lvm, e.g. to create, check or resize filesystems.
+// Tools are looked up by their name in PATH unless a path is set with SetToolPath.
+type Tool string
+
+var (
+	toolPathsLock = &sync.Mutex{}
+	toolPaths     = map[Tool]string{}
+)
+
+// SetToolPath sets the path to the binary of the tool.
+// An empty path resets the tool to be looked up by its name.
+func SetToolPath(tool Tool, path string) {
+	toolPathsLock.Lock()
+	defer toolPathsLock.Unlock()
+	if path == "" {
+		delete(toolPaths, tool)
+	} else {
+		toolPaths[tool] = path
+	}
+}
+
+// GetToolPath returns the path to the binary of the tool, or its name if no path was set.
+func GetToolPath(tool Tool) string {
+	toolPathsLock.Lock()
+	defer toolPathsLock.Unlock()
+	if path, ok := toolPaths[tool]; ok {
+		return path
+	}
+	return string(tool)
+}
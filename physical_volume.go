@@ -127,9 +127,30 @@ func (opt PhysicalVolumeName) ApplyToPVChangeOptions(opts *PVChangeOptions) {
 func (opt PhysicalVolumeName) ApplyToPVRemoveOptions(opts *PVRemoveOptions) {
 	opts.PhysicalVolumeName = opt
 }
+func (opt PhysicalVolumeName) ApplyToVGImportCloneOptions(opts *VGImportCloneOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt)
+}
+func (opt PhysicalVolumeName) ApplyToVGSplitOptions(opts *VGSplitOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt)
+}
+func (opt PhysicalVolumeName) ApplyToPVCheckOptions(opts *PVCheckOptions) {
+	opts.PhysicalVolumeName = opt
+}
+func (opt PhysicalVolumeName) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.PhysicalVolumeName = opt
+}
 func (opt PhysicalVolumeName) ApplyToPVMoveOptions(opts *PVMoveOptions) {
 	opts.SetOldOrNew(opt)
 }
+func (opt PhysicalVolumeName) ApplyToLVCreateOptions(opts *LVCreateOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt)
+}
+func (opt PhysicalVolumeName) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt)
+}
+func (opt PhysicalVolumeName) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt)
+}
 
 type PhysicalVolumeNames []PhysicalVolumeName
 
@@ -176,3 +197,23 @@ func (opt PhysicalVolumeNames) ApplyToArgs(args Arguments) error {
 func (opt PhysicalVolumeNames) ApplyToVGCreateOptions(opts *VGCreateOptions) {
 	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
 }
+
+func (opt PhysicalVolumeNames) ApplyToVGImportCloneOptions(opts *VGImportCloneOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (opt PhysicalVolumeNames) ApplyToVGSplitOptions(opts *VGSplitOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (opt PhysicalVolumeNames) ApplyToLVCreateOptions(opts *LVCreateOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (opt PhysicalVolumeNames) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (opt PhysicalVolumeNames) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
@@ -0,0 +1,330 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+var (
+	ErrInsufficientFreeExtents       = errors.New("insufficient free extents in volume group")
+	ErrPercentOriginCannotBePlanned  = errors.New("extents relative to the origin cannot be planned")
+	ErrSizeOrExtentsRequiredForPlan  = errors.New("size or extents must be specified to plan a logical volume")
+	ErrExtentSizeRequiredForPlanning = errors.New("extent size of the volume group is required for planning")
+)
+
+const (
+	// DefaultThinPoolChunkSize is the chunk size in bytes lvm2 starts with when choosing the chunk size of a thin pool.
+	DefaultThinPoolChunkSize = 64 * 1024
+
+	// thinPoolOptimalMetadataSize is the metadata size up to which lvm2 keeps doubling the chunk size of a thin pool.
+	thinPoolOptimalMetadataSize = 128 * 1024 * 1024
+	thinPoolMinMetadataSize     = 2 * 1024 * 1024
+	thinPoolMaxMetadataSize     = 16 * 1024 * 1024 * 1024
+	thinPoolMaxChunkSize        = 1024 * 1024 * 1024
+	// thinPoolMetadataPerChunk is the metadata size in bytes lvm2 estimates for each chunk of a thin pool.
+	thinPoolMetadataPerChunk = 64
+
+	// raidMetadataExtents is the size of the metadata subvolume of each RAID image.
+	// lvm2 only needs more than one extent if the extent size is smaller than the RAID bitmap.
+	raidMetadataExtents = 1
+	// mirrorLogExtents is the size of the disk log of a mirror.
+	mirrorLogExtents = 1
+)
+
+// Planner calculates the extents that logical volumes consume in a volume group before they are created,
+// so that it can be verified that they fit before calling LVCreate.
+// The layout rules follow the defaults of lvm2, e.g. the number of RAID images and the size of thin pool metadata.
+type Planner struct {
+	VolumeGroupName
+
+	// ExtentSize is the size of an extent in bytes.
+	ExtentSize  uint64
+	ExtentCount uint64
+	FreeCount   uint64
+
+	// PoolMetadataSpare is the number of extents of the spare pool metadata logical volume in the volume group.
+	// lvm2 grows the spare to the size of the largest pool metadata when a thin pool is created.
+	PoolMetadataSpare uint64
+}
+
+// PlannedLogicalVolume is the extent consumption of a logical volume in a Plan.
+type PlannedLogicalVolume struct {
+	LogicalVolumeName
+	Type
+
+	// Extents is the number of logical extents of the logical volume, rounded up to full stripes.
+	// Thin volumes are planned with 0 extents, as they only consume space in their thin pool.
+	Extents uint64
+	// DataExtents are the extents of all data images, including mirrors and RAID parity.
+	DataExtents uint64
+	// MetadataExtents are the extents of RAID metadata subvolumes, the mirror log or the thin pool metadata.
+	MetadataExtents uint64
+	// PoolMetadataSpareExtents are the extents by which the spare pool metadata logical volume grows.
+	PoolMetadataSpareExtents uint64
+}
+
+// TotalExtents is the number of extents the logical volume consumes in the volume group.
+func (lv *PlannedLogicalVolume) TotalExtents() uint64 {
+	return lv.DataExtents + lv.MetadataExtents + lv.PoolMetadataSpareExtents
+}
+
+// Plan is the extent consumption of logical volumes in a volume group.
+type Plan struct {
+	VolumeGroupName
+	LogicalVolumes []*PlannedLogicalVolume
+
+	// FreeCount is the number of free extents in the volume group before the logical volumes are created.
+	FreeCount uint64
+	// TotalExtents is the number of extents all logical volumes consume.
+	TotalExtents uint64
+}
+
+// Fits returns true if all logical volumes of the plan fit into the free extents of the volume group.
+func (p *Plan) Fits() bool {
+	return p.TotalExtents <= p.FreeCount
+}
+
+// Verify returns ErrInsufficientFreeExtents if the plan does not fit into the volume group.
+func (p *Plan) Verify() error {
+	if !p.Fits() {
+		return fmt.Errorf("%w: %s requires %d extents, but only %d are free",
+			ErrInsufficientFreeExtents, p.VolumeGroupName, p.TotalExtents, p.FreeCount)
+	}
+	return nil
+}
+
+// NewPlanner creates a Planner for the given volume group.
+// The spare pool metadata is not part of the volume group report, use the client to include it.
+func NewPlanner(vg *VolumeGroup) (*Planner, error) {
+	extentSize, err := vg.ExtentSize.ToUnit(UnitBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine extent size of %s: %w", vg.Name, err)
+	}
+	if extentSize.Val <= 0 {
+		return nil, fmt.Errorf("%w: %s", ErrExtentSizeRequiredForPlanning, vg.Name)
+	}
+	return &Planner{
+		VolumeGroupName: vg.Name,
+		ExtentSize:      uint64(extentSize.Val),
+		ExtentCount:     uint64(max(vg.ExtentCount, 0)),
+		FreeCount:       uint64(max(vg.FreeCount, 0)),
+	}, nil
+}
+
+func (c *client) Planner(ctx context.Context, vg VolumeGroupName) (*Planner, error) {
+	group, err := c.VG(ctx, vg)
+	if err != nil {
+		return nil, err
+	}
+	planner, err := NewPlanner(group)
+	if err != nil {
+		return nil, err
+	}
+
+	lvs, err := c.LVs(ctx, vg, ShowInternal(true), UnitBytes)
+	if err != nil {
+		return nil, err
+	}
+	for _, lv := range lvs {
+		if name := string(internalLogicalVolumeName(string(lv.Name))); strings.HasPrefix(name, "lvol") && strings.HasSuffix(name, "_pmspare") {
+			planner.PoolMetadataSpare += uint64(math.Ceil(lv.Size.Val / float64(planner.ExtentSize)))
+		}
+	}
+
+	return planner, nil
+}
+
+// Plan calculates the extents consumed by the given logical volumes, which are given as they would be passed to LVCreate.
+// The logical volumes are planned in order, so that extents relative to the free space
+// only consider the space left by the logical volumes before.
+// Like lvm2, percentages of RAID and mirrored logical volumes are split across their images and metadata.
+// Whether the logical volumes fit is answered by Plan.Fits.
+func (p *Planner) Plan(lvs ...LVCreateOptionList) (*Plan, error) {
+	plan := &Plan{VolumeGroupName: p.VolumeGroupName, FreeCount: p.FreeCount}
+	spare := p.PoolMetadataSpare
+	for _, list := range lvs {
+		opts := LVCreateOptions{}
+		list.ApplyToLVCreateOptions(&opts)
+
+		var free uint64
+		if plan.TotalExtents < p.FreeCount {
+			free = p.FreeCount - plan.TotalExtents
+		}
+
+		lv, err := p.planLogicalVolume(opts, free, &spare)
+		if err != nil {
+			return nil, fmt.Errorf("failed to plan logical volume %s: %w", opts.LogicalVolumeName, err)
+		}
+		plan.LogicalVolumes = append(plan.LogicalVolumes, lv)
+		plan.TotalExtents += lv.TotalExtents()
+	}
+	return plan, nil
+}
+
+func (p *Planner) planLogicalVolume(opts LVCreateOptions, free uint64, spare *uint64) (*PlannedLogicalVolume, error) {
+	lv := &PlannedLogicalVolume{LogicalVolumeName: opts.LogicalVolumeName, Type: opts.Type}
+
+	if opts.ThinPool != nil && opts.Type != TypeThinPool {
+		lv.Type = TypeThin
+		return lv, nil
+	}
+
+	extents, relative, err := p.extents(opts, free)
+	if err != nil {
+		return nil, err
+	}
+
+	stripes, mirrors := uint64(max(opts.Stripes, 0)), uint64(max(opts.Mirrors, 0))
+	if lv.Type == "" {
+		switch {
+		case mirrors > 0:
+			lv.Type = TypeRAID1
+		case stripes > 1:
+			lv.Type = TypeStriped
+		default:
+			lv.Type = TypeLinear
+		}
+	}
+
+	switch lv.Type {
+	case TypeRAID1:
+		images := max(mirrors, 1) + 1
+		lv.MetadataExtents = images * raidMetadataExtents
+		if relative {
+			extents = splitAcrossImages(extents, images, lv.MetadataExtents)
+		}
+		lv.Extents = extents
+		lv.DataExtents = extents * images
+	case TypeMirrored:
+		images := max(mirrors, 1) + 1
+		lv.MetadataExtents = mirrorLogExtents
+		if relative {
+			extents = splitAcrossImages(extents, images, lv.MetadataExtents)
+		}
+		lv.Extents = extents
+		lv.DataExtents = extents * images
+	case TypeRAID0:
+		lv.Extents = roundUpToMultiple(extents, max(stripes, 2))
+		lv.DataExtents = lv.Extents
+	case TypeRAID4, TypeRAID5, TypeRAID6:
+		parity, defaultStripes := uint64(1), uint64(2)
+		if lv.Type == TypeRAID6 {
+			parity, defaultStripes = 2, 3
+		}
+		if stripes == 0 {
+			stripes = defaultStripes
+		}
+		images := stripes + parity
+		lv.MetadataExtents = images * raidMetadataExtents
+		if relative {
+			extents = splitAcrossImages(extents, images, lv.MetadataExtents) * stripes
+		}
+		lv.Extents = roundUpToMultiple(extents, stripes)
+		lv.DataExtents = lv.Extents / stripes * images
+	case TypeRAID10:
+		stripes, copies := max(stripes, 2), max(mirrors, 1)+1
+		lv.MetadataExtents = stripes * copies * raidMetadataExtents
+		if relative {
+			extents = splitAcrossImages(extents, stripes*copies, lv.MetadataExtents) * stripes
+		}
+		lv.Extents = roundUpToMultiple(extents, stripes)
+		lv.DataExtents = lv.Extents * copies
+	case TypeThinPool:
+		lv.Extents = roundUpToMultiple(extents, max(stripes, 1))
+		lv.DataExtents = lv.Extents
+		if lv.MetadataExtents, err = p.thinPoolMetadataExtents(lv.Extents, opts.ChunkSize); err != nil {
+			return nil, err
+		}
+		if lv.MetadataExtents > *spare {
+			lv.PoolMetadataSpareExtents = lv.MetadataExtents - *spare
+			*spare = lv.MetadataExtents
+		}
+	default:
+		lv.Extents = roundUpToMultiple(extents, max(stripes, 1))
+		lv.DataExtents = lv.Extents
+	}
+
+	return lv, nil
+}
+
+// extents returns the logical extents requested by size or extents, resolving percentages.
+// Extents resolved from a percentage are relative, as lvm2 treats them as the extents consumed by all images.
+func (p *Planner) extents(opts LVCreateOptions, free uint64) (uint64, bool, error) {
+	if opts.Extents.Val > 0 {
+		switch opts.Extents.ExtentPercent {
+		case "":
+			return opts.Extents.Val, false, nil
+		case ExtentPercentVG, ExtentPercentPVS:
+			return opts.Extents.Val * p.ExtentCount / 100, true, nil
+		case ExtentPercentFree:
+			return opts.Extents.Val * free / 100, true, nil
+		default:
+			return 0, false, ErrPercentOriginCannotBePlanned
+		}
+	}
+	if opts.Size.Val > 0 {
+		bytes, err := opts.Size.ToUnit(UnitBytes)
+		if err != nil {
+			return 0, false, err
+		}
+		return uint64(math.Ceil(bytes.Val / float64(p.ExtentSize))), false, nil
+	}
+	return 0, false, ErrSizeOrExtentsRequiredForPlan
+}
+
+// splitAcrossImages returns the extents of each image of a redundant logical volume
+// whose images and metadata together consume the given extents.
+func splitAcrossImages(extents, images, metadataExtents uint64) uint64 {
+	if extents <= metadataExtents {
+		return 0
+	}
+	return (extents - metadataExtents) / images
+}
+
+// thinPoolMetadataExtents estimates the metadata size of a thin pool like lvm2,
+// which doubles the default chunk size until the metadata fits into the optimal metadata size.
+func (p *Planner) thinPoolMetadataExtents(dataExtents uint64, chunkSize ChunkSize) (uint64, error) {
+	data := dataExtents * p.ExtentSize
+	chunk := uint64(DefaultThinPoolChunkSize)
+	if chunkSize.Val > 0 {
+		bytes, err := Size(chunkSize).ToUnit(UnitBytes)
+		if err != nil {
+			return 0, err
+		}
+		if chunk = uint64(bytes.Val); chunk == 0 {
+			return 0, fmt.Errorf("chunk size %s is too small", Size(chunkSize))
+		}
+	} else {
+		for data/chunk*thinPoolMetadataPerChunk > thinPoolOptimalMetadataSize && chunk < thinPoolMaxChunkSize {
+			chunk *= 2
+		}
+	}
+	metadata := min(max(data/chunk*thinPoolMetadataPerChunk, thinPoolMinMetadataSize), thinPoolMaxMetadataSize)
+	return roundUpToMultiple(metadata, p.ExtentSize) / p.ExtentSize, nil
+}
+
+func roundUpToMultiple(val, multiple uint64) uint64 {
+	if multiple <= 1 {
+		return val
+	}
+	return (val + multiple - 1) / multiple * multiple
+}
@@ -0,0 +1,149 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestPlanner(t *testing.T) {
+	t.Parallel()
+	planner, err := NewPlanner(&VolumeGroup{Name: "vg", ExtentSize: MustParseSize("4M"), ExtentCount: 250, FreeCount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name                                  string
+		lv                                    LVCreateOptionList
+		typ                                   Type
+		extents, data, metadata, spare, total uint64
+		err                                   error
+	}{
+		{name: "linear", lv: LVCreateOptionList{MustParseSize("10M")}, typ: TypeLinear, extents: 3, data: 3, total: 3},
+		{name: "striped", lv: LVCreateOptionList{MustParseSize("10M"), Stripes(2)}, typ: TypeStriped, extents: 4, data: 4, total: 4},
+		{name: "mirrors", lv: LVCreateOptionList{MustParseSize("8M"), Mirrors(1)}, typ: TypeRAID1, extents: 2, data: 4, metadata: 2, total: 6},
+		{name: "raid1", lv: LVCreateOptionList{MustParseSize("8M"), TypeRAID1, Mirrors(2)}, typ: TypeRAID1, extents: 2, data: 6, metadata: 3, total: 9},
+		{name: "mirrored", lv: LVCreateOptionList{MustParseSize("4M"), TypeMirrored}, typ: TypeMirrored, extents: 1, data: 2, metadata: 1, total: 3},
+		{name: "raid0", lv: LVCreateOptionList{MustParseExtents("3"), TypeRAID0, Stripes(2)}, typ: TypeRAID0, extents: 4, data: 4, total: 4},
+		{name: "raid5", lv: LVCreateOptionList{MustParseSize("10M"), TypeRAID5}, typ: TypeRAID5, extents: 4, data: 6, metadata: 3, total: 9},
+		{name: "raid6", lv: LVCreateOptionList{MustParseSize("4M"), TypeRAID6}, typ: TypeRAID6, extents: 3, data: 5, metadata: 5, total: 10},
+		{name: "raid10", lv: LVCreateOptionList{MustParseSize("8M"), TypeRAID10}, typ: TypeRAID10, extents: 2, data: 4, metadata: 4, total: 8},
+		{name: "thin pool", lv: LVCreateOptionList{MustParseSize("100M"), TypeThinPool}, typ: TypeThinPool, extents: 25, data: 25, metadata: 1, spare: 1, total: 27},
+		{name: "thin pool with small chunks", lv: LVCreateOptionList{MustParseSize("100M"), TypeThinPool, ChunkSize(MustParseSize("1K"))}, typ: TypeThinPool, extents: 25, data: 25, metadata: 2, spare: 2, total: 29},
+		{name: "thin volume", lv: LVCreateOptionList{MustNewThinPool("vg", "pool"), MustParseSize("1T").Virtual()}, typ: TypeThin},
+		{name: "percent of volume group", lv: LVCreateOptionList{MustParseExtents("10%VG")}, typ: TypeLinear, extents: 25, data: 25, total: 25},
+		{name: "percent of free", lv: LVCreateOptionList{MustParseExtents("50%FREE")}, typ: TypeLinear, extents: 50, data: 50, total: 50},
+		{name: "raid1 percent of free", lv: LVCreateOptionList{MustParseExtents("100%FREE"), TypeRAID1}, typ: TypeRAID1, extents: 49, data: 98, metadata: 2, total: 100},
+		{name: "mirrors percent of free", lv: LVCreateOptionList{MustParseExtents("50%FREE"), Mirrors(2)}, typ: TypeRAID1, extents: 15, data: 45, metadata: 3, total: 48},
+		{name: "mirrored percent of free", lv: LVCreateOptionList{MustParseExtents("100%FREE"), TypeMirrored}, typ: TypeMirrored, extents: 49, data: 98, metadata: 1, total: 99},
+		{name: "raid5 percent of free", lv: LVCreateOptionList{MustParseExtents("100%FREE"), TypeRAID5}, typ: TypeRAID5, extents: 64, data: 96, metadata: 3, total: 99},
+		{name: "raid6 percent of volume group", lv: LVCreateOptionList{MustParseExtents("20%VG"), TypeRAID6}, typ: TypeRAID6, extents: 27, data: 45, metadata: 5, total: 50},
+		{name: "raid10 percent of free", lv: LVCreateOptionList{MustParseExtents("100%FREE"), TypeRAID10}, typ: TypeRAID10, extents: 48, data: 96, metadata: 4, total: 100},
+		{name: "percent of origin", lv: LVCreateOptionList{MustParseExtents("50%ORIGIN")}, err: ErrPercentOriginCannotBePlanned},
+		{name: "no size", lv: LVCreateOptionList{LogicalVolumeName("lv")}, err: ErrSizeOrExtentsRequiredForPlan},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			plan, err := planner.Plan(tc.lv)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			lv := plan.LogicalVolumes[0]
+			if lv.Type != tc.typ || lv.Extents != tc.extents || lv.DataExtents != tc.data ||
+				lv.MetadataExtents != tc.metadata || lv.PoolMetadataSpareExtents != tc.spare || plan.TotalExtents != tc.total {
+				t.Fatalf("unexpected plan %+v", lv)
+			}
+		})
+	}
+}
+
+func TestPlannerFits(t *testing.T) {
+	t.Parallel()
+	planner := &Planner{VolumeGroupName: "vg", ExtentSize: 4 << 20, ExtentCount: 250, FreeCount: 100, PoolMetadataSpare: 1}
+
+	// percentages of free space consider the logical volumes planned before
+	plan, err := planner.Plan(
+		LVCreateOptionList{MustParseExtents("10")},
+		LVCreateOptionList{MustParseExtents("50%FREE")},
+		LVCreateOptionList{MustParseSize("100M"), TypeThinPool},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.LogicalVolumes[1].Extents != 45 {
+		t.Fatalf("expected 45 extents for half of the free space, got %d", plan.LogicalVolumes[1].Extents)
+	}
+	if plan.LogicalVolumes[2].PoolMetadataSpareExtents != 0 {
+		t.Fatalf("expected the existing spare to be used, got %d extents", plan.LogicalVolumes[2].PoolMetadataSpareExtents)
+	}
+	if plan.TotalExtents != 81 || !plan.Fits() || plan.Verify() != nil {
+		t.Fatalf("expected plan to fit with 81 extents, got %d", plan.TotalExtents)
+	}
+
+	plan, err = planner.Plan(LVCreateOptionList{MustParseSize("200M"), Mirrors(1)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.Fits() || !errors.Is(plan.Verify(), ErrInsufficientFreeExtents) {
+		t.Fatalf("expected plan with %d extents not to fit", plan.TotalExtents)
+	}
+}
+
+func TestPlannerMatchesLVCreate(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{LoopDevices: []Size{MustParseSize("64M")}}.SetupDevicesAndVolumeGroup(t)
+	vg := infra.volumeGroup.Name
+
+	for _, lv := range []LVCreateOptionList{
+		{LogicalVolumeName("linear"), MustParseSize("10M")},
+		{LogicalVolumeName("pool"), MustParseSize("8M"), TypeThinPool},
+	} {
+		planner, err := clnt.Planner(ctx, vg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan, err := planner.Plan(lv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !plan.Fits() {
+			t.Fatalf("expected plan to fit, got %d of %d extents", plan.TotalExtents, plan.FreeCount)
+		}
+		if err := clnt.LVCreate(ctx, vg, lv); err != nil {
+			t.Fatal(err)
+		}
+		after, err := clnt.VG(ctx, vg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if consumed := planner.FreeCount - uint64(after.FreeCount); consumed != plan.TotalExtents {
+			t.Fatalf("expected %d extents to be consumed, got %d", plan.TotalExtents, consumed)
+		}
+	}
+}
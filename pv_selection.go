@@ -0,0 +1,177 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+)
+
+// DeviceClass is the class of media backing a physical volume, as reported by the rotational flag in sysfs.
+type DeviceClass string
+
+const (
+	// DeviceClassSSD selects physical volumes on non-rotational devices, e.g. SSDs and NVMe devices.
+	DeviceClassSSD DeviceClass = "ssd"
+	// DeviceClassHDD selects physical volumes on rotational devices.
+	DeviceClassHDD DeviceClass = "hdd"
+)
+
+type (
+	// MinimumFree is the free space a physical volume needs to be selected.
+	MinimumFree Size
+
+	SelectPhysicalVolumesOptions struct {
+		VolumeGroupName
+		// Tags are required to be present on all selected physical volumes.
+		Tags
+		MinimumFree
+		DeviceClass
+
+		// SysfsRoot and DevRoot are used to discover the device class, see ReadBlockDevices.
+		SysfsRoot
+		DevRoot
+	}
+	SelectPhysicalVolumesOption interface {
+		ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions)
+	}
+)
+
+func (opt VolumeGroupName) ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions) {
+	opts.VolumeGroupName = opt
+}
+
+func (opt Tags) ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions) {
+	opts.Tags = opt
+}
+
+func (opt MinimumFree) ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions) {
+	opts.MinimumFree = opt
+}
+
+func (opt DeviceClass) ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions) {
+	opts.DeviceClass = opt
+}
+
+func (opt SysfsRoot) ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions) {
+	opts.SysfsRoot = opt
+}
+
+func (opt DevRoot) ApplyToSelectPhysicalVolumesOptions(opts *SelectPhysicalVolumesOptions) {
+	opts.DevRoot = opt
+}
+
+func (opts *SelectPhysicalVolumesOptions) ApplyToSelectPhysicalVolumesOptions(new *SelectPhysicalVolumesOptions) {
+	*new = *opts
+}
+
+func (c *client) SelectPhysicalVolumes(ctx context.Context, opts ...SelectPhysicalVolumesOption) (PhysicalVolumeNames, error) {
+	options := SelectPhysicalVolumesOptions{SysfsRoot: DefaultSysfsRoot, DevRoot: DefaultDevRoot}
+	for _, opt := range opts {
+		opt.ApplyToSelectPhysicalVolumesOptions(&options)
+	}
+
+	pvs, err := c.PVs(ctx, UnitBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []*BlockDevice
+	if options.DeviceClass != "" {
+		if devices, err = ReadBlockDevices(string(options.SysfsRoot), string(options.DevRoot)); err != nil {
+			return nil, fmt.Errorf("failed to discover device class of physical volumes: %w", err)
+		}
+	}
+
+	selected, err := SelectPhysicalVolumesFrom(pvs, devices, options)
+	if err != nil {
+		return nil, err
+	}
+	names := make(PhysicalVolumeNames, 0, len(selected))
+	for _, pv := range selected {
+		names = append(names, pv.Name)
+	}
+	return names, nil
+}
+
+// SelectPhysicalVolumesFrom returns the physical volumes that match the options, ordered by free space with the most free first.
+// The device class is determined from the devices with the same major and minor number as the physical volume,
+// physical volumes without a matching device are not selected if a DeviceClass is given.
+func SelectPhysicalVolumesFrom(pvs []*PhysicalVolume, devices []*BlockDevice, opts SelectPhysicalVolumesOptions) ([]*PhysicalVolume, error) {
+	switch opts.DeviceClass {
+	case "", DeviceClassSSD, DeviceClassHDD:
+	default:
+		return nil, fmt.Errorf("unknown device class %q", opts.DeviceClass)
+	}
+
+	minimum := 0.0
+	if opts.MinimumFree.Val > 0 {
+		bytes, err := Size(opts.MinimumFree).ToUnit(UnitBytes)
+		if err != nil {
+			return nil, err
+		}
+		minimum = bytes.Val
+	}
+
+	type selection struct {
+		pv   *PhysicalVolume
+		free float64
+	}
+	var selections []selection
+	for _, pv := range pvs {
+		if opts.VolumeGroupName != "" && pv.VGName != opts.VolumeGroupName {
+			continue
+		}
+		if pv.Attr.DuplicateAllocatableUsed != Allocatable || pv.Attr.Missing == MissingTrue {
+			continue
+		}
+		if slices.ContainsFunc(opts.Tags, func(tag string) bool { return !slices.Contains(pv.Tags, tag) }) {
+			continue
+		}
+		if pv.Free.Val <= 0 {
+			continue
+		}
+		free, err := pv.Free.ToUnit(UnitBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine free space of %s: %w", pv.Name, err)
+		}
+		if free.Val < minimum {
+			continue
+		}
+		if opts.DeviceClass != "" {
+			idx := slices.IndexFunc(devices, func(dev *BlockDevice) bool {
+				return dev.Major == pv.Major && dev.Minor == pv.Minor
+			})
+			if idx < 0 || devices[idx].Rotational != (opts.DeviceClass == DeviceClassHDD) {
+				continue
+			}
+		}
+		selections = append(selections, selection{pv: pv, free: free.Val})
+	}
+
+	slices.SortStableFunc(selections, func(a, b selection) int {
+		return cmp.Compare(b.free, a.free)
+	})
+
+	selected := make([]*PhysicalVolume, 0, len(selections))
+	for _, s := range selections {
+		selected = append(selected, s.pv)
+	}
+	return selected, nil
+}
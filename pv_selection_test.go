@@ -0,0 +1,192 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestPhysicalVolumeAllocationArgs(t *testing.T) {
+	t.Parallel()
+	ranges := PERanges{
+		{PhysicalVolumeName: "/dev/sdc", Start: 0, End: 99},
+		{PhysicalVolumeName: "/dev/sdc", Start: 200, End: 299},
+	}
+	for _, tc := range []struct {
+		name    string
+		gen     ArgumentGenerator
+		args    []string
+		wantErr bool
+	}{
+		{
+			name: "lvcreate",
+			gen: LVCreateOptionList{
+				VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParseSize("1G"),
+				PhysicalVolumeName("/dev/sdb"), ranges,
+			},
+			args: []string{"vg", "--name=lv", "--size=1.00g", "--yes", "/dev/sdb", "/dev/sdc:0-99", "/dev/sdc:200-299"},
+		},
+		{
+			name: "lvextend",
+			gen: LVExtendOptionsList{
+				VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("+1G"),
+				Contiguous, PhysicalVolumesFrom("/dev/sdb", "/dev/sdd"),
+			},
+			args: []string{"vg/lv", "--size=+1.00g", "--alloc=contiguous", "--yes", "/dev/sdb", "/dev/sdd"},
+		},
+		{
+			name: "lvresize",
+			gen: LVResizeOptionsList{
+				VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("2G"),
+				PERange{PhysicalVolumeName: "/dev/sdb", Start: 10, End: 10},
+			},
+			args: []string{"vg/lv", "--size=2.00g", "--yes", "/dev/sdb:10-10"},
+		},
+		{
+			name: "invalid range",
+			gen: LVCreateOptionList{
+				VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParseSize("1G"),
+				PERange{PhysicalVolumeName: "/dev/sdb", Start: 10, End: 9},
+			},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.gen.AsArgs()
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestSelectPhysicalVolumesFrom(t *testing.T) {
+	t.Parallel()
+	pv := func(name string, minor int64, free string, tags ...string) *PhysicalVolume {
+		return &PhysicalVolume{
+			Name:   PhysicalVolumeName(name),
+			VGName: "vg",
+			Major:  8,
+			Minor:  minor,
+			Free:   MustParseSize(free),
+			Tags:   tags,
+			Attr:   PVAttributes{DuplicateAllocatableUsed: Allocatable, Exported: ExportedFalse, Missing: MissingFalse},
+		}
+	}
+	pvs := []*PhysicalVolume{
+		pv("/dev/sda", 0, "10G", "fast"),
+		pv("/dev/sdb", 16, "100G"),
+		pv("/dev/sdc", 32, "50G", "fast", "cache"),
+		pv("/dev/sdd", 48, "0"),
+	}
+	missing := pv("/dev/sde", 64, "10G", "fast")
+	missing.Attr.Missing = MissingTrue
+	other := pv("/dev/sdf", 80, "10G", "fast")
+	other.VGName = "other"
+	pvs = append(pvs, missing, other)
+
+	devices := []*BlockDevice{
+		{Name: "sda", Major: 8, Minor: 0},
+		{Name: "sdb", Major: 8, Minor: 16, Rotational: true},
+		{Name: "sdc", Major: 8, Minor: 32},
+		{Name: "sdd", Major: 8, Minor: 48},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		opts     SelectPhysicalVolumesOptions
+		expected []string
+	}{
+		{"by free space", SelectPhysicalVolumesOptions{VolumeGroupName: "vg"}, []string{"/dev/sdb", "/dev/sdc", "/dev/sda"}},
+		{"by tag", SelectPhysicalVolumesOptions{VolumeGroupName: "vg", Tags: Tags{"fast"}}, []string{"/dev/sdc", "/dev/sda"}},
+		{"by tags", SelectPhysicalVolumesOptions{Tags: Tags{"fast", "cache"}}, []string{"/dev/sdc"}},
+		{"by minimum free", SelectPhysicalVolumesOptions{VolumeGroupName: "vg", MinimumFree: MinimumFree(MustParseSize("20G"))}, []string{"/dev/sdb", "/dev/sdc"}},
+		{"ssd", SelectPhysicalVolumesOptions{VolumeGroupName: "vg", DeviceClass: DeviceClassSSD}, []string{"/dev/sdc", "/dev/sda"}},
+		{"hdd", SelectPhysicalVolumesOptions{VolumeGroupName: "vg", DeviceClass: DeviceClassHDD}, []string{"/dev/sdb"}},
+		{"all volume groups", SelectPhysicalVolumesOptions{Tags: Tags{"fast"}, DeviceClass: DeviceClassSSD}, []string{"/dev/sdc", "/dev/sda"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			selected, err := SelectPhysicalVolumesFrom(pvs, devices, tc.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var names []string
+			for _, pv := range selected {
+				names = append(names, string(pv.Name))
+			}
+			if !slices.Equal(names, tc.expected) {
+				t.Fatalf("expected %v, got %v", tc.expected, names)
+			}
+		})
+	}
+
+	if _, err := SelectPhysicalVolumesFrom(pvs, devices, SelectPhysicalVolumesOptions{DeviceClass: "tape"}); err == nil {
+		t.Fatal("expected unknown device class to fail")
+	}
+}
+
+func TestLVCreateOnPERange(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{LoopDevices: []Size{MustParseSize("16M"), MustParseSize("16M")}}.SetupDevicesAndVolumeGroup(t)
+	vg := infra.volumeGroup.Name
+	target := infra.loopDevices.PhysicalVolumeNames()[1]
+
+	selected, err := clnt.SelectPhysicalVolumes(ctx, vg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(selected) != 2 {
+		t.Fatalf("expected both physical volumes to be selected, got %v", selected)
+	}
+
+	lv := MustNewFQLogicalVolumeName(vg, "ranged")
+	if err := clnt.LVCreate(ctx, lv, MustParseExtents("1"), PERange{PhysicalVolumeName: target, Start: 1, End: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.LVExtend(ctx, lv, MustParsePrefixedExtents("+1"), target); err != nil {
+		t.Fatal(err)
+	}
+
+	segments, err := clnt.LVSegments(ctx, vg, lv.LogicalVolumeName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, seg := range segments {
+		if !slices.Equal(seg.PERanges.PhysicalVolumeNames(), PhysicalVolumeNames{target}) {
+			t.Fatalf("expected segment on %s, got %v", target, seg.PERanges)
+		}
+	}
+	if segments[0].PERanges[0].Start != 1 {
+		t.Fatalf("expected allocation to start at extent 1, got %v", segments[0].PERanges)
+	}
+}
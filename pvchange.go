@@ -18,13 +18,35 @@ package lvm2go
 
 import (
 	"context"
+	"fmt"
 )
 
+const (
+	AllowAllocation    PVAllocatable = "y"
+	DisallowAllocation PVAllocatable = "n"
+)
+
+// PVAllocatable controls if physical extents of the physical volume can be allocated.
+type PVAllocatable string
+
+func (opt PVAllocatable) ApplyToPVChangeOptions(opts *PVChangeOptions) {
+	opts.PVAllocatable = opt
+}
+
+func (opt PVAllocatable) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--allocatable=%s", string(opt)))
+	return nil
+}
+
 type (
 	PVChangeOptions struct {
 		PhysicalVolumeName
 		Tags
 		DelTags
+		PVAllocatable
 		CommonOptions
 	}
 	PVChangeOption interface {
@@ -72,6 +94,7 @@ func (opts *PVChangeOptions) ApplyToArgs(args Arguments) error {
 		opts.PhysicalVolumeName,
 		opts.Tags,
 		opts.DelTags,
+		opts.PVAllocatable,
 		opts.CommonOptions,
 	} {
 		if err := arg.ApplyToArgs(args); err != nil {
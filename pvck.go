@@ -0,0 +1,383 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PVCheckDump selects the on-disk structures that pvck dumps.
+type PVCheckDump string
+
+const (
+	// PVCheckDumpHeaders dumps the label, physical volume and metadata area headers including the
+	// current metadata text location of each metadata area.
+	PVCheckDumpHeaders PVCheckDump = "headers"
+	// PVCheckDumpMetadata dumps the current metadata text of the metadata area.
+	PVCheckDumpMetadata PVCheckDump = "metadata"
+	// PVCheckDumpMetadataAll dumps all metadata text versions found in the metadata area.
+	PVCheckDumpMetadataAll PVCheckDump = "metadata_all"
+	// PVCheckDumpMetadataSearch searches the whole metadata area for metadata text,
+	// even if the headers are damaged.
+	PVCheckDumpMetadataSearch PVCheckDump = "metadata_search"
+)
+
+// IsMetadata returns true if the dump contains metadata text.
+func (opt PVCheckDump) IsMetadata() bool {
+	return opt == PVCheckDumpMetadata || opt == PVCheckDumpMetadataAll || opt == PVCheckDumpMetadataSearch
+}
+
+func (opt PVCheckDump) ApplyToPVCheckOptions(opts *PVCheckOptions) {
+	opts.PVCheckDump = opt
+}
+
+func (opt PVCheckDump) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--dump=%s", string(opt)))
+	return nil
+}
+
+// MetadataAreaNumber selects the first or second metadata area of a physical volume for pvck.
+type MetadataAreaNumber int
+
+func (opt MetadataAreaNumber) ApplyToPVCheckOptions(opts *PVCheckOptions) {
+	opts.MetadataAreaNumber = opt
+}
+
+func (opt MetadataAreaNumber) ApplyToArgs(args Arguments) error {
+	if opt == 0 {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--settings=mda_num=%d", opt))
+	return nil
+}
+
+// MetadataFile is the file pvck writes dumped metadata text to.
+type MetadataFile string
+
+func (opt MetadataFile) ApplyToPVCheckOptions(opts *PVCheckOptions) {
+	opts.MetadataFile = opt
+}
+
+func (opt MetadataFile) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--file=%s", string(opt)))
+	return nil
+}
+
+type (
+	PVCheckOptions struct {
+		PhysicalVolumeName
+		PVCheckDump
+		MetadataAreaNumber
+		// MetadataFile writes the dumped metadata text to the given file instead of stdout.
+		// If lvm2 runs containerized, the path is resolved on the host.
+		MetadataFile
+
+		CommonOptions
+	}
+	PVCheckOption interface {
+		ApplyToPVCheckOptions(opts *PVCheckOptions)
+	}
+	PVCheckOptionsList []PVCheckOption
+)
+
+var (
+	_ ArgumentGenerator = PVCheckOptionsList{}
+	_ Argument          = (*PVCheckOptions)(nil)
+)
+
+// MetadataArea is a metadata area of a physical volume as reported by pvck --dump headers.
+type MetadataArea struct {
+	// Number is the number of the metadata area, 1 for the area at the start and 2 for the area at the end of the device.
+	Number int
+	// Offset is the location of the metadata area header on the device in bytes.
+	Offset int64
+	// Start and Size describe the circular buffer holding the metadata text in bytes.
+	Start int64
+	Size  int64
+	// Checksum is the checksum stored in the metadata area header.
+	Checksum string
+
+	// TextOffset is the location of the current metadata text on the device in bytes.
+	TextOffset      int64
+	TextChecksum    string
+	VolumeGroupName VolumeGroupName
+	SeqNo           int64
+
+	// ChecksumMismatch is true if the checksum of the header or the metadata text does not match its content.
+	ChecksumMismatch bool
+	// Problems are the CHECK messages pvck reported for the metadata area.
+	Problems []string
+}
+
+// IsValid returns true if pvck did not report any problems for the metadata area.
+func (area *MetadataArea) IsValid() bool {
+	return len(area.Problems) == 0
+}
+
+// MetadataText is a version of the metadata text found in a metadata area.
+type MetadataText struct {
+	Offset          int64
+	Length          int64
+	Checksum        string
+	VolumeGroupName VolumeGroupName
+	SeqNo           int64
+}
+
+// PVCheckResult is the typed output of pvck.
+type PVCheckResult struct {
+	PhysicalVolumeName
+
+	// Headers are the raw header fields, e.g. "pv_header.device_size" or "mda_header_1.raw_locn[0].offset".
+	Headers       map[string]string
+	MetadataAreas []*MetadataArea
+	// Metadata lists the metadata text versions found by PVCheckDumpMetadataAll and PVCheckDumpMetadataSearch,
+	// or the current metadata text location reported by PVCheckDumpHeaders.
+	Metadata []MetadataText
+	// RawMetadata is the metadata text recovered by a metadata dump to stdout.
+	// It only contains lines of the metadata text format, other output of pvck is not included.
+	RawMetadata string
+	// Problems are all CHECK messages reported by pvck.
+	Problems []string
+}
+
+// IsValid returns true if pvck did not report any problems.
+func (res *PVCheckResult) IsValid() bool {
+	return len(res.Problems) == 0
+}
+
+// MetadataArea returns the metadata area with the given number or nil if it was not reported.
+func (res *PVCheckResult) MetadataArea(number int) *MetadataArea {
+	for _, area := range res.MetadataAreas {
+		if area.Number == number {
+			return area
+		}
+	}
+	return nil
+}
+
+// LatestSeqNo returns the highest metadata sequence number reported for the volume group.
+func (res *PVCheckResult) LatestSeqNo() int64 {
+	var seqNo int64
+	for _, text := range res.Metadata {
+		seqNo = max(seqNo, text.SeqNo)
+	}
+	for _, area := range res.MetadataAreas {
+		seqNo = max(seqNo, area.SeqNo)
+	}
+	return seqNo
+}
+
+func (c *client) PVCheck(ctx context.Context, opts ...PVCheckOption) (*PVCheckResult, error) {
+	options := PVCheckOptions{}
+	for _, opt := range opts {
+		opt.ApplyToPVCheckOptions(&options)
+	}
+	if options.PVCheckDump == "" {
+		options.PVCheckDump = PVCheckDumpHeaders
+	}
+
+	args, err := PVCheckOptionsList{&options}.AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	var res *PVCheckResult
+	err = c.RunLVMRaw(ctx, func(out io.Reader) error {
+		var err error
+		res, err = ParsePVCheckOutput(out)
+		return err
+	}, append([]string{"pvck"}, args.GetRaw()...)...)
+	if res == nil {
+		return nil, err
+	}
+	res.PhysicalVolumeName = options.PhysicalVolumeName
+
+	// pvck fails if it finds problems, in which case the CHECK messages can also end up in stderr.
+	// Other error messages are not part of the dump and are only kept in the returned error.
+	if stdErr, ok := AsLVMStdErr(err); ok {
+		for _, line := range stdErr.Lines(true) {
+			if isPVCheckStdErrLine(string(line)) {
+				res.addLine(string(line))
+			}
+		}
+	}
+
+	return res, err
+}
+
+func (list PVCheckOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := PVCheckOptions{}
+	for _, opt := range list {
+		opt.ApplyToPVCheckOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *PVCheckOptions) ApplyToPVCheckOptions(new *PVCheckOptions) {
+	*new = *opts
+}
+
+func (opts *PVCheckOptions) ApplyToArgs(args Arguments) error {
+	if opts.PhysicalVolumeName == "" {
+		return fmt.Errorf("PhysicalVolumeName is required for checking a physical volume")
+	}
+	if opts.MetadataFile != "" && !opts.PVCheckDump.IsMetadata() {
+		return fmt.Errorf("MetadataFile can only be used with a metadata dump")
+	}
+
+	for _, arg := range []Argument{
+		opts.PVCheckDump,
+		opts.MetadataAreaNumber,
+		opts.MetadataFile,
+		opts.CommonOptions,
+		opts.PhysicalVolumeName,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var (
+	pvCheckMetadataAreaPattern = regexp.MustCompile(`^mda_header_(\d+) at (\d+)`)
+	pvCheckHeaderPattern       = regexp.MustCompile(`^(?:label_header|pv_header|pv_header_extension) at \d+`)
+	pvCheckMetadataTextPattern = regexp.MustCompile(
+		`^metadata(?: text)? at (\d+)(?: length (\d+))? crc (?:0x)?([0-9a-fA-F]+)(?: #)? vg(?:name)? (\S+) seqno (\d+)`,
+	)
+	pvCheckHeaderFieldPattern = regexp.MustCompile(`^((?:label_header|pv_header|pv_header_extension|mda_header_\d+)\.\S+) (.*)$`)
+	pvCheckMetadataAreaRef    = regexp.MustCompile(`mda_header_(\d+)`)
+	// pvCheckMetadataTextLinePattern matches the lines of the metadata text format: sections, assignments,
+	// closing brackets, comments and the entries of lists that span multiple lines.
+	pvCheckMetadataTextLinePattern = regexp.MustCompile(`^\s*(?:[\w.+-]+\s*(?:=.*|\{)|[}\]]|#.*|"[^"]*",\s*\d+,?)$`)
+)
+
+// ParsePVCheckOutput parses the output of pvck --dump.
+func ParsePVCheckOutput(out io.Reader) (*PVCheckResult, error) {
+	res := &PVCheckResult{Headers: make(map[string]string)}
+	var metadata strings.Builder
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		raw := scanner.Text()
+		if res.addLine(raw) {
+			continue
+		}
+		// blank lines separate the sections of the metadata text and are only kept within it.
+		if pvCheckMetadataTextLinePattern.MatchString(raw) || (metadata.Len() > 0 && strings.TrimSpace(raw) == "") {
+			metadata.WriteString(raw)
+			metadata.WriteString("\n")
+		}
+	}
+	res.RawMetadata = metadata.String()
+	return res, scanner.Err()
+}
+
+// isPVCheckStdErrLine returns true if a line of stderr is a CHECK message or describes a metadata area or text.
+func isPVCheckStdErrLine(raw string) bool {
+	line := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), LVMWarningPrefix))
+	return strings.HasPrefix(line, "CHECK: ") ||
+		pvCheckMetadataAreaPattern.MatchString(line) ||
+		pvCheckMetadataTextPattern.MatchString(line)
+}
+
+// addLine parses a line of a header dump or a CHECK message and returns false if the line is neither.
+func (res *PVCheckResult) addLine(raw string) bool {
+	line := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), LVMWarningPrefix))
+	if line == "" {
+		return false
+	}
+
+	if problem, ok := strings.CutPrefix(line, "CHECK: "); ok {
+		res.Problems = append(res.Problems, problem)
+		var area *MetadataArea
+		if matches := pvCheckMetadataAreaRef.FindStringSubmatch(problem); matches != nil {
+			number, _ := strconv.Atoi(matches[1])
+			area = res.MetadataArea(number)
+		} else if strings.HasPrefix(problem, "metadata text") && len(res.MetadataAreas) > 0 {
+			area = res.MetadataAreas[len(res.MetadataAreas)-1]
+		}
+		if area != nil {
+			area.Problems = append(area.Problems, problem)
+			lower := strings.ToLower(problem)
+			area.ChecksumMismatch = area.ChecksumMismatch || strings.Contains(lower, "checksum") || strings.Contains(lower, "crc")
+		}
+		return true
+	}
+
+	if matches := pvCheckMetadataAreaPattern.FindStringSubmatch(line); matches != nil {
+		number, _ := strconv.Atoi(matches[1])
+		offset, _ := strconv.ParseInt(matches[2], 10, 64)
+		res.MetadataAreas = append(res.MetadataAreas, &MetadataArea{Number: number, Offset: offset})
+		return true
+	}
+
+	if matches := pvCheckMetadataTextPattern.FindStringSubmatch(line); matches != nil {
+		text := MetadataText{Checksum: matches[3], VolumeGroupName: VolumeGroupName(matches[4])}
+		text.Offset, _ = strconv.ParseInt(matches[1], 10, 64)
+		text.Length, _ = strconv.ParseInt(matches[2], 10, 64)
+		text.SeqNo, _ = strconv.ParseInt(matches[5], 10, 64)
+		res.Metadata = append(res.Metadata, text)
+		if strings.HasPrefix(line, "metadata text") && len(res.MetadataAreas) > 0 {
+			area := res.MetadataAreas[len(res.MetadataAreas)-1]
+			area.TextOffset, area.TextChecksum = text.Offset, text.Checksum
+			area.VolumeGroupName, area.SeqNo = text.VolumeGroupName, text.SeqNo
+		}
+		return true
+	}
+
+	if pvCheckHeaderPattern.MatchString(line) {
+		return true
+	}
+
+	if matches := pvCheckHeaderFieldPattern.FindStringSubmatch(line); matches != nil {
+		key, value := matches[1], strings.TrimSpace(matches[2])
+		res.Headers[key] = value
+		if len(res.MetadataAreas) == 0 {
+			return true
+		}
+		area := res.MetadataAreas[len(res.MetadataAreas)-1]
+		prefix := fmt.Sprintf("mda_header_%d.", area.Number)
+		switch strings.TrimPrefix(key, prefix) {
+		case "checksum":
+			area.Checksum = value
+		case "start":
+			area.Start, _ = strconv.ParseInt(value, 10, 64)
+		case "size":
+			area.Size, _ = strconv.ParseInt(value, 10, 64)
+		}
+		return true
+	}
+
+	return false
+}
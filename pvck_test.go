@@ -0,0 +1,280 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+const testPVCheckHeaders = `  label_header at 512
+  label_header.id LABELONE
+  label_header.sector 1
+  label_header.crc 0x2f5b3d41
+  label_header.offset 32
+  label_header.type LVM2 001
+  pv_header at 544
+  pv_header.pv_uuid 7nsClKdh3jHyB0Ez6kUx2xsMUGeq8CkL
+  pv_header.device_size 10485760
+  pv_header.disk_locn[0].offset 1048576
+  pv_header.disk_locn[0].size 9437184
+  mda_header_1 at 4096 # metadata area
+  mda_header_1.checksum 0x1d7b9ea8
+  mda_header_1.magic 0x204c564d32207835305d4c4d5b35413e
+  mda_header_1.version 1
+  mda_header_1.start 4096
+  mda_header_1.size 1044480
+  mda_header_1.raw_locn[0].offset 6656
+  mda_header_1.raw_locn[0].size 1260
+  mda_header_1.raw_locn[0].checksum 0xd5dd8a28
+  metadata text at 10752 crc 0xd5dd8a28 # vgname vg1 seqno 3
+  mda_header_2 at 10481664 # metadata area
+  mda_header_2.checksum 0x00000000
+  mda_header_2.start 10481664
+  mda_header_2.size 4096
+  CHECK: mda_header_2.checksum expected 0x8f3e6c8a
+  metadata text at 10485248 crc 0x11111111 # vgname vg1 seqno 2
+`
+
+func TestParsePVCheckOutput(t *testing.T) {
+	t.Parallel()
+	res, err := ParsePVCheckOutput(strings.NewReader(testPVCheckHeaders))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.IsValid() || len(res.Problems) != 1 {
+		t.Fatalf("expected exactly one problem, got %v", res.Problems)
+	}
+	if res.Headers["pv_header.device_size"] != "10485760" || res.Headers["label_header.type"] != "LVM2 001" {
+		t.Fatalf("unexpected headers %v", res.Headers)
+	}
+
+	first, second := res.MetadataArea(1), res.MetadataArea(2)
+	if first == nil || second == nil {
+		t.Fatalf("expected two metadata areas, got %d", len(res.MetadataAreas))
+	}
+	if !first.IsValid() || first.ChecksumMismatch {
+		t.Fatalf("expected first metadata area to be valid, got %v", first.Problems)
+	}
+	if first.Offset != 4096 || first.Start != 4096 || first.Size != 1044480 || first.Checksum != "0x1d7b9ea8" {
+		t.Fatalf("unexpected first metadata area %+v", first)
+	}
+	if first.TextOffset != 10752 || first.TextChecksum != "d5dd8a28" || first.VolumeGroupName != "vg1" || first.SeqNo != 3 {
+		t.Fatalf("unexpected metadata text of first metadata area %+v", first)
+	}
+	if second.IsValid() || !second.ChecksumMismatch || second.SeqNo != 2 {
+		t.Fatalf("expected second metadata area to have a checksum mismatch, got %+v", second)
+	}
+	if res.RawMetadata != "" {
+		t.Fatalf("expected no metadata text in header dump, got %q", res.RawMetadata)
+	}
+	if res.LatestSeqNo() != 3 {
+		t.Fatalf("expected latest seqno 3, got %d", res.LatestSeqNo())
+	}
+}
+
+func TestParsePVCheckOutput_MetadataAll(t *testing.T) {
+	t.Parallel()
+	res, err := ParsePVCheckOutput(strings.NewReader(`  metadata at 5632 length 1024 crc 8b6b2bd3 vg vg1 seqno 1 id aBcDeF
+  metadata at 6656 length 1260 crc d5dd8a28 vg vg1 seqno 2 id aBcDeF
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []MetadataText{
+		{Offset: 5632, Length: 1024, Checksum: "8b6b2bd3", VolumeGroupName: "vg1", SeqNo: 1},
+		{Offset: 6656, Length: 1260, Checksum: "d5dd8a28", VolumeGroupName: "vg1", SeqNo: 2},
+	}
+	if !slices.Equal(res.Metadata, expected) {
+		t.Fatalf("expected metadata %v, got %v", expected, res.Metadata)
+	}
+}
+
+func TestParsePVCheckOutput_Metadata(t *testing.T) {
+	t.Parallel()
+	text := "vg1 {\nid = \"aBcDeF\"\nseqno = 3\n\nphysical_volumes {\n\npv0 {\ndevice = \"/dev/sdb\"\n}\n}\n" +
+		"logical_volumes {\n\nlv {\nsegment1 {\nstripes = [\n\"pv0\", 0\n]\n}\n}\n}\n}\n" +
+		"# Generated by LVM2 version 2.03.22(2) (2023-08-02): Tue Jan  2 12:00:00 2024\n\n" +
+		"contents = \"Text Format Volume Group\"\nversion = 1\n"
+	res, err := ParsePVCheckOutput(strings.NewReader("  Found label on /dev/sdb, sector 1, type=LVM2 001\n" + text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RawMetadata != text {
+		t.Fatalf("expected metadata text %q, got %q", text, res.RawMetadata)
+	}
+}
+
+func TestParseVGCheckOutput(t *testing.T) {
+	t.Parallel()
+	res, err := ParseVGCheckOutput(strings.NewReader(`  WARNING: Inconsistent metadata found for VG vg1.
+  WARNING: ignoring metadata seqno 5 on /dev/sdb for seqno 6 on /dev/sdc for VG vg1.
+  WARNING: outdated PV /dev/sdd seqno 4 has been removed in current VG vg1 seqno 6.
+  /dev/sde: Checksum error at offset 6656
+  WARNING: scanning /dev/sde mda2 failed to read metadata summary.
+  WARNING: PV /dev/sdc in VG vg1 is using an old PV header, modify the VG to update.
+  WARNING: Device /dev/sdf not found in devices file.
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.IsValid() || len(res.Problems) != 5 || !res.InconsistentMetadata {
+		t.Fatalf("expected five problems with inconsistent metadata, got %+v", res)
+	}
+	if res.Problems[0] != "Inconsistent metadata found for VG vg1." {
+		t.Fatalf("expected warning prefix to be trimmed, got %q", res.Problems[0])
+	}
+	if res.SeqNo != 6 {
+		t.Fatalf("expected seqno 6, got %d", res.SeqNo)
+	}
+
+	for _, tc := range []struct {
+		pv     PhysicalVolumeName
+		number int
+		seqNo  int64
+		offset int64
+		valid  bool
+	}{
+		{"/dev/sdb", 0, 5, 0, false},
+		{"/dev/sdc", 0, 6, 0, true},
+		{"/dev/sdd", 0, 4, 0, false},
+		{"/dev/sde", 0, 0, 6656, false},
+		{"/dev/sde", 2, 0, 0, false},
+	} {
+		area := res.MetadataArea(tc.pv, tc.number)
+		if area == nil {
+			t.Fatalf("expected metadata area %d on %s to be reported", tc.number, tc.pv)
+		}
+		if area.SeqNo != tc.seqNo || area.Offset != tc.offset || area.IsValid() != tc.valid {
+			t.Fatalf("unexpected metadata area %+v", area)
+		}
+	}
+	if len(res.MetadataAreas) != 5 {
+		t.Fatalf("expected 5 metadata areas, got %d", len(res.MetadataAreas))
+	}
+
+	res, err = ParseVGCheckOutput(strings.NewReader(`  WARNING: PV /dev/sdb in VG vg1 is using an old PV header, modify the VG to update.
+  WARNING: Device /dev/sdb has size of 20480 sectors which is smaller than corresponding PV size of 40960 sectors. Was device resized?
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsValid() || res.InconsistentMetadata {
+		t.Fatalf("expected valid result, got %+v", res)
+	}
+}
+
+func TestVGCheckPVCheckOptions(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts ArgumentGenerator
+		args []string
+		err  bool
+	}{
+		{"vgck all", VGCheckOptionsList{}, []string{"--yes"}, false},
+		{"vgck update", VGCheckOptionsList{VolumeGroupName("vg"), UpdateMetadata(true)}, []string{"vg", "--updatemetadata", "--yes"}, false},
+		{"pvck headers", PVCheckOptionsList{PhysicalVolumeName("/dev/sdb"), PVCheckDumpHeaders}, []string{"--dump=headers", "--yes", "/dev/sdb"}, false},
+		{
+			"pvck metadata",
+			PVCheckOptionsList{PhysicalVolumeName("/dev/sdb"), PVCheckDumpMetadataSearch, MetadataAreaNumber(2), MetadataFile("/tmp/md")},
+			[]string{"--dump=metadata_search", "--settings=mda_num=2", "--file=/tmp/md", "--yes", "/dev/sdb"},
+			false,
+		},
+		{"pvck without pv", PVCheckOptionsList{PVCheckDumpHeaders}, nil, true},
+		{"pvck file without metadata", PVCheckOptionsList{PhysicalVolumeName("/dev/sdb"), MetadataFile("/tmp/md")}, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestIsInconsistentMetadata(t *testing.T) {
+	t.Parallel()
+	for _, stderr := range []string{
+		"  WARNING: Inconsistent metadata found for VG vg1.",
+		"  WARNING: ignoring metadata seqno 5 on /dev/sdb for seqno 6 on /dev/sdc for VG vg1.",
+		"  /dev/sdb: Checksum error at offset 6656",
+	} {
+		if !IsInconsistentMetadata(NewLVMStdErr([]byte(stderr))) {
+			t.Fatalf("expected %q to be detected as inconsistent metadata", stderr)
+		}
+	}
+}
+
+func TestVGCheckPVCheck(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+
+	clnt := NewClient()
+	ctx := context.Background()
+
+	test := test{
+		LoopDevices: []Size{
+			MustParseSize("10M"),
+		},
+	}
+
+	infra := test.SetupDevicesAndVolumeGroup(t)
+	pv := PhysicalVolumeName(infra.loopDevices.Devices()[0])
+
+	vgRes, err := clnt.VGCheck(ctx, infra.volumeGroup.Name, UpdateMetadata(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vgRes.IsValid() || vgRes.VolumeGroupName != infra.volumeGroup.Name {
+		t.Fatalf("expected valid check of %s, got %+v", infra.volumeGroup.Name, vgRes)
+	}
+
+	res, err := clnt.PVCheck(ctx, pv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.IsValid() || res.MetadataArea(1) == nil {
+		t.Fatalf("expected valid metadata area, got %+v", res)
+	}
+	if res.MetadataArea(1).VolumeGroupName != infra.volumeGroup.Name {
+		t.Fatalf("expected metadata of %s, got %s", infra.volumeGroup.Name, res.MetadataArea(1).VolumeGroupName)
+	}
+
+	res, err = clnt.PVCheck(ctx, pv, PVCheckDumpMetadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(res.RawMetadata, string(infra.volumeGroup.Name)) {
+		t.Fatalf("expected metadata text of %s, got %q", infra.volumeGroup.Name, res.RawMetadata)
+	}
+}
@@ -27,6 +27,12 @@ type (
 		To   PhysicalVolumeNames
 		LogicalVolumeName
 		AllocationPolicy
+
+		// PollInterval is the interval in which pvmove reports its progress.
+		PollInterval
+		// PollProgressHandler is called for every progress update reported by pvmove.
+		PollProgressHandler
+
 		CommonOptions
 	}
 	PVMoveOption interface {
@@ -49,12 +55,20 @@ var (
 )
 
 func (c *client) PVMove(ctx context.Context, opts ...PVMoveOption) error {
-	args, err := PVMoveOptionsList(opts).AsArgs()
+	options := PVMoveOptions{}
+	for _, opt := range opts {
+		opt.ApplyToPVMoveOptions(&options)
+	}
+
+	args, err := PVMoveOptionsList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
-	return c.RunLVM(ctx, append([]string{"pvmove"}, args.GetRaw()...)...)
+	if options.PollProgressHandler == nil {
+		return c.RunLVM(ctx, append([]string{"pvmove"}, args.GetRaw()...)...)
+	}
+	return c.RunLVMRaw(ctx, pollProgressProcessor(ctx, options.PollProgressHandler), append([]string{"pvmove"}, args.GetRaw()...)...)
 }
 
 func (opts *PVMoveOptions) ApplyToPVMoveOptions(new *PVMoveOptions) {
@@ -86,6 +100,7 @@ func (opts *PVMoveOptions) ApplyToArgs(args Arguments) error {
 		opts.From,
 		opts.To,
 		opts.AllocationPolicy,
+		opts.PollInterval,
 		opts.CommonOptions,
 	} {
 		if err := arg.ApplyToArgs(args); err != nil {
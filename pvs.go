@@ -25,6 +25,7 @@ type (
 		Unit
 		Tags
 		Select
+		Foreign
 
 		ColumnOptions
 		CommonOptions
@@ -90,6 +91,7 @@ func (opts *PVsOptions) ApplyToArgs(args Arguments) error {
 		opts.CommonOptions,
 		opts.ColumnOptions,
 		opts.Select,
+		opts.Foreign,
 	} {
 		if err := arg.ApplyToArgs(args); err != nil {
 			return err
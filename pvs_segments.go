@@ -0,0 +1,82 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+)
+
+// PVSegments returns the segments of all physical volumes that match the given options,
+// including free segments that are not allocated to any logical volume.
+// If no segments are found, nil is returned.
+// It is a wrapper around the `pvs --segments --reportformat json` command.
+func (c *client) PVSegments(ctx context.Context, opts ...PVsOption) ([]*PVSegment, error) {
+	type segReport struct {
+		Report []struct {
+			PVSeg []*PVSegment `json:"pvseg"`
+		} `json:"report"`
+	}
+
+	var res = new(segReport)
+
+	args := []string{
+		"pvs", "--segments", "--reportformat", "json",
+	}
+	argsFromOpts, err := PVSegmentsOptionsList(opts).AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	err = c.RunLVMInto(ctx, res, append(args, argsFromOpts.GetRaw()...)...)
+
+	if IsNotFound(err) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(res.Report) == 0 {
+		return nil, nil
+	}
+
+	segs := res.Report[0].PVSeg
+
+	if len(segs) == 0 {
+		return nil, nil
+	}
+
+	return segs, nil
+}
+
+// PVSegmentsOptionsList generates arguments for pvs --segments from PVsOption.
+type PVSegmentsOptionsList []PVsOption
+
+var _ ArgumentGenerator = PVSegmentsOptionsList{}
+
+func (list PVSegmentsOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypePVSegments)
+	options := PVsOptions{}
+	for _, opt := range list {
+		opt.ApplyToPVsOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
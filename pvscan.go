@@ -0,0 +1,284 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// PVScanCache makes pvscan record the scanned devices as online, which is the basis for event based autoactivation.
+type PVScanCache bool
+
+func (opt PVScanCache) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.PVScanCache = opt
+}
+
+func (opt PVScanCache) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--cache")
+	}
+	return nil
+}
+
+// ListVG makes pvscan print the volume group the scanned device belongs to.
+type ListVG bool
+
+func (opt ListVG) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.ListVG = opt
+}
+
+func (opt ListVG) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--listvg")
+	}
+	return nil
+}
+
+// ListLVs makes pvscan print the logical volumes that use the scanned device.
+type ListLVs bool
+
+func (opt ListLVs) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.ListLVs = opt
+}
+
+func (opt ListLVs) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--listlvs")
+	}
+	return nil
+}
+
+// CheckComplete makes pvscan report whether all physical volumes of the listed volume groups
+// or logical volumes are online.
+type CheckComplete bool
+
+func (opt CheckComplete) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.CheckComplete = opt
+}
+
+func (opt CheckComplete) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--checkcomplete")
+	}
+	return nil
+}
+
+// VGOnline makes pvscan report a volume group as complete only once,
+// so that concurrent device events do not activate the same volume group twice.
+type VGOnline bool
+
+func (opt VGOnline) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.VGOnline = opt
+}
+
+func (opt VGOnline) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--vgonline")
+	}
+	return nil
+}
+
+// AutoActivationEvent tells lvm2 that the command runs in response to a device event,
+// which is required to honor the event_activation setting of lvm.conf.
+type AutoActivationEvent bool
+
+func (opt AutoActivationEvent) ApplyToPVScanOptions(opts *PVScanOptions) {
+	opts.AutoActivationEvent = opt
+}
+
+func (opt AutoActivationEvent) ApplyToVGChangeOptions(opts *VGChangeOptions) {
+	opts.AutoActivationEvent = opt
+}
+
+func (opt AutoActivationEvent) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--autoactivation=event")
+	}
+	return nil
+}
+
+type (
+	PVScanOptions struct {
+		// PhysicalVolumeName is the device to scan. If empty, all devices are scanned.
+		PhysicalVolumeName
+
+		PVScanCache
+		ListVG
+		ListLVs
+		CheckComplete
+		VGOnline
+		AutoActivationEvent
+		// ActivationState can only be AutoActivate, which activates complete volume groups.
+		ActivationState
+
+		CommonOptions
+	}
+	PVScanOption interface {
+		ApplyToPVScanOptions(opts *PVScanOptions)
+	}
+	PVScanOptionsList []PVScanOption
+)
+
+var (
+	_ ArgumentGenerator = PVScanOptionsList{}
+	_ Argument          = (*PVScanOptions)(nil)
+)
+
+// PVScanVolumeGroup is a volume group reported by pvscan.
+type PVScanVolumeGroup struct {
+	Name VolumeGroupName
+	// Complete is true if all physical volumes of the volume group are online.
+	Complete bool
+}
+
+// PVScanLogicalVolume is a logical volume reported by pvscan --listlvs.
+type PVScanLogicalVolume struct {
+	VolumeGroupName VolumeGroupName
+	Name            LogicalVolumeName
+	// Complete is true if all physical volumes of the logical volume are online.
+	Complete bool
+}
+
+// PVScanResult is the typed output of pvscan.
+type PVScanResult struct {
+	VolumeGroups   []PVScanVolumeGroup
+	LogicalVolumes []PVScanLogicalVolume
+}
+
+// CompleteVolumeGroups returns the volume groups that became complete with the scan.
+func (res *PVScanResult) CompleteVolumeGroups() []VolumeGroupName {
+	var names []VolumeGroupName
+	for _, vg := range res.VolumeGroups {
+		if vg.Complete {
+			names = append(names, vg.Name)
+		}
+	}
+	return names
+}
+
+func (c *client) PVScan(ctx context.Context, opts ...PVScanOption) (*PVScanResult, error) {
+	args, err := PVScanOptionsList(opts).AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	var res *PVScanResult
+	err = c.RunLVMRaw(ctx, func(out io.Reader) error {
+		var err error
+		res, err = ParsePVScanOutput(out)
+		return err
+	}, append([]string{"pvscan"}, args.GetRaw()...)...)
+	if res == nil {
+		return nil, err
+	}
+
+	// pvscan reports warnings about unrelated devices in stderr, the parsed result is still valid in this case.
+	return res, err
+}
+
+func (list PVScanOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := PVScanOptions{}
+	for _, opt := range list {
+		opt.ApplyToPVScanOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *PVScanOptions) ApplyToPVScanOptions(new *PVScanOptions) {
+	*new = *opts
+}
+
+func (opts *PVScanOptions) ApplyToArgs(args Arguments) error {
+	if opts.ActivationState != "" && opts.ActivationState != AutoActivate {
+		return fmt.Errorf("pvscan only supports the activation state %q, got %q", AutoActivate, opts.ActivationState)
+	}
+	if opts.ActivationState != "" && !opts.PVScanCache {
+		return fmt.Errorf("PVScanCache is required for autoactivation with pvscan")
+	}
+
+	for _, arg := range []Argument{
+		opts.PVScanCache,
+		opts.ListVG,
+		opts.ListLVs,
+		opts.CheckComplete,
+		opts.VGOnline,
+		opts.AutoActivationEvent,
+		opts.ActivationState,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	if opts.PhysicalVolumeName != "" {
+		return opts.PhysicalVolumeName.ApplyToArgs(args)
+	}
+
+	return nil
+}
+
+var (
+	pvScanVGPattern       = regexp.MustCompile(`^\s*VG (\S+)(?: (complete|incomplete))?`)
+	pvScanLVPattern       = regexp.MustCompile(`^\s*LV (\S+?)/(\S+)(?: (complete|incomplete))?`)
+	pvScanOnlinePattern   = regexp.MustCompile(`PV \S+ online, VG (\S+) (is complete|incomplete)`)
+	pvScanUdevVGPattern   = regexp.MustCompile(`^LVM_VG_NAME_(COMPLETE|INCOMPLETE)='?([^'\s]+)'?`)
+	pvScanCompleteMarkers = map[string]bool{"complete": true, "is complete": true, "COMPLETE": true}
+)
+
+// ParsePVScanOutput parses the output of pvscan with --listvg, --listlvs and --checkcomplete
+// as well as the online messages of pvscan --cache.
+// Volume groups and logical volumes listed without --checkcomplete are reported as incomplete.
+func ParsePVScanOutput(out io.Reader) (*PVScanResult, error) {
+	res := &PVScanResult{}
+	addVG := func(name string, complete bool) {
+		for i, vg := range res.VolumeGroups {
+			if vg.Name == VolumeGroupName(name) {
+				res.VolumeGroups[i].Complete = vg.Complete || complete
+				return
+			}
+		}
+		res.VolumeGroups = append(res.VolumeGroups, PVScanVolumeGroup{Name: VolumeGroupName(name), Complete: complete})
+	}
+
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if matches := pvScanLVPattern.FindStringSubmatch(line); matches != nil {
+			res.LogicalVolumes = append(res.LogicalVolumes, PVScanLogicalVolume{
+				VolumeGroupName: VolumeGroupName(matches[1]),
+				Name:            LogicalVolumeName(matches[2]),
+				Complete:        pvScanCompleteMarkers[matches[3]],
+			})
+		} else if matches := pvScanVGPattern.FindStringSubmatch(line); matches != nil {
+			addVG(matches[1], pvScanCompleteMarkers[matches[2]])
+		} else if matches := pvScanOnlinePattern.FindStringSubmatch(line); matches != nil {
+			addVG(matches[1], pvScanCompleteMarkers[matches[2]])
+		} else if matches := pvScanUdevVGPattern.FindStringSubmatch(line); matches != nil {
+			addVG(matches[2], pvScanCompleteMarkers[matches[1]])
+		}
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,167 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestParsePVScanOutput(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		out      string
+		vgs      []PVScanVolumeGroup
+		lvs      []PVScanLogicalVolume
+		complete []VolumeGroupName
+	}{
+		{
+			name:     "listvg checkcomplete",
+			out:      "  VG vg1 complete\n",
+			vgs:      []PVScanVolumeGroup{{Name: "vg1", Complete: true}},
+			complete: []VolumeGroupName{"vg1"},
+		},
+		{
+			name: "listvg incomplete",
+			out:  "  VG vg1 incomplete\n",
+			vgs:  []PVScanVolumeGroup{{Name: "vg1"}},
+		},
+		{
+			name: "listvg",
+			out:  "  VG vg1\n",
+			vgs:  []PVScanVolumeGroup{{Name: "vg1"}},
+		},
+		{
+			name:     "listlvs",
+			out:      "  VG vg1 complete\n  LV vg1/lv1 complete\n  LV vg1/lv2 incomplete\n",
+			vgs:      []PVScanVolumeGroup{{Name: "vg1", Complete: true}},
+			lvs:      []PVScanLogicalVolume{{VolumeGroupName: "vg1", Name: "lv1", Complete: true}, {VolumeGroupName: "vg1", Name: "lv2"}},
+			complete: []VolumeGroupName{"vg1"},
+		},
+		{
+			name:     "cache activation",
+			out:      "  pvscan[1234] PV /dev/sdb online, VG vg1 is complete.\n  pvscan[1234] VG vg1 run autoactivation.\n",
+			vgs:      []PVScanVolumeGroup{{Name: "vg1", Complete: true}},
+			complete: []VolumeGroupName{"vg1"},
+		},
+		{
+			name: "cache incomplete",
+			out:  "  pvscan[1234] PV /dev/sdb online, VG vg1 incomplete (need 1).\n",
+			vgs:  []PVScanVolumeGroup{{Name: "vg1"}},
+		},
+		{
+			name:     "udev output",
+			out:      "LVM_VG_NAME_COMPLETE='vg1'\n",
+			vgs:      []PVScanVolumeGroup{{Name: "vg1", Complete: true}},
+			complete: []VolumeGroupName{"vg1"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			res, err := ParsePVScanOutput(strings.NewReader(tc.out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(res.VolumeGroups, tc.vgs) {
+				t.Fatalf("expected volume groups %v, got %v", tc.vgs, res.VolumeGroups)
+			}
+			if !slices.Equal(res.LogicalVolumes, tc.lvs) {
+				t.Fatalf("expected logical volumes %v, got %v", tc.lvs, res.LogicalVolumes)
+			}
+			if !slices.Equal(res.CompleteVolumeGroups(), tc.complete) {
+				t.Fatalf("expected complete volume groups %v, got %v", tc.complete, res.CompleteVolumeGroups())
+			}
+		})
+	}
+}
+
+func TestPVScanOptionsList(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts PVScanOptionsList
+		args []string
+		err  bool
+	}{
+		{"all", PVScanOptionsList{}, []string{"--yes"}, false},
+		{
+			"event",
+			PVScanOptionsList{PhysicalVolumeName("/dev/sdb"), PVScanCache(true), ListVG(true), CheckComplete(true), VGOnline(true), AutoActivationEvent(true)},
+			[]string{"--cache", "--listvg", "--checkcomplete", "--vgonline", "--autoactivation=event", "--yes", "/dev/sdb"},
+			false,
+		},
+		{
+			"autoactivate",
+			PVScanOptionsList{PhysicalVolumeName("/dev/sdb"), PVScanCache(true), AutoActivate},
+			[]string{"--cache", "--activate", "ay", "--yes", "/dev/sdb"},
+			false,
+		},
+		{"activate", PVScanOptionsList{PVScanCache(true), Activate}, nil, true},
+		{"autoactivate without cache", PVScanOptionsList{AutoActivate}, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestPVScan(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+
+	clnt := NewClient()
+	ctx := context.Background()
+
+	test := test{
+		LoopDevices: []Size{
+			MustParseSize("10M"),
+		},
+	}
+
+	infra := test.SetupDevicesAndVolumeGroup(t)
+
+	res, err := clnt.PVScan(ctx,
+		PhysicalVolumeName(infra.loopDevices.Devices()[0]),
+		PVScanCache(true),
+		ListVG(true),
+		CheckComplete(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Contains(res.CompleteVolumeGroups(), infra.volumeGroup.Name) {
+		t.Fatalf("expected volume group %s to be complete, got %v", infra.volumeGroup.Name, res.VolumeGroups)
+	}
+}
@@ -0,0 +1,385 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+var (
+	ErrUnknownRecoveryAction       = errors.New("unknown recovery action")
+	ErrRecoveryActionNotApplicable = errors.New("recovery action is not applicable to the logical volume")
+	ErrPartialLogicalVolumesRemain = errors.New("missing physical volumes cannot be removed while partial logical volumes remain")
+)
+
+// RecoveryClass classifies a logical volume in a volume group with missing physical volumes.
+type RecoveryClass string
+
+const (
+	// RecoveryClassIntact logical volumes have no extents on missing physical volumes.
+	RecoveryClassIntact RecoveryClass = "intact"
+	// RecoveryClassRepairable logical volumes are RAID or mirrored logical volumes
+	// that lost fewer images than their redundancy tolerates.
+	RecoveryClassRepairable RecoveryClass = "repairable"
+	// RecoveryClassLost logical volumes lost data that cannot be rebuilt from the remaining physical volumes.
+	RecoveryClassLost RecoveryClass = "lost"
+)
+
+// RecoveryAction is executed on a logical volume during Recover.
+type RecoveryAction string
+
+const (
+	// RecoveryActionNone leaves the logical volume untouched.
+	RecoveryActionNone RecoveryAction = ""
+	// RecoveryActionRepair rebuilds the missing images with lvconvert --repair.
+	RecoveryActionRepair RecoveryAction = "repair"
+	// RecoveryActionActivateDegraded activates the logical volume with ActivationModeDegraded.
+	RecoveryActionActivateDegraded RecoveryAction = "activate-degraded"
+	// RecoveryActionActivatePartial activates the logical volume with ActivationModePartial,
+	// e.g. to rescue the remaining data of a lost logical volume.
+	RecoveryActionActivatePartial RecoveryAction = "activate-partial"
+	// RecoveryActionRemove removes the logical volume.
+	RecoveryActionRemove RecoveryAction = "remove"
+)
+
+// LogicalVolumeRecovery is the classification of a logical volume and the action chosen for it.
+type LogicalVolumeRecovery struct {
+	LogicalVolume *LogicalVolume
+	Class         RecoveryClass
+	// Reason describes why the logical volume is not intact.
+	Reason string
+	Action RecoveryAction
+}
+
+// RecoveryPlan describes the recovery of a volume group with missing physical volumes.
+type RecoveryPlan struct {
+	VolumeGroupName
+	MissingPhysicalVolumes int64
+	LogicalVolumes         []*LogicalVolumeRecovery
+
+	// PhysicalVolumeNames are the physical volumes used to rebuild repaired images.
+	// If empty, lvm2 allocates from all physical volumes in the volume group.
+	PhysicalVolumeNames
+
+	// RemoveMissing removes the missing physical volumes from the volume group with vgreduce --removemissing
+	// after all logical volumes were recovered. This requires that no partial logical volume remains.
+	RemoveMissing bool
+}
+
+// NewRecoveryPlan classifies the logical volumes of the volume group and proposes a recovery plan.
+// The logical volumes need to include internal logical volumes, see ShowInternal,
+// and the segments are used to determine the RAID level of RAID logical volumes.
+//
+// The proposed plan repairs all repairable logical volumes and does not act on lost logical volumes,
+// as removing them destroys the remaining data. Choose RecoveryActionRemove or RecoveryActionActivatePartial
+// for them with RecoveryPlan.Choose. The missing physical volumes are only removed if no lost logical volume exists.
+func NewRecoveryPlan(vg VolumeGroupName, lvs []*LogicalVolume, segments []*LVSegment) *RecoveryPlan {
+	type images struct{ total, missing int }
+	imagesByLV := make(map[LogicalVolumeName]*images)
+	for _, lv := range lvs {
+		parent, kind, ok := subVolumeOf(string(lv.Name))
+		if !ok || kind == subVolumeRAIDMeta {
+			continue
+		}
+		if imagesByLV[parent] == nil {
+			imagesByLV[parent] = &images{}
+		}
+		imagesByLV[parent].total++
+		if lv.Attr.VolumeHealth == VolumeHealthPartialActivation {
+			imagesByLV[parent].missing++
+		}
+	}
+
+	types := make(map[LogicalVolumeName]Type)
+	for _, seg := range segments {
+		if _, ok := types[seg.LogicalVolumeName]; !ok {
+			types[seg.LogicalVolumeName] = seg.Type
+		}
+	}
+
+	plan := &RecoveryPlan{VolumeGroupName: vg, RemoveMissing: true}
+	lost := make(map[string]bool)
+	for _, lv := range lvs {
+		if strings.HasPrefix(string(lv.Name), "[") {
+			continue
+		}
+		recovery := &LogicalVolumeRecovery{LogicalVolume: lv, Class: RecoveryClassIntact}
+		if lv.Attr.VolumeHealth == VolumeHealthPartialActivation {
+			recovery.Class, recovery.Reason = RecoveryClassLost, "extents on missing physical volumes"
+			if img := imagesByLV[lv.Name]; img != nil && isRedundant(lv.Attr.VolumeType) {
+				recovery.Reason = fmt.Sprintf("%d of %d images missing", img.missing, img.total)
+				if img.missing < img.total && img.missing <= redundancyOf(types[lv.Name], img.total) {
+					recovery.Class, recovery.Action = RecoveryClassRepairable, RecoveryActionRepair
+				}
+			}
+		}
+		if recovery.Class == RecoveryClassLost {
+			lost[string(lv.Name)] = true
+			plan.RemoveMissing = false
+		}
+		plan.LogicalVolumes = append(plan.LogicalVolumes, recovery)
+	}
+
+	// thin volumes cannot be used without their thin pool, so they are lost with it.
+	for _, recovery := range plan.LogicalVolumes {
+		if recovery.Class != RecoveryClassLost && lost[recovery.LogicalVolume.PoolLogicalVolume] {
+			recovery.Class, recovery.Reason, recovery.Action = RecoveryClassLost, "thin pool is lost", RecoveryActionNone
+		}
+	}
+
+	return plan
+}
+
+func isRedundant(typ VolumeType) bool {
+	switch typ {
+	case VolumeTypeRAID, VolumeTypeRAIDNoInitialSync, VolumeTypeMirrored, VolumeTypeMirroredNoInitialSync:
+		return true
+	}
+	return false
+}
+
+// redundancyOf returns the number of images of a logical volume with the given segment type that can be lost.
+func redundancyOf(typ Type, images int) int {
+	switch {
+	case typ == TypeRAID1, strings.HasPrefix(string(typ), "mirror"):
+		return images - 1
+	case strings.HasPrefix(string(typ), string(TypeRAID6)):
+		return 2
+	case strings.HasPrefix(string(typ), string(TypeRAID4)),
+		strings.HasPrefix(string(typ), string(TypeRAID5)),
+		typ == TypeRAID10:
+		return 1
+	}
+	return 0
+}
+
+// Choose sets the action for the logical volume in the plan.
+func (plan *RecoveryPlan) Choose(lv LogicalVolumeName, action RecoveryAction) error {
+	for _, recovery := range plan.LogicalVolumes {
+		if recovery.LogicalVolume.Name != lv {
+			continue
+		}
+		if err := recovery.validate(action); err != nil {
+			return err
+		}
+		recovery.Action = action
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrLogicalVolumeNotFound, lv)
+}
+
+// Validate verifies that all chosen actions are applicable and that no partial logical volume
+// remains if the missing physical volumes are removed.
+func (plan *RecoveryPlan) Validate() error {
+	if plan.VolumeGroupName == "" {
+		return ErrVolumeGroupNameRequired
+	}
+	for _, recovery := range plan.LogicalVolumes {
+		if err := recovery.validate(recovery.Action); err != nil {
+			return err
+		}
+		if plan.RemoveMissing && recovery.Class != RecoveryClassIntact &&
+			recovery.Action != RecoveryActionRepair && recovery.Action != RecoveryActionRemove {
+			return fmt.Errorf("%w: %s is %s", ErrPartialLogicalVolumesRemain, recovery.LogicalVolume.Name, recovery.Class)
+		}
+	}
+	return nil
+}
+
+func (recovery *LogicalVolumeRecovery) validate(action RecoveryAction) error {
+	var applicable bool
+	switch action {
+	case RecoveryActionNone, RecoveryActionActivatePartial, RecoveryActionRemove:
+		applicable = true
+	case RecoveryActionRepair:
+		applicable = recovery.Class == RecoveryClassRepairable
+	case RecoveryActionActivateDegraded:
+		applicable = recovery.Class != RecoveryClassLost
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownRecoveryAction, action)
+	}
+	if !applicable {
+		return fmt.Errorf("%w: cannot %s %s logical volume %s",
+			ErrRecoveryActionNotApplicable, action, recovery.Class, recovery.LogicalVolume.Name)
+	}
+	return nil
+}
+
+func (c *client) PlanRecovery(ctx context.Context, vg VolumeGroupName) (*RecoveryPlan, error) {
+	if vg == "" {
+		return nil, ErrVolumeGroupNameRequired
+	}
+	volumeGroup, err := c.VG(ctx, vg)
+	if err != nil {
+		return nil, err
+	}
+	lvs, err := c.LVs(ctx, vg, ShowInternal(true))
+	if err != nil {
+		return nil, err
+	}
+	segments, err := c.LVSegments(ctx, vg)
+	if err != nil {
+		return nil, err
+	}
+	plan := NewRecoveryPlan(vg, lvs, segments)
+	plan.MissingPhysicalVolumes = volumeGroup.MissingPVCount
+	plan.RemoveMissing = plan.RemoveMissing && plan.MissingPhysicalVolumes > 0
+	return plan, nil
+}
+
+type (
+	RepairRAIDOptions struct {
+		VolumeGroupName
+		LogicalVolumeName
+
+		// PhysicalVolumeNames are the physical volumes the rebuilt images are allocated on.
+		// If empty, lvm2 allocates from all physical volumes in the volume group.
+		PhysicalVolumeNames
+
+		CommonOptions
+	}
+	RepairRAIDOption interface {
+		ApplyToRepairRAIDOptions(opts *RepairRAIDOptions)
+	}
+	RepairRAIDOptionsList []RepairRAIDOption
+)
+
+var (
+	_ ArgumentGenerator = RepairRAIDOptionsList{}
+	_ Argument          = (*RepairRAIDOptions)(nil)
+)
+
+func (opt PhysicalVolumeNames) ApplyToRepairRAIDOptions(opts *RepairRAIDOptions) {
+	opts.PhysicalVolumeNames = append(opts.PhysicalVolumeNames, opt...)
+}
+
+func (list RepairRAIDOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := RepairRAIDOptions{}
+	for _, opt := range list {
+		opt.ApplyToRepairRAIDOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *RepairRAIDOptions) ApplyToRepairRAIDOptions(new *RepairRAIDOptions) {
+	*new = *opts
+}
+
+func (opts *RepairRAIDOptions) ApplyToArgs(args Arguments) error {
+	fq := &FQLogicalVolumeName{VolumeGroupName: opts.VolumeGroupName, LogicalVolumeName: opts.LogicalVolumeName}
+	if err := fq.Validate(); err != nil {
+		return err
+	}
+
+	args.AddOrReplaceAll([]string{"--repair"})
+	for _, arg := range []Argument{
+		fq,
+		opts.CommonOptions,
+		opts.PhysicalVolumeNames,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) Recover(ctx context.Context, plan *RecoveryPlan) error {
+	if err := plan.Validate(); err != nil {
+		return err
+	}
+	vg := plan.VolumeGroupName
+
+	for _, recovery := range plan.LogicalVolumes {
+		if recovery.Action != RecoveryActionRepair {
+			continue
+		}
+		lv := recovery.LogicalVolume
+		fq := &FQLogicalVolumeName{VolumeGroupName: vg, LogicalVolumeName: lv.Name}
+		// images can only be rebuilt while the logical volume is active.
+		if !lv.Attr.State.IsActive() {
+			if err := c.LVChange(ctx, vg, lv.Name, Activate, ActivationModeDegraded); err != nil {
+				return fmt.Errorf("failed to activate %s for repair: %w", fq, err)
+			}
+		}
+		slog.InfoContext(ctx, "repairing logical volume", slog.String("lv", fq.String()), slog.String("reason", recovery.Reason))
+		args, err := RepairRAIDOptionsList{&RepairRAIDOptions{
+			VolumeGroupName:     vg,
+			LogicalVolumeName:   lv.Name,
+			PhysicalVolumeNames: plan.PhysicalVolumeNames,
+		}}.AsArgs()
+		if err != nil {
+			return err
+		}
+		if err := c.RunLVM(ctx, append([]string{"lvconvert"}, args.GetRaw()...)...); err != nil {
+			return fmt.Errorf("failed to repair %s: %w", fq, err)
+		}
+	}
+
+	for _, recovery := range plan.LogicalVolumes {
+		var mode ActivationMode
+		switch recovery.Action {
+		case RecoveryActionActivateDegraded:
+			mode = ActivationModeDegraded
+		case RecoveryActionActivatePartial:
+			mode = ActivationModePartial
+		default:
+			continue
+		}
+		lv := recovery.LogicalVolume.Name
+		slog.InfoContext(ctx, "activating logical volume", slog.String("vg", string(vg)), slog.String("lv", string(lv)), slog.String("mode", string(mode)))
+		if err := c.LVChange(ctx, vg, lv, Activate, mode); err != nil {
+			return fmt.Errorf("failed to activate %s/%s with activation mode %s: %w", vg, lv, mode, err)
+		}
+	}
+
+	// thin volumes are removed before the thin pools they are allocated in.
+	var removals []*LogicalVolume
+	for _, recovery := range plan.LogicalVolumes {
+		if recovery.Action != RecoveryActionRemove {
+			continue
+		}
+		if recovery.LogicalVolume.Attr.VolumeType == VolumeTypeThinVolume {
+			removals = append([]*LogicalVolume{recovery.LogicalVolume}, removals...)
+		} else {
+			removals = append(removals, recovery.LogicalVolume)
+		}
+	}
+	for _, lv := range removals {
+		slog.InfoContext(ctx, "removing logical volume", slog.String("vg", string(vg)), slog.String("lv", string(lv.Name)))
+		if err := c.LVRemove(ctx, vg, lv.Name, Force(true)); err != nil {
+			return fmt.Errorf("failed to remove %s/%s: %w", vg, lv.Name, err)
+		}
+	}
+
+	if plan.RemoveMissing {
+		slog.InfoContext(ctx, "removing missing physical volumes", slog.String("vg", string(vg)))
+		if err := c.VGReduce(ctx, vg, RemoveMissing(true)); err != nil {
+			return fmt.Errorf("failed to remove missing physical volumes from %s: %w", vg, err)
+		}
+	}
+
+	return nil
+}
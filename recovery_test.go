@@ -0,0 +1,206 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestNewRecoveryPlan(t *testing.T) {
+	t.Parallel()
+	lv := func(name, attr, pool string) *LogicalVolume {
+		attributes, err := ParseLVAttributes(attr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &LogicalVolume{Name: LogicalVolumeName(name), Attr: attributes, PoolLogicalVolume: pool}
+	}
+	seg := func(name string, typ Type) *LVSegment {
+		return &LVSegment{LogicalVolumeName: LogicalVolumeName(name), Type: typ}
+	}
+	plan := NewRecoveryPlan("vg", []*LogicalVolume{
+		lv("intact", "-wi-a-----", ""),
+		lv("linear", "-wi-a---p-", ""),
+		lv("mirror", "rwi-a-r-p-", ""),
+		lv("[mirror_rimage_0]", "iwi-aor---", ""),
+		lv("[mirror_rimage_1]", "iwi-aor-p-", ""),
+		lv("stripes", "rwi-a-r-p-", ""),
+		lv("[stripes_rimage_0]", "iwi-aor-p-", ""),
+		lv("[stripes_rimage_1]", "iwi-aor---", ""),
+		lv("parity", "rwi-a-r-p-", ""),
+		lv("[parity_rimage_0]", "iwi-aor-p-", ""),
+		lv("[parity_rimage_1]", "iwi-aor-p-", ""),
+		lv("[parity_rimage_2]", "iwi-aor---", ""),
+		lv("pool", "twi-a-tzp-", ""),
+		lv("thin", "Vwi-a-tz--", "pool"),
+	}, []*LVSegment{
+		seg("intact", TypeLinear),
+		seg("linear", TypeLinear),
+		seg("mirror", TypeRAID1),
+		seg("stripes", TypeRAID0),
+		seg("parity", TypeRAID5+"_ls"),
+		seg("pool", TypeThinPool),
+		seg("thin", TypeThin),
+	})
+
+	expected := map[LogicalVolumeName]struct {
+		class  RecoveryClass
+		action RecoveryAction
+	}{
+		"intact":  {RecoveryClassIntact, RecoveryActionNone},
+		"linear":  {RecoveryClassLost, RecoveryActionNone},
+		"mirror":  {RecoveryClassRepairable, RecoveryActionRepair},
+		"stripes": {RecoveryClassLost, RecoveryActionNone},
+		"parity":  {RecoveryClassLost, RecoveryActionNone},
+		"pool":    {RecoveryClassLost, RecoveryActionNone},
+		"thin":    {RecoveryClassLost, RecoveryActionNone},
+	}
+	if len(plan.LogicalVolumes) != len(expected) {
+		t.Fatalf("expected %d logical volumes, got %d", len(expected), len(plan.LogicalVolumes))
+	}
+	for _, recovery := range plan.LogicalVolumes {
+		expect := expected[recovery.LogicalVolume.Name]
+		if recovery.Class != expect.class || recovery.Action != expect.action {
+			t.Errorf("expected %s to be %s with action %q, got %s with action %q (%s)", recovery.LogicalVolume.Name,
+				expect.class, expect.action, recovery.Class, recovery.Action, recovery.Reason)
+		}
+	}
+	if plan.RemoveMissing {
+		t.Fatal("expected missing physical volumes to be kept while lost logical volumes exist")
+	}
+
+	if err := plan.Choose("linear", RecoveryActionRepair); !errors.Is(err, ErrRecoveryActionNotApplicable) {
+		t.Fatalf("expected %v, got %v", ErrRecoveryActionNotApplicable, err)
+	}
+	if err := plan.Choose("parity", RecoveryActionActivateDegraded); !errors.Is(err, ErrRecoveryActionNotApplicable) {
+		t.Fatalf("expected %v, got %v", ErrRecoveryActionNotApplicable, err)
+	}
+	if err := plan.Choose("unknown", RecoveryActionRemove); !errors.Is(err, ErrLogicalVolumeNotFound) {
+		t.Fatalf("expected %v, got %v", ErrLogicalVolumeNotFound, err)
+	}
+
+	plan.RemoveMissing = true
+	if err := plan.Validate(); !errors.Is(err, ErrPartialLogicalVolumesRemain) {
+		t.Fatalf("expected %v, got %v", ErrPartialLogicalVolumesRemain, err)
+	}
+	for _, lost := range []LogicalVolumeName{"linear", "stripes", "parity", "pool", "thin"} {
+		if err := plan.Choose(lost, RecoveryActionRemove); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := plan.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRepairRAIDArgs(t *testing.T) {
+	t.Parallel()
+	args, err := RepairRAIDOptionsList{
+		&RepairRAIDOptions{VolumeGroupName: "vg", LogicalVolumeName: "raid"},
+		PhysicalVolumeNames{"/dev/sdc", "/dev/sdd"},
+	}.AsArgs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := []string{"--repair", "vg/raid", "--yes", "/dev/sdc", "/dev/sdd"}; !slices.Equal(args.GetRaw(), expected) {
+		t.Fatalf("expected %v, got %v", expected, args.GetRaw())
+	}
+
+	if _, err := (RepairRAIDOptionsList{&RepairRAIDOptions{VolumeGroupName: "vg"}}).AsArgs(); err == nil {
+		t.Fatal("expected logical volume name to be required")
+	}
+}
+
+func TestRecover(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+
+	clnt := NewClient()
+	ctx := context.Background()
+
+	infra := test{
+		LoopDevices: []Size{
+			MustParseSize("20M"),
+			MustParseSize("20M"),
+			MustParseSize("20M"),
+		},
+	}.SetupDevicesAndVolumeGroup(t)
+	vg := infra.volumeGroup.Name
+	pvs := infra.loopDevices.PhysicalVolumeNames()
+
+	if err := clnt.LVCreate(ctx, vg, LogicalVolumeName("mirror"), MustParseSize("4M"), TypeRAID1, Mirrors(1), pvs[0], pvs[1]); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.LVCreate(ctx, vg, LogicalVolumeName("linear"), MustParseSize("4M"), pvs[1]); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := infra.loopDevices[1].Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Wait to allow the device to be removed
+	time.Sleep(100 * time.Millisecond)
+
+	plan, err := clnt.PlanRecovery(ctx, vg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plan.MissingPhysicalVolumes != 1 {
+		t.Fatalf("expected 1 missing physical volume, got %d", plan.MissingPhysicalVolumes)
+	}
+	for _, recovery := range plan.LogicalVolumes {
+		switch recovery.LogicalVolume.Name {
+		case "mirror":
+			if recovery.Class != RecoveryClassRepairable {
+				t.Fatalf("expected mirror to be repairable, got %s", recovery.Class)
+			}
+		case "linear":
+			if recovery.Class != RecoveryClassLost {
+				t.Fatalf("expected linear to be lost, got %s", recovery.Class)
+			}
+		}
+	}
+
+	if err := plan.Choose("linear", RecoveryActionRemove); err != nil {
+		t.Fatal(err)
+	}
+	plan.RemoveMissing = true
+	if err := clnt.Recover(ctx, plan); err != nil {
+		t.Fatal(err)
+	}
+
+	volumeGroup, err := clnt.VG(ctx, vg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if volumeGroup.MissingPVCount != 0 {
+		t.Fatalf("expected no missing physical volumes, got %d", volumeGroup.MissingPVCount)
+	}
+	mirror, err := clnt.LV(ctx, vg, LogicalVolumeName("mirror"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := mirror.Attr.VerifyHealth(); err != nil {
+		t.Fatal(err)
+	}
+}
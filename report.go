@@ -0,0 +1,478 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownReportField      = errors.New("field is not a known report field")
+	ErrUnsupportedReportValue  = errors.New("field type is not supported for report values")
+	ErrInvalidReportTarget     = errors.New("report target must be a pointer to a slice of structs or a pointer to a struct")
+	ErrUnsupportedReportType   = errors.New("report type is not supported")
+	ErrMultipleReportRowsFound = errors.New("report contains multiple rows but the target is a single struct")
+	ErrNoReportColumns         = errors.New("no report columns requested, tag the fields to report with LVMConfigStructTag")
+)
+
+// ReportTimeLayout is the layout lvm2 uses for time fields such as lv_time.
+const ReportTimeLayout = "2006-01-02 15:04:05 -0700"
+
+var (
+	sizeType          = reflect.TypeOf(Size{})
+	timeType          = reflect.TypeOf(time.Time{})
+	lvAttributesType  = reflect.TypeOf(LVAttributes{})
+	vgAttributesType  = reflect.TypeOf(VGAttributes{})
+	pvAttributesType  = reflect.TypeOf(PVAttributes{})
+	textUnmarshalType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+type (
+	ReportOptions struct {
+		ColumnOptions
+		Select
+		Unit
+
+		CommonOptions
+	}
+	ReportOption interface {
+		ApplyToReportOptions(opts *ReportOptions)
+	}
+	ReportOptionsList []ReportOption
+)
+
+var (
+	_ ArgumentGenerator = ReportOptionsList{}
+	_ Argument          = (*ReportOptions)(nil)
+)
+
+func (c *client) ReportInto(ctx context.Context, v any, reportType ReportType, opts ...ReportOption) error {
+	command, types, err := reportCommand(reportType)
+	if err != nil {
+		return err
+	}
+
+	columns, err := ReportColumns(v, types...)
+	if err != nil {
+		return err
+	}
+
+	args := append(command, "--reportformat", "json")
+	argsFromOpts, err := append(ReportOptionsList{columns}, opts...).AsArgs()
+	if err != nil {
+		return err
+	}
+
+	err = c.RunLVMInto(ctx, &reportDecoder{reportType: reportType, into: v}, append(args, argsFromOpts.GetRaw()...)...)
+	if IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// reportCommand returns the command reporting on the report type and the report types whose fields it can report.
+func reportCommand(reportType ReportType) ([]string, []ReportType, error) {
+	switch reportType {
+	case ReportTypeLV:
+		return []string{"lvs"}, LVsReportTypes, nil
+	case ReportTypeVG:
+		return []string{"vgs"}, VGsReportTypes, nil
+	case ReportTypePV:
+		return []string{"pvs"}, PVsReportTypes, nil
+	case ReportTypeSeg:
+		return []string{"lvs", "--segments"}, LVsReportTypes, nil
+	case ReportTypePVSeg:
+		return []string{"pvs", "--segments"}, append(PVsReportTypes, ReportTypeLV, ReportTypeSeg), nil
+	default:
+		return nil, nil, fmt.Errorf("%w: %q", ErrUnsupportedReportType, reportType)
+	}
+}
+
+func (list ReportOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := ReportOptions{}
+	for _, opt := range list {
+		opt.ApplyToReportOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *ReportOptions) ApplyToReportOptions(new *ReportOptions) {
+	*new = *opts
+}
+
+func (opts *ReportOptions) ApplyToArgs(args Arguments) error {
+	// sizes are reported in bytes by default so they can be decoded without losing precision.
+	unit := opts.Unit
+	if unit == UnitUnknown {
+		unit = UnitBytes
+	}
+
+	if len(opts.ColumnOptions) == 0 {
+		return ErrNoReportColumns
+	}
+
+	for _, arg := range []Argument{
+		opts.ColumnOptions,
+		opts.Select,
+		unit,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reportDecoder decodes a json report into a target through DecodeReport.
+type reportDecoder struct {
+	reportType ReportType
+	into       any
+}
+
+func (d *reportDecoder) UnmarshalJSON(data []byte) error {
+	return DecodeReport(data, d.reportType, d.into)
+}
+
+// ReportColumns returns the report columns for all fields of the struct (or slice of structs) v
+// that are tagged with LVMConfigStructTag, e.g.
+//
+//	type Volume struct {
+//		Name string `lvm:"lv_name"`
+//		Size Size   `lvm:"lv_size"`
+//	}
+//
+// If report types are given, all tagged fields have to be known fields of one of these types.
+// Untagged embedded structs are searched for tagged fields as well.
+func ReportColumns(v any, types ...ReportType) (ColumnOptions, error) {
+	t, err := reportElemType(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+	var columns ColumnOptions
+	err = walkReportFields(t, nil, func(name string, _ []int) error {
+		if len(types) > 0 && !IsReportField(name, types...) {
+			return fmt.Errorf("%w for %v: %q", ErrUnknownReportField, types, name)
+		}
+		columns = append(columns, name)
+		return nil
+	})
+	return columns, err
+}
+
+// DecodeReport decodes the rows of the given report type from a json report as produced by
+// lvs, vgs, pvs or fullreport with --reportformat json.
+// The target has to be a pointer to a slice of structs (or struct pointers) or a pointer to a single struct.
+// Fields are decoded as described in DecodeReportRow.
+func DecodeReport(data []byte, reportType ReportType, into any) error {
+	var raw struct {
+		Report []map[string][]map[string]json.RawMessage `json:"report"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var rows []map[string]string
+	for _, entry := range raw.Report {
+		for _, rawRow := range entry[string(reportType)] {
+			row, err := reportRowToStrings(rawRow)
+			if err != nil {
+				return err
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	target := reflect.ValueOf(into)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return ErrInvalidReportTarget
+	}
+	target = target.Elem()
+
+	switch target.Kind() {
+	case reflect.Struct:
+		if len(rows) > 1 {
+			return ErrMultipleReportRowsFound
+		}
+		if len(rows) == 1 {
+			return DecodeReportRow(rows[0], target.Addr().Interface())
+		}
+		return nil
+	case reflect.Slice:
+		elemType := target.Type().Elem()
+		isPtr := elemType.Kind() == reflect.Ptr
+		if isPtr {
+			elemType = elemType.Elem()
+		}
+		if elemType.Kind() != reflect.Struct {
+			return ErrInvalidReportTarget
+		}
+		result := reflect.MakeSlice(target.Type(), 0, len(rows))
+		for _, row := range rows {
+			elem := reflect.New(elemType)
+			if err := DecodeReportRow(row, elem.Interface()); err != nil {
+				return err
+			}
+			if !isPtr {
+				elem = elem.Elem()
+			}
+			result = reflect.Append(result, elem)
+		}
+		target.Set(result)
+		return nil
+	default:
+		return ErrInvalidReportTarget
+	}
+}
+
+// DecodeReportRow decodes a single report row into the struct pointed to by into.
+// Struct fields are matched by their LVMConfigStructTag, fields without a value in the row are left untouched.
+//
+// Supported field types are:
+// - string and string based types
+// - bool, true if a binary field reports its name or 1
+// - int(8,16,32,64) and uint(8,16,32,64), sizes are converted to bytes
+// - float32 and float64, e.g. for percent fields
+// - []string and slices of string based types for string list fields
+// - Size, time.Time, LVAttributes, VGAttributes and PVAttributes
+// - pointers to the above and types implementing encoding.TextUnmarshaler
+func DecodeReportRow(row map[string]string, into any) error {
+	target := reflect.ValueOf(into)
+	if target.Kind() != reflect.Ptr || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return ErrInvalidReportTarget
+	}
+	target = target.Elem()
+
+	return walkReportFields(target.Type(), nil, func(name string, index []int) error {
+		raw, ok := row[name]
+		if !ok {
+			return nil
+		}
+		field, _ := LookupReportField(name, ReportTypeLV, ReportTypeVG, ReportTypePV, ReportTypePVSeg, ReportTypeSeg)
+		if err := setReportValue(target.FieldByIndex(index), name, field.ValueType, raw); err != nil {
+			return fmt.Errorf("failed to decode report field %q: %w", name, err)
+		}
+		return nil
+	})
+}
+
+func reportElemType(t reflect.Type) (reflect.Type, error) {
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, ErrInvalidReportTarget
+	}
+	return t, nil
+}
+
+func walkReportFields(t reflect.Type, index []int, fn func(name string, index []int) error) error {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		fieldIndex := append(append([]int{}, index...), i)
+		tag := field.Tag.Get(LVMConfigStructTag)
+		if tag == "-" || !field.IsExported() {
+			continue
+		}
+		if tag == "" {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := walkReportFields(field.Type, fieldIndex, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if err := fn(tag, fieldIndex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reportRowToStrings converts a json report row into its string values.
+// lvm2 reports all values as strings, except for the json_std format that uses numbers and arrays.
+func reportRowToStrings(rawRow map[string]json.RawMessage) (map[string]string, error) {
+	row := make(map[string]string, len(rawRow))
+	for key, raw := range rawRow {
+		raw = bytes.TrimSpace(raw)
+		switch {
+		case len(raw) > 0 && raw[0] == '"':
+			var str string
+			if err := json.Unmarshal(raw, &str); err != nil {
+				return nil, err
+			}
+			row[key] = str
+		case len(raw) > 0 && raw[0] == '[':
+			var list []string
+			if err := json.Unmarshal(raw, &list); err != nil {
+				return nil, err
+			}
+			row[key] = strings.Join(list, ",")
+		case string(raw) == "null":
+			row[key] = ""
+		default:
+			row[key] = string(raw)
+		}
+	}
+	return row, nil
+}
+
+func setReportValue(v reflect.Value, name string, valueType ReportValueType, raw string) error {
+	raw = strings.TrimSpace(raw)
+
+	if v.Kind() == reflect.Ptr {
+		if raw == "" {
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return setReportValue(v.Elem(), name, valueType, raw)
+	}
+
+	switch v.Type() {
+	case sizeType:
+		return setParsed(v, raw, ParseSizeLenient)
+	case timeType:
+		if raw == "" {
+			return nil
+		}
+		return setParsed(v, raw, func(raw string) (time.Time, error) {
+			return time.Parse(ReportTimeLayout, raw)
+		})
+	case lvAttributesType:
+		return setParsed(v, raw, ParseLVAttributes)
+	case vgAttributesType:
+		return setParsed(v, raw, ParseVGAttributes)
+	case pvAttributesType:
+		return setParsed(v, raw, ParsePVAttributes)
+	}
+
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(raw))
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		v.SetBool(parseBinary(name, raw))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if valueType == ReportValueTypeSize {
+			bytes, err := parseReportSizeInBytes(raw)
+			if err != nil {
+				return err
+			}
+			v.SetInt(int64(bytes))
+			return nil
+		}
+		if raw == "" {
+			return nil
+		}
+		i, err := strconv.ParseInt(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if valueType == ReportValueTypeSize {
+			bytes, err := parseReportSizeInBytes(raw)
+			if err != nil {
+				return err
+			}
+			v.SetUint(uint64(bytes))
+			return nil
+		}
+		if raw == "" {
+			return nil
+		}
+		u, err := strconv.ParseUint(raw, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		if valueType == ReportValueTypeSize {
+			bytes, err := parseReportSizeInBytes(raw)
+			if err != nil {
+				return err
+			}
+			v.SetFloat(bytes)
+			return nil
+		}
+		if raw == "" {
+			return nil
+		}
+		f, err := strconv.ParseFloat(strings.TrimSuffix(raw, "%"), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return fmt.Errorf("%w: %s", ErrUnsupportedReportValue, v.Type())
+		}
+		items := strings.FieldsFunc(raw, func(r rune) bool {
+			return r == ',' || r == ' '
+		})
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			list.Index(i).SetString(item)
+		}
+		v.Set(list)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportedReportValue, v.Type())
+	}
+	return nil
+}
+
+func setParsed[T any](v reflect.Value, raw string, parse func(string) (T, error)) error {
+	parsed, err := parse(raw)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(parsed))
+	return nil
+}
+
+func parseReportSizeInBytes(raw string) (float64, error) {
+	size, err := ParseSizeLenient(raw)
+	if err != nil {
+		return 0, err
+	}
+	if size.Unit == UnitUnknown {
+		return size.Val, nil
+	}
+	if size, err = size.ToUnit(UnitBytes); err != nil {
+		return 0, err
+	}
+	return size.Val, nil
+}
@@ -0,0 +1,236 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"slices"
+	"strings"
+)
+
+// ReportType is the type of object lvm2 reports on, as used by --configreport and in selection criteria.
+type ReportType string
+
+const (
+	ReportTypeVG    ReportType = "vg"
+	ReportTypePV    ReportType = "pv"
+	ReportTypeLV    ReportType = "lv"
+	ReportTypePVSeg ReportType = "pvseg"
+	ReportTypeSeg   ReportType = "seg"
+)
+
+// Prefix is the field name prefix lvm2 allows to omit for fields of the report type, e.g. "size" for "lv_size".
+func (t ReportType) Prefix() string {
+	return string(t) + "_"
+}
+
+// ReportValueType is the type of value lvm2 reports for a field.
+type ReportValueType string
+
+const (
+	ReportValueTypeString     ReportValueType = "string"
+	ReportValueTypeStringList ReportValueType = "string list"
+	ReportValueTypeNumber     ReportValueType = "number"
+	ReportValueTypeSize       ReportValueType = "size"
+	ReportValueTypePercent    ReportValueType = "percent"
+	ReportValueTypeTime       ReportValueType = "time"
+	ReportValueTypeBinary     ReportValueType = "binary"
+)
+
+// ReportField is a field lvm2 can report for a ReportType.
+type ReportField struct {
+	Name string
+	ReportType
+	ValueType ReportValueType
+}
+
+// reportFieldsByType are the fields lvm2 knows per report type and value type,
+// as listed by lvs -o help, vgs -o help and pvs -o help. TestReportFieldsMatchHelp verifies them against
+// the installed lvm2.
+var reportFieldsByType = map[ReportType]map[ReportValueType][]string{
+	ReportTypeLV: {
+		ReportValueTypeString: {
+			"lv_uuid", "lv_name", "lv_full_name", "lv_path", "lv_dm_path", "lv_parent", "lv_allocation_policy",
+			"lv_when_full", "lv_active", "origin", "origin_uuid", "raid_sync_action", "raidintegritymode", "move_pv",
+			"move_pv_uuid", "convert_lv", "convert_lv_uuid", "mirror_log", "mirror_log_uuid", "data_lv",
+			"data_lv_uuid", "metadata_lv", "metadata_lv_uuid", "pool_lv", "pool_lv_uuid", "lv_profile",
+			"lv_lockargs", "lv_host", "lv_permissions", "kernel_cache_policy", "kernel_metadata_format",
+			"lv_health_status", "kernel_discards", "vdo_operating_mode", "vdo_compression_state",
+			"vdo_index_state", "lv_attr",
+		},
+		ReportValueTypeStringList: {
+			"lv_layout", "lv_role", "lv_ancestors", "lv_full_ancestors", "lv_descendants", "lv_full_descendants",
+			"lv_tags", "lv_modules", "kernel_cache_settings",
+		},
+		ReportValueTypeBinary: {
+			"lv_initial_image_sync", "lv_image_synced", "lv_merging", "lv_converting", "lv_allocation_locked",
+			"lv_fixed_minor", "lv_skip_activation", "lv_autoactivation", "lv_active_locally", "lv_active_remotely",
+			"lv_active_exclusively", "lv_historical", "lv_suspended", "lv_live_table", "lv_inactive_table",
+			"lv_device_open", "lv_check_needed", "lv_merge_failed", "lv_snapshot_invalid",
+		},
+		ReportValueTypeNumber: {
+			"lv_major", "lv_minor", "seg_count", "raid_mismatch_count", "raid_write_behind",
+			"raid_min_recovery_rate", "raid_max_recovery_rate", "raidintegrityblocksize", "integritymismatches",
+			"writecache_block_size", "lv_kernel_major", "lv_kernel_minor", "cache_total_blocks",
+			"cache_used_blocks", "cache_dirty_blocks", "cache_read_hits", "cache_read_misses", "cache_write_hits",
+			"cache_write_misses", "writecache_total_blocks", "writecache_free_blocks",
+			"writecache_writeback_blocks", "writecache_error",
+		},
+		ReportValueTypeSize: {
+			"lv_read_ahead", "lv_size", "lv_metadata_size", "origin_size", "lv_kernel_read_ahead", "vdo_used_size",
+		},
+		ReportValueTypePercent: {
+			"data_percent", "snap_percent", "metadata_percent", "copy_percent", "sync_percent",
+			"vdo_saving_percent",
+		},
+		ReportValueTypeTime: {
+			"lv_time", "lv_time_removed",
+		},
+	},
+	ReportTypeVG: {
+		ReportValueTypeString: {
+			"vg_fmt", "vg_uuid", "vg_name", "vg_attr", "vg_permissions", "vg_allocation_policy", "vg_sysid",
+			"vg_systemid", "vg_lock_type", "vg_lock_args", "vg_profile",
+		},
+		ReportValueTypeStringList: {
+			"vg_tags",
+		},
+		ReportValueTypeBinary: {
+			"vg_extendable", "vg_exported", "vg_autoactivation", "vg_partial", "vg_clustered", "vg_shared",
+		},
+		ReportValueTypeNumber: {
+			"vg_extent_count", "vg_free_count", "max_lv", "max_pv", "pv_count", "vg_missing_pv_count", "lv_count",
+			"snap_count", "vg_seqno", "vg_mda_count", "vg_mda_used_count", "vg_mda_copies",
+		},
+		ReportValueTypeSize: {
+			"vg_size", "vg_free", "vg_extent_size", "vg_mda_free", "vg_mda_size",
+		},
+	},
+	ReportTypePV: {
+		ReportValueTypeString: {
+			"pv_fmt", "pv_uuid", "pv_name", "pv_attr", "pv_device_id", "pv_device_id_type",
+		},
+		ReportValueTypeStringList: {
+			"pv_tags",
+		},
+		ReportValueTypeBinary: {
+			"pv_allocatable", "pv_exported", "pv_missing", "pv_in_use", "pv_duplicate",
+		},
+		ReportValueTypeNumber: {
+			"pv_major", "pv_minor", "pv_ext_vsn", "pv_pe_count", "pv_pe_alloc_count", "pv_mda_count",
+			"pv_mda_used_count",
+		},
+		ReportValueTypeSize: {
+			"dev_size", "pv_mda_free", "pv_mda_size", "pe_start", "pv_size", "pv_free", "pv_used", "pv_ba_start",
+			"pv_ba_size",
+		},
+	},
+	ReportTypePVSeg: {
+		ReportValueTypeNumber: {
+			"pvseg_start", "pvseg_size",
+		},
+	},
+	ReportTypeSeg: {
+		ReportValueTypeString: {
+			"segtype", "discards", "cache_metadata_format", "cache_mode", "seg_monitor", "cache_policy",
+			"vdo_write_policy",
+		},
+		ReportValueTypeStringList: {
+			"seg_tags", "seg_pe_ranges", "seg_le_ranges", "seg_metadata_le_ranges", "devices", "metadata_devices",
+			"cache_settings", "integrity_settings",
+		},
+		ReportValueTypeBinary: {
+			"zero", "vdo_compression", "vdo_deduplication", "vdo_use_metadata_hints", "vdo_use_sparse_index",
+		},
+		ReportValueTypeNumber: {
+			"stripes", "data_stripes", "reshape_len_le", "data_copies", "parity_chunks", "thin_count",
+			"transaction_id", "thin_id", "seg_start_pe", "seg_size_pe", "vdo_block_map_era_length",
+			"vdo_ack_threads", "vdo_bio_threads", "vdo_bio_rotation", "vdo_cpu_threads", "vdo_hash_zone_threads",
+			"vdo_logical_threads", "vdo_physical_threads",
+		},
+		ReportValueTypeSize: {
+			"reshape_len", "data_offset", "new_data_offset", "stripe_size", "region_size", "chunk_size",
+			"seg_start", "seg_size", "vdo_minimum_io_size", "vdo_block_map_cache_size", "vdo_index_memory_size",
+			"vdo_slab_size", "vdo_max_discard", "vdo_header_size",
+		},
+	},
+}
+
+var (
+	// LVsReportTypes are the report types whose fields can be used with lvs.
+	LVsReportTypes = []ReportType{ReportTypeLV, ReportTypeVG, ReportTypeSeg}
+	// VGsReportTypes are the report types whose fields can be used with vgs.
+	VGsReportTypes = []ReportType{ReportTypeVG}
+	// PVsReportTypes are the report types whose fields can be used with pvs.
+	PVsReportTypes = []ReportType{ReportTypePV, ReportTypeVG, ReportTypePVSeg}
+)
+
+// ReportFields are the field names lvm2 knows per report type, as listed by lvs -o help, vgs -o help and pvs -o help.
+var ReportFields = func() map[ReportType][]string {
+	fields := make(map[ReportType][]string, len(reportFieldsByType))
+	for t := range reportFieldsByType {
+		for _, field := range ReportFieldsOf(t) {
+			fields[t] = append(fields[t], field.Name)
+		}
+	}
+	return fields
+}()
+
+// ReportFieldsOf returns the fields known for the given report types, sorted by name per report type.
+func ReportFieldsOf(types ...ReportType) []ReportField {
+	var fields []ReportField
+	for _, t := range types {
+		var ofType []ReportField
+		for valueType, names := range reportFieldsByType[t] {
+			for _, name := range names {
+				ofType = append(ofType, ReportField{Name: name, ReportType: t, ValueType: valueType})
+			}
+		}
+		slices.SortFunc(ofType, func(a, b ReportField) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+		fields = append(fields, ofType...)
+	}
+	return fields
+}
+
+// LookupReportField returns the field for the first of the given report types it belongs to.
+// Like lvm2, it accepts fields without the prefix of their report type, e.g. "size" for "lv_size".
+func LookupReportField(name string, types ...ReportType) (ReportField, bool) {
+	name = strings.ToLower(name)
+	for _, t := range types {
+		for valueType, names := range reportFieldsByType[t] {
+			for _, candidate := range names {
+				if candidate == name || candidate == t.Prefix()+name {
+					return ReportField{Name: candidate, ReportType: t, ValueType: valueType}, true
+				}
+			}
+		}
+	}
+	return ReportField{}, false
+}
+
+// ResolveReportField returns the full name of the field for the first of the given report types it belongs to.
+func ResolveReportField(field string, types ...ReportType) (string, bool) {
+	resolved, ok := LookupReportField(field, types...)
+	return resolved.Name, ok
+}
+
+// IsReportField returns true if the field is known for one of the given report types.
+func IsReportField(name string, types ...ReportType) bool {
+	_, ok := LookupReportField(name, types...)
+	return ok
+}
@@ -0,0 +1,263 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+type reportedVolume struct {
+	Name      LogicalVolumeName `lvm:"lv_name"`
+	VG        VolumeGroupName   `lvm:"vg_name"`
+	Size      Size              `lvm:"lv_size"`
+	SizeBytes uint64            `lvm:"origin_size"`
+	Active    bool              `lvm:"lv_active_locally"`
+	Data      *float64          `lvm:"data_percent"`
+	Tags      []string          `lvm:"lv_tags"`
+	Created   time.Time         `lvm:"lv_time"`
+	Attr      LVAttributes      `lvm:"lv_attr"`
+	Ignored   string            `lvm:"-"`
+}
+
+func TestDecodeReport(t *testing.T) {
+	t.Parallel()
+	data := []byte(`{"report":[{"lv":[
+{"lv_name":"lv1","vg_name":"vg","lv_size":"1073741824B","origin_size":"4194304B","lv_active_locally":"active locally",
+"data_percent":"12.50","lv_tags":"a,b","lv_time":"2024-07-16 10:21:01 +0200","lv_attr":"-wi-a-----"},
+{"lv_name":"lv2","vg_name":"vg","lv_size":"","origin_size":"","lv_active_locally":"","data_percent":"","lv_tags":"","lv_time":"","lv_attr":"-wi-------"},
+{"lv_name":"lv3","vg_name":"vg","lv_active_locally":"unknown","lv_attr":"-wi-------"}
+]}]}`)
+
+	var volumes []*reportedVolume
+	if err := DecodeReport(data, ReportTypeLV, &volumes); err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 3 {
+		t.Fatalf("expected 3 volumes, got %d", len(volumes))
+	}
+
+	lv1, lv2, lv3 := volumes[0], volumes[1], volumes[2]
+	if lv1.Name != "lv1" || lv1.VG != "vg" {
+		t.Fatalf("unexpected names %q/%q", lv1.VG, lv1.Name)
+	}
+	if lv1.Size != NewSize(1073741824, UnitBytes) {
+		t.Fatalf("unexpected size %v", lv1.Size)
+	}
+	if lv1.SizeBytes != 4194304 {
+		t.Fatalf("unexpected size in bytes %d", lv1.SizeBytes)
+	}
+	if !lv1.Active || lv2.Active || lv3.Active {
+		t.Fatalf("unexpected binary values %v, %v and %v", lv1.Active, lv2.Active, lv3.Active)
+	}
+	if lv1.Data == nil || *lv1.Data != 12.5 || lv2.Data != nil {
+		t.Fatalf("unexpected percent values %v and %v", lv1.Data, lv2.Data)
+	}
+	if !slices.Equal(lv1.Tags, []string{"a", "b"}) || len(lv2.Tags) != 0 {
+		t.Fatalf("unexpected tags %v and %v", lv1.Tags, lv2.Tags)
+	}
+	if !lv1.Created.Equal(time.Date(2024, 7, 16, 8, 21, 1, 0, time.UTC)) || !lv2.Created.IsZero() {
+		t.Fatalf("unexpected times %v and %v", lv1.Created, lv2.Created)
+	}
+	if lv1.Attr.State != StateActive || lv2.Attr.State == StateActive {
+		t.Fatalf("unexpected attributes %v and %v", lv1.Attr, lv2.Attr)
+	}
+}
+
+func TestDecodeReport_SingleStruct(t *testing.T) {
+	t.Parallel()
+	var vg struct {
+		Name    VolumeGroupName `lvm:"vg_name"`
+		Extents int64           `lvm:"vg_extent_count"`
+		Free    int64           `lvm:"vg_free"`
+	}
+	data := []byte(`{"report":[{"vg":[{"vg_name":"vg","vg_extent_count":"256","vg_free":"4.00m"}]}]}`)
+	if err := DecodeReport(data, ReportTypeVG, &vg); err != nil {
+		t.Fatal(err)
+	}
+	if vg.Name != "vg" || vg.Extents != 256 || vg.Free != 4*1024*1024 {
+		t.Fatalf("unexpected volume group %+v", vg)
+	}
+
+	data = []byte(`{"report":[{"vg":[{"vg_name":"vg1"},{"vg_name":"vg2"}]}]}`)
+	if err := DecodeReport(data, ReportTypeVG, &vg); !errors.Is(err, ErrMultipleReportRowsFound) {
+		t.Fatalf("expected %v, got %v", ErrMultipleReportRowsFound, err)
+	}
+}
+
+func TestReportColumns(t *testing.T) {
+	t.Parallel()
+	columns, err := ReportColumns([]reportedVolume{}, LVsReportTypes...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ColumnOptions{"lv_name", "vg_name", "lv_size", "origin_size", "lv_active_locally",
+		"data_percent", "lv_tags", "lv_time", "lv_attr"}
+	if !slices.Equal(columns, want) {
+		t.Fatalf("expected %v, got %v", want, columns)
+	}
+
+	if _, err := ReportColumns(&reportedVolume{}, VGsReportTypes...); !errors.Is(err, ErrUnknownReportField) {
+		t.Fatalf("expected %v, got %v", ErrUnknownReportField, err)
+	}
+	if _, err := ReportColumns("lv_name"); !errors.Is(err, ErrInvalidReportTarget) {
+		t.Fatalf("expected %v, got %v", ErrInvalidReportTarget, err)
+	}
+}
+
+func TestLookupReportField(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name  string
+		types []ReportType
+		want  ReportField
+		found bool
+	}{
+		{"lv_size", LVsReportTypes, ReportField{Name: "lv_size", ReportType: ReportTypeLV, ValueType: ReportValueTypeSize}, true},
+		{"size", LVsReportTypes, ReportField{Name: "lv_size", ReportType: ReportTypeLV, ValueType: ReportValueTypeSize}, true},
+		{"VG_FREE", LVsReportTypes, ReportField{Name: "vg_free", ReportType: ReportTypeVG, ValueType: ReportValueTypeSize}, true},
+		{"lv_tags", VGsReportTypes, ReportField{}, false},
+		{"pvseg_start", PVsReportTypes, ReportField{Name: "pvseg_start", ReportType: ReportTypePVSeg, ValueType: ReportValueTypeNumber}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, found := LookupReportField(tc.name, tc.types...)
+			if found != tc.found || got != tc.want {
+				t.Fatalf("LookupReportField() = %v, %v, want %v, %v", got, found, tc.want, tc.found)
+			}
+			if name, found := ResolveReportField(tc.name, tc.types...); found != tc.found || name != tc.want.Name {
+				t.Fatalf("ResolveReportField() = %v, %v, want %v, %v", name, found, tc.want.Name, tc.found)
+			}
+		})
+	}
+}
+
+// TestReportFieldsMatchHelp verifies the report field registry against the fields listed by lvs, vgs and pvs -o help.
+func TestReportFieldsMatchHelp(t *testing.T) {
+	t.Parallel()
+	if _, err := exec.LookPath(GetLVMPath()); err != nil {
+		t.Skip("Skipping test because lvm command is not found")
+	}
+	ctx := context.Background()
+
+	listed := make(map[ReportType]map[string]bool)
+	for _, cmd := range []string{"lvs", "vgs", "pvs"} {
+		// lvm2 prints the help to stderr and may exit with a non-zero status.
+		out, _ := CommandContext(ctx, GetLVMPath(), cmd, "-o", "help").CombinedOutput()
+		for reportType, fields := range parseReportFieldsHelp(string(out)) {
+			if listed[reportType] == nil {
+				listed[reportType] = make(map[string]bool)
+			}
+			for _, field := range fields {
+				listed[reportType][field] = true
+			}
+		}
+	}
+	if len(listed) == 0 {
+		t.Fatal("no report fields found in -o help output")
+	}
+
+	for reportType, fields := range ReportFields {
+		for _, field := range fields {
+			if !listed[reportType][field] {
+				t.Errorf("%s field %s is not listed by lvm2", reportType, field)
+			}
+		}
+	}
+	for reportType, fields := range listed {
+		for field := range fields {
+			if !slices.Contains(ReportFields[reportType], field) {
+				t.Errorf("%s field %s listed by lvm2 is missing in ReportFields", reportType, field)
+			}
+		}
+	}
+}
+
+// parseReportFieldsHelp parses the sections of -o help, e.g. "Logical Volume Fields", followed by
+// lines of fields and their description, e.g. "lv_uuid - Unique identifier.".
+func parseReportFieldsHelp(out string) map[ReportType][]string {
+	fields := make(map[ReportType][]string)
+	var section ReportType
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasSuffix(line, "Fields") {
+			switch {
+			case strings.Contains(line, "Logical Volume Segment"):
+				section = ReportTypeSeg
+			case strings.Contains(line, "Physical Volume Segment"):
+				section = ReportTypePVSeg
+			case strings.Contains(line, "Logical Volume"):
+				section = ReportTypeLV
+			case strings.Contains(line, "Physical Volume"):
+				section = ReportTypePV
+			case strings.Contains(line, "Volume Group"):
+				section = ReportTypeVG
+			default:
+				section = ""
+			}
+			continue
+		}
+		name, _, ok := strings.Cut(line, " - ")
+		name = strings.TrimSpace(name)
+		if !ok || section == "" || strings.HasSuffix(name, "_all") || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		fields[section] = append(fields[section], name)
+	}
+	return fields
+}
+
+func TestReportInto(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+
+	clnt := NewClient()
+	ctx := context.Background()
+
+	test := test{
+		LoopDevices: []Size{
+			MustParseSize("10M"),
+		},
+		Volumes: []TestLogicalVolume{{
+			Options: LVCreateOptionList{
+				MustParseExtents("100%FREE"),
+			},
+		}},
+	}
+
+	infra := test.SetupDevicesAndVolumeGroup(t)
+
+	var volumes []reportedVolume
+	if err := clnt.ReportInto(ctx, &volumes, ReportTypeLV,
+		MustNewSelect(Field("vg_name").Eq(infra.volumeGroup.Name), LVsReportTypes...),
+	); err != nil {
+		t.Fatal(err)
+	}
+	if len(volumes) != 1 {
+		t.Fatalf("expected 1 volume, got %d", len(volumes))
+	}
+	if volumes[0].Size.Val == 0 || volumes[0].Size.Unit != UnitBytes {
+		t.Fatalf("expected size in bytes, got %v", volumes[0].Size)
+	}
+}
@@ -0,0 +1,282 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strconv"
+)
+
+var (
+	ErrFileSystemUnsupported       = errors.New("filesystem is not supported for resizing")
+	ErrFileSystemShrinkUnsupported = errors.New("filesystem cannot be shrunk")
+	ErrFileSystemMounted           = errors.New("filesystem must not be mounted")
+	ErrFileSystemNotMounted        = errors.New("filesystem must be mounted read-write")
+)
+
+// Filesystem tools used by GrowFileSystem and ShrinkFileSystem.
+const (
+	ToolE2FSCK    Tool = "e2fsck"
+	ToolResize2FS Tool = "resize2fs"
+	ToolXFSGrowFS Tool = "xfs_growfs"
+	ToolBtrfs     Tool = "btrfs"
+)
+
+// ResizeFS resizes the filesystem together with the logical volume, it is equivalent to ResizeFSModeResize.
+type ResizeFS bool
+
+func (opt ResizeFS) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.ResizeFS = opt
+}
+
+func (opt ResizeFS) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.ResizeFS = opt
+}
+
+func (opt ResizeFS) ApplyToLVReduceOptions(opts *LVReduceOptions) {
+	opts.ResizeFS = opt
+}
+
+func (opt ResizeFS) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplaceAll([]string{"--resizefs"})
+	}
+	return nil
+}
+
+// ResizeFSMode decides how the filesystem on a logical volume is handled when it is resized.
+type ResizeFSMode string
+
+const (
+	// ResizeFSModeIgnore resizes the logical volume regardless of its filesystem.
+	ResizeFSModeIgnore ResizeFSMode = "ignore"
+	// ResizeFSModeCheckSize refuses to reduce the logical volume below the size of its filesystem.
+	ResizeFSModeCheckSize ResizeFSMode = "checksize"
+	// ResizeFSModeResize resizes the filesystem with the tools of the filesystem.
+	ResizeFSModeResize ResizeFSMode = "resize"
+	// ResizeFSModeResizeFSAdm resizes the filesystem with fsadm.
+	ResizeFSModeResizeFSAdm ResizeFSMode = "resize_fsadm"
+	// ResizeFSModeGo is not passed to lvm2. The logical volume is resized without --fs and the filesystem
+	// is resized with GrowFileSystem and ShrinkFileSystem instead, as a fallback for lvm2 versions without --fs.
+	ResizeFSModeGo ResizeFSMode = "go"
+)
+
+func (opt ResizeFSMode) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.ResizeFSMode = opt
+}
+
+func (opt ResizeFSMode) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.ResizeFSMode = opt
+}
+
+func (opt ResizeFSMode) ApplyToLVReduceOptions(opts *LVReduceOptions) {
+	opts.ResizeFSMode = opt
+}
+
+func (opt ResizeFSMode) ApplyToArgs(args Arguments) error {
+	switch opt {
+	case "", ResizeFSModeGo:
+		return nil
+	case ResizeFSModeIgnore, ResizeFSModeCheckSize, ResizeFSModeResize, ResizeFSModeResizeFSAdm:
+		args.AddOrReplace(fmt.Sprintf("--fs=%s", opt))
+		return nil
+	}
+	return fmt.Errorf("invalid filesystem resize mode %q", opt)
+}
+
+// validateResizeFS rejects ResizeFS combined with a ResizeFSMode other than ResizeFSModeResize.
+func validateResizeFS(resizeFS ResizeFS, mode ResizeFSMode) error {
+	if resizeFS && mode != "" && mode != ResizeFSModeResize {
+		return fmt.Errorf("ResizeFS and ResizeFSMode %q are mutually exclusive", mode)
+	}
+	return nil
+}
+
+// fileSystem is a filesystem on a device together with the mounts of the device.
+type fileSystem struct {
+	Signature
+	device string
+	mounts MountInfos
+}
+
+// writableMountPoint returns the first read-write mount point of the filesystem.
+func (fs *fileSystem) writableMountPoint() (string, error) {
+	for _, mount := range fs.mounts {
+		if !mount.IsReadOnly() {
+			return mount.MountPoint, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s on %s can only be resized online", ErrFileSystemNotMounted, fs.Signature, fs.device)
+}
+
+func (fs *fileSystem) isExt() bool {
+	return slices.Contains([]Signature{SignatureExt2, SignatureExt3, SignatureExt4}, fs.Signature)
+}
+
+// inspectFileSystem probes the filesystem on the device and reads its mounts with ReadMountInfo.
+// It returns nil if the device does not contain any signature.
+func inspectFileSystem(ctx context.Context, device string) (*fileSystem, error) {
+	signatures, err := ProbeSignaturesFromPath(device)
+	if err != nil {
+		return nil, fmt.Errorf("failed to probe filesystem on %s: %w", device, err)
+	}
+	if len(signatures) == 0 {
+		return nil, nil
+	}
+	fs := &fileSystem{device: device}
+	for _, signature := range signatures {
+		switch signature {
+		case SignatureExt2, SignatureExt3, SignatureExt4, SignatureXFS, SignatureBtrfs:
+			fs.Signature = signature
+		}
+	}
+	if fs.Signature == "" {
+		return nil, fmt.Errorf("%w: %v on %s", ErrFileSystemUnsupported, signatures, device)
+	}
+
+	// Image files are not tracked by their device number in mountinfo, they cannot be mounted without a loop device.
+	if info, err := os.Stat(device); err != nil {
+		return nil, err
+	} else if info.Mode()&os.ModeDevice == 0 {
+		return fs, nil
+	}
+	major, minor, err := DeviceNumber(device)
+	if err != nil {
+		return nil, err
+	}
+	mounts, err := ReadMountInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mount state of %s: %w", device, err)
+	}
+	fs.mounts = mounts.OfDevice(major, minor)
+	return fs, nil
+}
+
+// GrowFileSystem grows the ext2, ext3, ext4, xfs or btrfs filesystem on the device to the size of the device.
+// ext filesystems are grown online if mounted and checked with e2fsck before they are grown offline,
+// xfs and btrfs filesystems can only be grown if mounted read-write. A device without a signature is ignored.
+func GrowFileSystem(ctx context.Context, device string) error {
+	fs, err := inspectFileSystem(ctx, device)
+	if err != nil || fs == nil {
+		return err
+	}
+
+	switch {
+	case fs.isExt():
+		if len(fs.mounts) == 0 {
+			if err := checkExtFileSystem(ctx, device); err != nil {
+				return err
+			}
+		}
+		return runFileSystemTool(ctx, ToolResize2FS, device)
+	case fs.Signature == SignatureXFS:
+		mountPoint, err := fs.writableMountPoint()
+		if err != nil {
+			return err
+		}
+		return runFileSystemTool(ctx, ToolXFSGrowFS, mountPoint)
+	default:
+		mountPoint, err := fs.writableMountPoint()
+		if err != nil {
+			return err
+		}
+		return runFileSystemTool(ctx, ToolBtrfs, "filesystem", "resize", "max", mountPoint)
+	}
+}
+
+// ShrinkFileSystem shrinks the filesystem on the device to the size before the device itself is reduced.
+// ext filesystems can only be shrunk offline and are checked with e2fsck first, btrfs filesystems can only
+// be shrunk online and xfs filesystems cannot be shrunk at all. A device without a signature is ignored.
+func ShrinkFileSystem(ctx context.Context, device string, size Size) error {
+	bytes, err := size.ToUnit(UnitBytes)
+	if err != nil {
+		return err
+	}
+	if bytes.Val <= 0 {
+		return fmt.Errorf("cannot shrink filesystem on %s to %s", device, size)
+	}
+
+	fs, err := inspectFileSystem(ctx, device)
+	if err != nil || fs == nil {
+		return err
+	}
+
+	switch {
+	case fs.isExt():
+		if len(fs.mounts) > 0 {
+			return fmt.Errorf("%w: %s on %s is mounted at %s and can only be shrunk offline",
+				ErrFileSystemMounted, fs.Signature, device, fs.mounts[0].MountPoint)
+		}
+		if err := checkExtFileSystem(ctx, device); err != nil {
+			return err
+		}
+		return runFileSystemTool(ctx, ToolResize2FS, device, strconv.FormatInt(int64(bytes.Val)/1024, 10)+"K")
+	case fs.Signature == SignatureBtrfs:
+		mountPoint, err := fs.writableMountPoint()
+		if err != nil {
+			return err
+		}
+		return runFileSystemTool(ctx, ToolBtrfs, "filesystem", "resize", strconv.FormatInt(int64(bytes.Val), 10), mountPoint)
+	default:
+		return fmt.Errorf("%w: %s on %s", ErrFileSystemShrinkUnsupported, fs.Signature, device)
+	}
+}
+
+// resizeFileSystemTarget returns whether resizing the device by size shrinks it and the size after the resize.
+// Sizes without a prefix are absolute and compared to the current size of the device.
+func resizeFileSystemTarget(device string, size PrefixedSize) (bool, Size, error) {
+	if size.SizePrefix == SizePrefixPlus {
+		return false, Size{}, nil
+	}
+	bytes, err := size.Size.ToUnit(UnitBytes)
+	if err != nil {
+		return false, Size{}, fmt.Errorf("%s requires a size with a unit: %w", ResizeFSModeGo, err)
+	}
+	current, err := deviceSize(device)
+	if err != nil {
+		return false, Size{}, fmt.Errorf("failed to read size of %s: %w", device, err)
+	}
+	target := bytes.Val
+	if size.SizePrefix == SizePrefixMinus {
+		target = float64(current) - bytes.Val
+	}
+	return target < float64(current), NewSize(target, UnitBytes), nil
+}
+
+// checkExtFileSystem runs a forced e2fsck, which resize2fs requires before offline resizes.
+// The exit code 1 signals that errors were corrected and is not treated as failure.
+func checkExtFileSystem(ctx context.Context, device string) error {
+	err := runFileSystemTool(ctx, ToolE2FSCK, "-f", "-p", device)
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return nil
+	}
+	return err
+}
+
+func runFileSystemTool(ctx context.Context, tool Tool, args ...string) error {
+	cmd := CommandContext(ctx, GetToolPath(tool), args...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", tool, err, out)
+	}
+	return nil
+}
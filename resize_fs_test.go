@@ -0,0 +1,156 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestResizeFSArgs(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name     string
+		args     ArgumentGenerator
+		expected []string
+		err      bool
+	}{
+		{
+			name:     "extend with resizefs",
+			args:     LVExtendOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("+1G"), ResizeFS(true)},
+			expected: []string{"vg/lv", "--size=+1.00g", "--resizefs", "--yes"},
+		},
+		{
+			name:     "resize with fs mode",
+			args:     LVResizeOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("2G"), ResizeFSModeResizeFSAdm},
+			expected: []string{"vg/lv", "--size=2.00g", "--fs=resize_fsadm", "--yes"},
+		},
+		{
+			name:     "go mode is not passed to lvm",
+			args:     LVExtendOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedExtents("+100%FREE"), ResizeFSModeGo},
+			expected: []string{"vg/lv", "--extents=+100%FREE", "--yes"},
+		},
+		{
+			name:     "reduce with checksize",
+			args:     LVReduceOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("-512M"), ResizeFSModeCheckSize},
+			expected: []string{"vg/lv", "--size=-512.00m", "--fs=checksize", "--yes"},
+		},
+		{
+			name: "reduce with positive size",
+			args: LVReduceOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("+512M")},
+			err:  true,
+		},
+		{
+			name: "reduce without size",
+			args: LVReduceOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv")},
+			err:  true,
+		},
+		{
+			name: "resizefs conflicts with ignore",
+			args: LVExtendOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("+1G"), ResizeFS(true), ResizeFSModeIgnore},
+			err:  true,
+		},
+		{
+			name: "invalid fs mode",
+			args: LVResizeOptionsList{VolumeGroupName("vg"), LogicalVolumeName("lv"), MustParsePrefixedSize("2G"), ResizeFSMode("grow")},
+			err:  true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.args.AsArgs()
+			if tc.err {
+				if err == nil {
+					t.Fatalf("expected error, got args %v", args.GetRaw())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.expected) {
+				t.Fatalf("expected args %v, got %v", tc.expected, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestParseMountInfo(t *testing.T) {
+	t.Parallel()
+	mounts, err := ParseMountInfo(strings.NewReader(
+		"22 1 253:0 / / rw,relatime shared:1 - xfs /dev/mapper/vg-root rw,attr2\n" +
+			"36 22 253:3 / /mnt/with\\040space ro,noatime master:1 - ext4 /dev/mapper/vg-data rw,errors=continue\n" +
+			"37 22 253:3 /sub /srv rw - ext4 /dev/mapper/vg-data rw\n" +
+			"38 22 0:45 / /mnt/btrfs rw - btrfs /dev/null rw,space_cache=v2\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mounts) != 4 {
+		t.Fatalf("expected 4 mounts, got %d", len(mounts))
+	}
+	data := mounts.OfDevice(253, 3)
+	if len(data) != 2 || data[0].MountPoint != "/mnt/with space" || data[0].FSType != "ext4" ||
+		data[0].Source != "/dev/mapper/vg-data" || !data[0].IsReadOnly() || data[1].IsReadOnly() || data[1].Root != "/sub" {
+		t.Fatalf("unexpected mounts of 253:3: %+v", data)
+	}
+	// btrfs reports an anonymous device number, the mount is found by the device number of its source.
+	if major, minor, err := DeviceNumber("/dev/null"); err == nil {
+		if btrfs := mounts.OfDevice(major, minor); len(btrfs) != 1 || btrfs[0].MountPoint != "/mnt/btrfs" {
+			t.Fatalf("expected btrfs mount of /dev/null, got %+v", btrfs)
+		}
+	}
+	if _, err := ParseMountInfo(strings.NewReader("22 1 253:0 / / rw\n")); err == nil {
+		t.Fatal("expected error for line without separator")
+	}
+}
+
+func TestResizeFileSystemRefusal(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	image := func(t *testing.T, magic string) string {
+		t.Helper()
+		path := filepath.Join(t.TempDir(), "image")
+		content := make([]byte, 128*1024)
+		copy(content, magic)
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	xfs := image(t, "XFSB")
+	if err := ShrinkFileSystem(ctx, xfs, MustParseSize("64K")); !errors.Is(err, ErrFileSystemShrinkUnsupported) {
+		t.Fatalf("expected xfs shrink to be refused, got %v", err)
+	}
+	if err := GrowFileSystem(ctx, xfs); !errors.Is(err, ErrFileSystemNotMounted) {
+		t.Fatalf("expected unmounted xfs grow to be refused, got %v", err)
+	}
+	if err := GrowFileSystem(ctx, image(t, "LUKS\xba\xbe")); !errors.Is(err, ErrFileSystemUnsupported) {
+		t.Fatalf("expected luks grow to be refused, got %v", err)
+	}
+	if err := GrowFileSystem(ctx, image(t, "")); err != nil {
+		t.Fatalf("expected device without filesystem to be ignored, got %v", err)
+	}
+}
@@ -0,0 +1,469 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LVSegment is a segment of a logical volume as reported by lvs --segments.
+type LVSegment struct {
+	LogicalVolumeUUID string            `json:"lv_uuid"`
+	LogicalVolumeName LogicalVolumeName `json:"lv_name"`
+	VolumeGroupName   VolumeGroupName   `json:"vg_name"`
+
+	Type       Type       `json:"segtype"`
+	Start      Size       `json:"seg_start"`
+	StartPE    int64      `json:"seg_start_pe"`
+	Size       Size       `json:"seg_size"`
+	SizePE     int64      `json:"seg_size_pe"`
+	Stripes    int64      `json:"stripes"`
+	StripeSize Size       `json:"stripe_size"`
+	PERanges   PERanges   `json:"seg_pe_ranges"`
+	Devices    SegDevices `json:"devices"`
+	// MetadataDevices are the metadata sub volumes of the segment,
+	// e.g. the _tmeta of a thin pool, the _rmeta_N of a RAID or the _cmeta of a cache pool.
+	MetadataDevices SegDevices `json:"metadata_devices"`
+}
+
+func (seg *LVSegment) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for key, fieldPtr := range map[string]*string{
+		"lv_uuid": &seg.LogicalVolumeUUID,
+		"lv_name": (*string)(&seg.LogicalVolumeName),
+		"vg_name": (*string)(&seg.VolumeGroupName),
+		"segtype": (*string)(&seg.Type),
+	} {
+		if val, ok := raw[key]; !ok {
+			continue
+		} else if err := json.Unmarshal(val, fieldPtr); err != nil {
+			return err
+		}
+	}
+
+	for key, fieldPtr := range map[string]*int64{
+		"seg_start_pe": &seg.StartPE,
+		"seg_size_pe":  &seg.SizePE,
+		"stripes":      &seg.Stripes,
+	} {
+		if err := unmarshalToStringAndParseInt64(raw, key, fieldPtr); err != nil {
+			return err
+		}
+	}
+
+	for key, fieldPtr := range map[string]*Size{
+		"seg_start":   &seg.Start,
+		"seg_size":    &seg.Size,
+		"stripe_size": &seg.StripeSize,
+	} {
+		if err := unmarshalToStringAndParse(raw, key, fieldPtr, ParseSizeLenient); err != nil {
+			return err
+		}
+	}
+
+	if err := unmarshalToStringAndParse(raw, "seg_pe_ranges", &seg.PERanges, ParsePERanges); err != nil {
+		return err
+	}
+
+	for key, fieldPtr := range map[string]*SegDevices{
+		"devices":          &seg.Devices,
+		"metadata_devices": &seg.MetadataDevices,
+	} {
+		if err := unmarshalToStringAndParse(raw, key, fieldPtr, ParseSegDevices); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// PVSegment is a segment of a physical volume as reported by pvs --segments.
+// Segments that are not allocated to a logical volume have an empty LogicalVolumeName.
+type PVSegment struct {
+	PhysicalVolumeUUID string             `json:"pv_uuid"`
+	PhysicalVolumeName PhysicalVolumeName `json:"pv_name"`
+	VolumeGroupName    VolumeGroupName    `json:"vg_name"`
+
+	// Start is the physical extent the segment starts at.
+	Start int64 `json:"pvseg_start"`
+	// Size is the number of physical extents in the segment.
+	Size int64 `json:"pvseg_size"`
+
+	LogicalVolumeUUID string            `json:"lv_uuid"`
+	LogicalVolumeName LogicalVolumeName `json:"lv_name"`
+	Type              Type              `json:"segtype"`
+}
+
+func (seg *PVSegment) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	for key, fieldPtr := range map[string]*string{
+		"pv_uuid": &seg.PhysicalVolumeUUID,
+		"pv_name": (*string)(&seg.PhysicalVolumeName),
+		"vg_name": (*string)(&seg.VolumeGroupName),
+		"lv_uuid": &seg.LogicalVolumeUUID,
+		"lv_name": (*string)(&seg.LogicalVolumeName),
+		"segtype": (*string)(&seg.Type),
+	} {
+		if val, ok := raw[key]; !ok {
+			continue
+		} else if err := json.Unmarshal(val, fieldPtr); err != nil {
+			return err
+		}
+	}
+
+	for key, fieldPtr := range map[string]*int64{
+		"pvseg_start": &seg.Start,
+		"pvseg_size":  &seg.Size,
+	} {
+		if err := unmarshalToStringAndParseInt64(raw, key, fieldPtr); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// IsFree returns true if the segment is not allocated to any logical volume.
+func (seg *PVSegment) IsFree() bool {
+	return seg.Type == SegmentTypeFree || seg.LogicalVolumeName == ""
+}
+
+// End returns the last physical extent of the segment.
+func (seg *PVSegment) End() int64 {
+	return seg.Start + seg.Size - 1
+}
+
+// SegmentTypeFree is the segment type lvm2 reports for unallocated physical volume segments.
+const SegmentTypeFree Type = "free"
+
+// PERange is a range of physical extents on a physical volume, e.g. /dev/sdb:0-99.
+type PERange struct {
+	PhysicalVolumeName
+	Start uint64
+	End   uint64
+}
+
+// ParsePERange parses a single physical extent range in the form of pv:start-end.
+func ParsePERange(raw string) (PERange, error) {
+	idx := strings.LastIndex(raw, ":")
+	if idx <= 0 {
+		return PERange{}, fmt.Errorf("%q is not a valid pe range: missing physical volume", raw)
+	}
+	pv, extents := raw[:idx], raw[idx+1:]
+	start, end, found := strings.Cut(extents, "-")
+	if !found {
+		end = start
+	}
+	startPE, err := strconv.ParseUint(start, 10, 64)
+	if err != nil {
+		return PERange{}, fmt.Errorf("%q is not a valid pe range: %w", raw, err)
+	}
+	endPE, err := strconv.ParseUint(end, 10, 64)
+	if err != nil {
+		return PERange{}, fmt.Errorf("%q is not a valid pe range: %w", raw, err)
+	}
+	if endPE < startPE {
+		return PERange{}, fmt.Errorf("%q is not a valid pe range: end is before start", raw)
+	}
+	return PERange{PhysicalVolumeName: PhysicalVolumeName(pv), Start: startPE, End: endPE}, nil
+}
+
+// Count returns the number of physical extents in the range.
+func (r PERange) Count() uint64 {
+	return r.End - r.Start + 1
+}
+
+func (r PERange) String() string {
+	return fmt.Sprintf("%s:%d-%d", r.PhysicalVolumeName, r.Start, r.End)
+}
+
+func (r PERange) Validate() error {
+	if r.PhysicalVolumeName == "" {
+		return ErrPhysicalVolumeNameRequired
+	}
+	if r.End < r.Start {
+		return fmt.Errorf("%q is not a valid pe range: end is before start", r)
+	}
+	return nil
+}
+
+// ApplyToArgs adds the range as physical volume argument, which restricts the allocation to its extents.
+func (r PERange) ApplyToArgs(args Arguments) error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	args.AddOrReplace(r.String())
+	return nil
+}
+
+func (r PERange) ApplyToLVCreateOptions(opts *LVCreateOptions) {
+	opts.PERanges = append(opts.PERanges, r)
+}
+
+func (r PERange) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.PERanges = append(opts.PERanges, r)
+}
+
+func (r PERange) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.PERanges = append(opts.PERanges, r)
+}
+
+type PERanges []PERange
+
+// ParsePERanges parses the seg_pe_ranges report field, which contains space separated PERange entries.
+func ParsePERanges(raw string) (PERanges, error) {
+	var ranges PERanges
+	for _, field := range strings.Fields(raw) {
+		r, err := ParsePERange(field)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+func (ranges PERanges) ApplyToArgs(args Arguments) error {
+	for _, r := range ranges {
+		if err := r.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (ranges PERanges) ApplyToLVCreateOptions(opts *LVCreateOptions) {
+	opts.PERanges = append(opts.PERanges, ranges...)
+}
+
+func (ranges PERanges) ApplyToLVExtendOptions(opts *LVExtendOptions) {
+	opts.PERanges = append(opts.PERanges, ranges...)
+}
+
+func (ranges PERanges) ApplyToLVResizeOptions(opts *LVResizeOptions) {
+	opts.PERanges = append(opts.PERanges, ranges...)
+}
+
+// PhysicalVolumeNames returns the distinct physical volumes referenced by the ranges.
+func (ranges PERanges) PhysicalVolumeNames() PhysicalVolumeNames {
+	var names PhysicalVolumeNames
+	for _, r := range ranges {
+		if !slices.Contains(names, r.PhysicalVolumeName) {
+			names = append(names, r.PhysicalVolumeName)
+		}
+	}
+	return names
+}
+
+// SegDevice is a device backing a segment starting at the given extent, e.g. /dev/sdb(0).
+// Devices can be physical volumes or, for RAID and thin segments, other (internal) logical volumes.
+type SegDevice struct {
+	Device      string
+	StartExtent int64
+}
+
+// ParseSegDevice parses a single device entry in the form of device(start).
+func ParseSegDevice(raw string) (SegDevice, error) {
+	idx := strings.LastIndex(raw, "(")
+	if idx <= 0 || !strings.HasSuffix(raw, ")") {
+		return SegDevice{}, fmt.Errorf("%q is not a valid segment device", raw)
+	}
+	start, err := strconv.ParseInt(raw[idx+1:len(raw)-1], 10, 64)
+	if err != nil {
+		return SegDevice{}, fmt.Errorf("%q is not a valid segment device: %w", raw, err)
+	}
+	return SegDevice{Device: raw[:idx], StartExtent: start}, nil
+}
+
+func (dev SegDevice) String() string {
+	return fmt.Sprintf("%s(%d)", dev.Device, dev.StartExtent)
+}
+
+type SegDevices []SegDevice
+
+// ParseSegDevices parses the devices report field, which contains comma separated SegDevice entries.
+func ParseSegDevices(raw string) (SegDevices, error) {
+	var devices SegDevices
+	for _, field := range strings.Split(raw, ",") {
+		if field = strings.TrimSpace(field); field == "" {
+			continue
+		}
+		dev, err := ParseSegDevice(field)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+// PhysicalVolumeLayout is the extent layout of a single physical volume, computed from its PVSegment entries.
+type PhysicalVolumeLayout struct {
+	Name     PhysicalVolumeName
+	Segments []*PVSegment
+
+	UsedExtents int64
+	FreeExtents int64
+	// FreeSegments is the number of unallocated segments, more than one signals fragmented free space.
+	FreeSegments int
+	// LargestFreeSegment is the size of the largest contiguous free area in extents.
+	LargestFreeSegment int64
+	// LogicalVolumes are the logical volumes that have at least one segment on the physical volume.
+	LogicalVolumes []LogicalVolumeName
+}
+
+// IsFragmented returns true if the free space on the physical volume is not contiguous.
+func (layout *PhysicalVolumeLayout) IsFragmented() bool {
+	return layout.FreeSegments > 1
+}
+
+// NewPhysicalVolumeLayouts groups the given segments by physical volume and computes their layout.
+// The layouts are returned in the order the physical volumes first appear in the segments and
+// the segments of each layout are sorted by their start extent.
+func NewPhysicalVolumeLayouts(segments []*PVSegment) []*PhysicalVolumeLayout {
+	var layouts []*PhysicalVolumeLayout
+	byName := make(map[PhysicalVolumeName]*PhysicalVolumeLayout)
+	for _, seg := range segments {
+		layout, ok := byName[seg.PhysicalVolumeName]
+		if !ok {
+			layout = &PhysicalVolumeLayout{Name: seg.PhysicalVolumeName}
+			byName[seg.PhysicalVolumeName] = layout
+			layouts = append(layouts, layout)
+		}
+		layout.Segments = append(layout.Segments, seg)
+		if seg.IsFree() {
+			layout.FreeExtents += seg.Size
+			layout.FreeSegments++
+			layout.LargestFreeSegment = max(layout.LargestFreeSegment, seg.Size)
+		} else {
+			layout.UsedExtents += seg.Size
+			if !slices.Contains(layout.LogicalVolumes, seg.LogicalVolumeName) {
+				layout.LogicalVolumes = append(layout.LogicalVolumes, seg.LogicalVolumeName)
+			}
+		}
+	}
+	for _, layout := range layouts {
+		slices.SortStableFunc(layout.Segments, func(a, b *PVSegment) int {
+			return int(a.Start - b.Start)
+		})
+	}
+	return layouts
+}
+
+// segmentGraph links logical volumes to the devices and metadata devices of their segments,
+// which are either physical volumes or other (internal) logical volumes.
+type segmentGraph struct {
+	order      []LogicalVolumeName
+	devices    map[LogicalVolumeName][]string
+	referenced map[LogicalVolumeName]bool
+}
+
+func newSegmentGraph(segments []*LVSegment) *segmentGraph {
+	graph := &segmentGraph{
+		devices:    make(map[LogicalVolumeName][]string),
+		referenced: make(map[LogicalVolumeName]bool),
+	}
+	for _, seg := range segments {
+		lv := internalLogicalVolumeName(string(seg.LogicalVolumeName))
+		if _, ok := graph.devices[lv]; !ok {
+			graph.order = append(graph.order, lv)
+			graph.devices[lv] = nil
+		}
+		for _, dev := range append(slices.Clone(seg.Devices), seg.MetadataDevices...) {
+			graph.devices[lv] = append(graph.devices[lv], dev.Device)
+		}
+	}
+	for _, devices := range graph.devices {
+		for _, dev := range devices {
+			if name := internalLogicalVolumeName(dev); graph.isLogicalVolume(name) {
+				graph.referenced[name] = true
+			}
+		}
+	}
+	return graph
+}
+
+func (graph *segmentGraph) isLogicalVolume(name LogicalVolumeName) bool {
+	_, ok := graph.devices[name]
+	return ok
+}
+
+// isTopLevel returns true if the logical volume is not a sub volume of another logical volume.
+// The pool metadata spare is not referenced by any pool, but is managed by lvm2 for the whole volume group.
+func (graph *segmentGraph) isTopLevel(name LogicalVolumeName) bool {
+	return !graph.referenced[name] && !strings.HasSuffix(string(name), "_pmspare")
+}
+
+func (graph *segmentGraph) physicalVolumes(lv LogicalVolumeName, visited []LogicalVolumeName) PhysicalVolumeNames {
+	if slices.Contains(visited, lv) {
+		return nil
+	}
+	visited = append(visited, lv)
+
+	var pvs PhysicalVolumeNames
+	for _, dev := range graph.devices[lv] {
+		if name := internalLogicalVolumeName(dev); graph.isLogicalVolume(name) {
+			for _, pv := range graph.physicalVolumes(name, visited) {
+				if !slices.Contains(pvs, pv) {
+					pvs = append(pvs, pv)
+				}
+			}
+		} else if pv := PhysicalVolumeName(dev); !slices.Contains(pvs, pv) {
+			pvs = append(pvs, pv)
+		}
+	}
+	return pvs
+}
+
+// internalLogicalVolumeName strips the brackets lvm2 uses to mark internal logical volumes in reports.
+func internalLogicalVolumeName(name string) LogicalVolumeName {
+	return LogicalVolumeName(strings.TrimSuffix(strings.TrimPrefix(name, "["), "]"))
+}
+
+// The kinds of sub volumes lvm2 allocates for the images and metadata of RAID and mirrored logical volumes.
+const (
+	subVolumeRAIDImage   = "rimage"
+	subVolumeRAIDMeta    = "rmeta"
+	subVolumeMirrorImage = "mimage"
+)
+
+// subVolumePattern matches the image and metadata sub volumes of RAID and mirrored logical volumes,
+// e.g. lv_rimage_0, lv_rmeta_0 or lv_mimage_1.
+var subVolumePattern = regexp.MustCompile(`^(.+)_(rimage|rmeta|mimage)_\d+$`)
+
+// subVolumeOf returns the logical volume the RAID or mirror sub volume belongs to and the kind of the sub volume.
+// The name may contain the brackets of internal logical volumes. If it is no such sub volume, ok is false.
+func subVolumeOf(name string) (parent LogicalVolumeName, kind string, ok bool) {
+	matches := subVolumePattern.FindStringSubmatch(string(internalLogicalVolumeName(name)))
+	if matches == nil {
+		return "", "", false
+	}
+	return LogicalVolumeName(matches[1]), matches[2], true
+}
@@ -0,0 +1,121 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestLVSegment_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	raw := `{"lv_uuid":"abc","lv_name":"lv","vg_name":"vg","segtype":"striped","seg_start":"0m","seg_start_pe":"0",
+"seg_size":"8.00m","seg_size_pe":"8","stripes":"2","stripe_size":"64.00k",
+"seg_pe_ranges":"/dev/loop0:0-3 /dev/loop1:10-13","devices":"/dev/loop0(0),/dev/loop1(10)","metadata_devices":"[lv_rmeta_0](0),[lv_rmeta_1](0)"}`
+
+	var seg LVSegment
+	if err := json.Unmarshal([]byte(raw), &seg); err != nil {
+		t.Fatal(err)
+	}
+	if seg.Type != TypeStriped || seg.Stripes != 2 || seg.SizePE != 8 {
+		t.Fatalf("unexpected segment %+v", seg)
+	}
+	if seg.StripeSize != MustParseSize("64k") {
+		t.Fatalf("unexpected stripe size %s", seg.StripeSize)
+	}
+	if len(seg.PERanges) != 2 || seg.PERanges[1] != (PERange{PhysicalVolumeName: "/dev/loop1", Start: 10, End: 13}) {
+		t.Fatalf("unexpected pe ranges %v", seg.PERanges)
+	}
+	if count := seg.PERanges[0].Count(); count != 4 {
+		t.Fatalf("expected 4 extents in range, got %d", count)
+	}
+	if len(seg.Devices) != 2 || seg.Devices[1] != (SegDevice{Device: "/dev/loop1", StartExtent: 10}) {
+		t.Fatalf("unexpected devices %v", seg.Devices)
+	}
+	if len(seg.MetadataDevices) != 2 || seg.MetadataDevices[0] != (SegDevice{Device: "[lv_rmeta_0]"}) {
+		t.Fatalf("unexpected metadata devices %v", seg.MetadataDevices)
+	}
+	if pvs := seg.PERanges.PhysicalVolumeNames(); len(pvs) != 2 {
+		t.Fatalf("expected 2 physical volumes, got %v", pvs)
+	}
+}
+
+func TestParsePERange(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		raw     string
+		want    PERange
+		wantErr bool
+	}{
+		{"/dev/sdb:0-999", PERange{PhysicalVolumeName: "/dev/sdb", Start: 0, End: 999}, false},
+		{"/dev/sdb:5", PERange{PhysicalVolumeName: "/dev/sdb", Start: 5, End: 5}, false},
+		{"/dev/sdb:10-5", PERange{}, true},
+		{"/dev/sdb", PERange{}, true},
+		{"/dev/sdb:a-b", PERange{}, true},
+	} {
+		t.Run(tc.raw, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParsePERange(tc.raw)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("ParsePERange() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if got != tc.want {
+				t.Fatalf("ParsePERange() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewPhysicalVolumeLayouts(t *testing.T) {
+	t.Parallel()
+	raw := `[
+{"pv_name":"/dev/loop0","vg_name":"vg","pvseg_start":"4","pvseg_size":"2","lv_name":"","segtype":"free"},
+{"pv_name":"/dev/loop0","vg_name":"vg","pvseg_start":"0","pvseg_size":"4","lv_name":"a","segtype":"linear"},
+{"pv_name":"/dev/loop0","vg_name":"vg","pvseg_start":"6","pvseg_size":"2","lv_name":"b","segtype":"linear"},
+{"pv_name":"/dev/loop0","vg_name":"vg","pvseg_start":"8","pvseg_size":"3","lv_name":"","segtype":"free"},
+{"pv_name":"/dev/loop1","vg_name":"vg","pvseg_start":"0","pvseg_size":"10","lv_name":"","segtype":"free"}
+]`
+	var segs []*PVSegment
+	if err := json.Unmarshal([]byte(raw), &segs); err != nil {
+		t.Fatal(err)
+	}
+
+	layouts := NewPhysicalVolumeLayouts(segs)
+	if len(layouts) != 2 {
+		t.Fatalf("expected 2 layouts, got %d", len(layouts))
+	}
+
+	first := layouts[0]
+	if first.Name != "/dev/loop0" || first.UsedExtents != 6 || first.FreeExtents != 5 {
+		t.Fatalf("unexpected layout %+v", first)
+	}
+	if !first.IsFragmented() || first.LargestFreeSegment != 3 {
+		t.Fatalf("expected fragmented layout with largest free segment of 3, got %+v", first)
+	}
+	if first.Segments[0].Start != 0 || first.Segments[len(first.Segments)-1].End() != 10 {
+		t.Fatalf("expected segments to be sorted by start, got %v", first.Segments)
+	}
+	if len(first.LogicalVolumes) != 2 {
+		t.Fatalf("expected 2 logical volumes, got %v", first.LogicalVolumes)
+	}
+
+	if second := layouts[1]; second.IsFragmented() || second.FreeExtents != 10 || len(second.LogicalVolumes) != 0 {
+		t.Fatalf("unexpected layout %+v", second)
+	}
+}
@@ -18,6 +18,7 @@ package lvm2go
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 )
 
@@ -32,6 +33,9 @@ func (opt Select) ApplyToVGsOptions(opts *VGsOptions) {
 func (opt Select) ApplyToPVsOptions(opts *PVsOptions) {
 	opts.Select = opt
 }
+func (opt Select) ApplyToReportOptions(opts *ReportOptions) {
+	opts.Select = opt
+}
 func (opt Select) ApplyToVGRemoveOptions(opts *VGRemoveOptions) {
 	opts.Select = opt
 }
@@ -59,18 +63,23 @@ func NewSelector(
 	co SelectionComparisonOperator,
 	fields map[string]string,
 ) Select {
+	// sort the fields to render a stable selection independent of map iteration order
+	keys := make([]string, 0, len(fields))
+	for field := range fields {
+		keys = append(keys, field)
+	}
+	slices.Sort(keys)
+
 	var sb strings.Builder
-	last := len(fields) - 1
-	for field, value := range fields {
-		last--
-		sb.WriteString(field)
-		sb.WriteString(string(co))
-		sb.WriteString(value)
-		if last > 0 {
+	for i, field := range keys {
+		if i > 0 {
 			sb.WriteRune(' ')
 			sb.WriteString(string(lo))
 			sb.WriteRune(' ')
 		}
+		sb.WriteString(field)
+		sb.WriteString(string(co))
+		sb.WriteString(fields[field])
 	}
 	return Select(sb.String())
 }
@@ -93,22 +102,24 @@ func NotSelect(sel Select) Select {
 }
 
 func NewCombinedSelect(operator LogicalAndGroupingOperator, selects ...Select) Select {
-	if len(selects) == 1 {
-		return selects[0]
+	nonEmpty := make([]Select, 0, len(selects))
+	for _, sel := range selects {
+		if len(sel) > 0 {
+			nonEmpty = append(nonEmpty, sel)
+		}
+	}
+	if len(nonEmpty) == 1 {
+		return nonEmpty[0]
 	}
 
 	var sb strings.Builder
-	last := len(selects) - 1
-	for i, sel := range selects {
-		if len(sel) == 0 {
-			continue
+	for i, sel := range nonEmpty {
+		if i > 0 {
+			sb.WriteString(string(operator))
 		}
 		sb.WriteString(string(LeftParenthesis))
 		sb.WriteString(string(sel))
 		sb.WriteString(string(RightParenthesis))
-		if i < last {
-			sb.WriteString(string(operator))
-		}
 	}
 	return Select(sb.String())
 }
@@ -0,0 +1,324 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrUnknownSelectField     = errors.New("field is not a known report field")
+	ErrUnquotableSelectValue  = errors.New("value contains both single and double quotes and cannot be quoted for selection")
+	ErrUnsupportedSelectValue = errors.New("value type is not supported in selection")
+)
+
+// selectSpecialCharacters are characters that terminate an unquoted value in lvm2 selection criteria.
+const selectSpecialCharacters = "\"'&|,#!()[]{}=<>~"
+
+// SelectExpression is a node of selection criteria that can be rendered into lvm2 selection syntax.
+// Expressions are built with Field, And, Or and Not, e.g.
+//
+//	And(Field("lv_size").Gt(MustParseSize("1g")), Not(Field("lv_tags").ContainsAny("backup")))
+//
+// See man lvmreport for more information on the selection syntax.
+type SelectExpression interface {
+	// Render renders the expression into lvm2 selection syntax.
+	Render() (Select, error)
+	// Fields returns all report fields referenced in the expression.
+	Fields() []string
+}
+
+var (
+	_ SelectExpression = Select("")
+	_ SelectExpression = (*selectComparison)(nil)
+	_ SelectExpression = (*selectGroup)(nil)
+	_ SelectExpression = (*selectNegation)(nil)
+)
+
+// NewSelect renders the expression into a Select.
+// If report types are given, all fields of the expression have to be known fields of one of these types.
+func NewSelect(expr SelectExpression, types ...ReportType) (Select, error) {
+	if len(types) > 0 {
+		for _, field := range expr.Fields() {
+			if !IsReportField(field, types...) {
+				return "", fmt.Errorf("%w for %v: %q", ErrUnknownSelectField, types, field)
+			}
+		}
+	}
+	return expr.Render()
+}
+
+func MustNewSelect(expr SelectExpression, types ...ReportType) Select {
+	sel, err := NewSelect(expr, types...)
+	if err != nil {
+		panic(err)
+	}
+	return sel
+}
+
+// Render returns the Select as is, so raw selection criteria can be combined with expressions.
+func (opt Select) Render() (Select, error) {
+	return opt, nil
+}
+
+// Fields returns nil, as fields of raw selection criteria are not known.
+func (opt Select) Fields() []string {
+	return nil
+}
+
+// SelectField is a report field used in selection criteria, e.g. "lv_size" or "vg_name".
+type SelectField string
+
+func Field(name string) SelectField {
+	return SelectField(name)
+}
+
+// Compare compares the field against the value with the given operator.
+// Supported values are strings, numbers, booleans, Size, time.Time and fmt.Stringer implementations.
+func (f SelectField) Compare(op SelectionComparisonOperator, value any) SelectExpression {
+	return &selectComparison{field: f, op: op, value: value}
+}
+
+func (f SelectField) Eq(value any) SelectExpression {
+	return f.Compare(Match, value)
+}
+
+func (f SelectField) Ne(value any) SelectExpression {
+	return f.Compare(NotMatch, value)
+}
+
+func (f SelectField) Gt(value any) SelectExpression {
+	return f.Compare(Greater, value)
+}
+
+func (f SelectField) Ge(value any) SelectExpression {
+	return f.Compare(GreaterOrEq, value)
+}
+
+func (f SelectField) Lt(value any) SelectExpression {
+	return f.Compare(Less, value)
+}
+
+func (f SelectField) Le(value any) SelectExpression {
+	return f.Compare(LessOrEq, value)
+}
+
+// Matches matches the field against the regular expression.
+func (f SelectField) Matches(regex string) SelectExpression {
+	return f.Compare(MatchRegex, regex)
+}
+
+// NotMatches matches the field against the regular expression and negates the result.
+func (f SelectField) NotMatches(regex string) SelectExpression {
+	return f.Compare(NotMatchRegex, regex)
+}
+
+func (f SelectField) Since(t time.Time) SelectExpression {
+	return f.Compare(Since, t)
+}
+
+func (f SelectField) After(t time.Time) SelectExpression {
+	return f.Compare(After, t)
+}
+
+func (f SelectField) Until(t time.Time) SelectExpression {
+	return f.Compare(Until, t)
+}
+
+func (f SelectField) Before(t time.Time) SelectExpression {
+	return f.Compare(Before, t)
+}
+
+// ListEq matches string list fields such as lv_tags that contain exactly the given items.
+func (f SelectField) ListEq(items ...string) SelectExpression {
+	return f.Compare(Match, selectList{start: ListStart, end: ListEnd, separator: AllFieldsMatchAlt, items: items})
+}
+
+// ContainsAll matches string list fields such as lv_tags that contain all given items.
+func (f SelectField) ContainsAll(items ...string) SelectExpression {
+	return f.Compare(Match, selectList{start: ListSubsetStart, end: ListSubsetEnd, separator: AllFieldsMatchAlt, items: items})
+}
+
+// ContainsAny matches string list fields such as lv_tags that contain at least one of the given items.
+func (f SelectField) ContainsAny(items ...string) SelectExpression {
+	return f.Compare(Match, selectList{start: ListSubsetStart, end: ListSubsetEnd, separator: AtLeastOneFieldMatches, items: items})
+}
+
+type selectComparison struct {
+	field SelectField
+	op    SelectionComparisonOperator
+	value any
+}
+
+func (c *selectComparison) Render() (Select, error) {
+	value, err := renderSelectValue(c.value)
+	if err != nil {
+		return "", fmt.Errorf("cannot render value of field %q: %w", c.field, err)
+	}
+	if c.op == Since || c.op == After || c.op == Until || c.op == Before {
+		return Select(fmt.Sprintf("%s %s %s", c.field, c.op, value)), nil
+	}
+	return Select(fmt.Sprintf("%s%s%s", c.field, c.op, value)), nil
+}
+
+func (c *selectComparison) Fields() []string {
+	return []string{string(c.field)}
+}
+
+type selectList struct {
+	start, end, separator LogicalAndGroupingOperator
+	items                 []string
+}
+
+func (l selectList) render() (string, error) {
+	items := make([]string, 0, len(l.items))
+	for _, item := range l.items {
+		quoted, err := quoteSelectValue(item)
+		if err != nil {
+			return "", err
+		}
+		items = append(items, quoted)
+	}
+	return string(l.start) + strings.Join(items, string(l.separator)) + string(l.end), nil
+}
+
+// And matches if all expressions match. Empty expressions are ignored.
+func And(exprs ...SelectExpression) SelectExpression {
+	return &selectGroup{op: AllFieldsMatch, exprs: exprs}
+}
+
+// Or matches if at least one of the expressions matches. Empty expressions are ignored.
+func Or(exprs ...SelectExpression) SelectExpression {
+	return &selectGroup{op: AtLeastOneFieldMatches, exprs: exprs}
+}
+
+type selectGroup struct {
+	op    LogicalAndGroupingOperator
+	exprs []SelectExpression
+}
+
+func (g *selectGroup) Render() (Select, error) {
+	var rendered, parts []string
+	for _, expr := range g.exprs {
+		sel, err := expr.Render()
+		if err != nil {
+			return "", err
+		}
+		if sel == "" {
+			continue
+		}
+		rendered = append(rendered, string(sel))
+		// nested groups and raw selects are grouped to keep their precedence
+		switch expr.(type) {
+		case *selectComparison, *selectNegation:
+		default:
+			sel = groupSelect(sel)
+		}
+		parts = append(parts, string(sel))
+	}
+	if len(parts) == 1 {
+		return Select(rendered[0]), nil
+	}
+	return Select(strings.Join(parts, fmt.Sprintf(" %s ", g.op))), nil
+}
+
+func (g *selectGroup) Fields() []string {
+	var fields []string
+	for _, expr := range g.exprs {
+		fields = append(fields, expr.Fields()...)
+	}
+	return fields
+}
+
+// Not negates the expression. Negating an empty expression results in an empty expression.
+func Not(expr SelectExpression) SelectExpression {
+	return &selectNegation{expr: expr}
+}
+
+type selectNegation struct {
+	expr SelectExpression
+}
+
+func (n *selectNegation) Render() (Select, error) {
+	sel, err := n.expr.Render()
+	if err != nil || sel == "" {
+		return sel, err
+	}
+	return Select(string(LogicalNegation) + string(groupSelect(sel))), nil
+}
+
+func (n *selectNegation) Fields() []string {
+	return n.expr.Fields()
+}
+
+func groupSelect(sel Select) Select {
+	return Select(string(LeftParenthesis) + string(sel) + string(RightParenthesis))
+}
+
+// renderSelectValue renders a value into its lvm2 selection representation.
+// Sizes are rendered with their unit, times as seconds since the epoch and booleans as 1 or 0.
+func renderSelectValue(value any) (string, error) {
+	switch v := value.(type) {
+	case selectList:
+		return v.render()
+	case Size:
+		return strconv.FormatFloat(v.Val, 'f', -1, 64) + v.Unit.String(), nil
+	case time.Time:
+		return fmt.Sprintf("@%d", v.Unix()), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	case fmt.Stringer:
+		return quoteSelectValue(v.String())
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.String:
+		return quoteSelectValue(rv.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, 64), nil
+	default:
+		return "", fmt.Errorf("%w: %T", ErrUnsupportedSelectValue, value)
+	}
+}
+
+// quoteSelectValue quotes the value if it contains whitespace or characters with a special meaning in selections.
+// lvm2 does not support escaping within quotes, so values with both quote characters cannot be used.
+func quoteSelectValue(value string) (string, error) {
+	if value != "" && !strings.ContainsAny(value, selectSpecialCharacters+" \t\n") {
+		return value, nil
+	}
+	if !strings.Contains(value, `"`) {
+		return `"` + value + `"`, nil
+	}
+	if !strings.Contains(value, `'`) {
+		return `'` + value + `'`, nil
+	}
+	return "", fmt.Errorf("%w: %s", ErrUnquotableSelectValue, value)
+}
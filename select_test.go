@@ -0,0 +1,118 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestNewSelector(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name   string
+		fields map[string]string
+		want   Select
+	}{
+		{"empty", map[string]string{}, ""},
+		{"single", map[string]string{"vg_name": "vg1"}, "vg_name=vg1"},
+		{"sorted", map[string]string{"vg_name": "vg1", "lv_name": "lv1", "lv_size": "1g"}, "lv_name=lv1 && lv_size=1g && vg_name=vg1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			for range 10 {
+				if got := NewMatchesAllSelector(tc.fields); got != tc.want {
+					t.Fatalf("NewMatchesAllSelector() = %q, want %q", got, tc.want)
+				}
+			}
+		})
+	}
+}
+
+func TestNewCombinedSelect(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name    string
+		selects []Select
+		want    Select
+	}{
+		{"empty", nil, ""},
+		{"single", []Select{"a=1"}, "a=1"},
+		{"trailing empty", []Select{"a=1", "b=2", ""}, "(a=1)&&(b=2)"},
+		{"only one non empty", []Select{"", "a=1"}, "a=1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := NewMatchesAllSelect(tc.selects...); got != tc.want {
+				t.Fatalf("NewMatchesAllSelect() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNewSelect(t *testing.T) {
+	t.Parallel()
+	created := time.Unix(1700000000, 0)
+	for _, tc := range []struct {
+		name  string
+		expr  SelectExpression
+		types []ReportType
+		want  Select
+		err   error
+	}{
+		{"size", Field("lv_size").Gt(NewSize(1.5, UnitGiB)), nil, "lv_size>1.5g", nil},
+		{"int", Field("seg_count").Le(2), nil, "seg_count<=2", nil},
+		{"bool", Field("lv_active").Eq(true), nil, "lv_active=1", nil},
+		{"named string", Field("vg_name").Eq(VolumeGroupName("vg1")), nil, "vg_name=vg1", nil},
+		{"quoted string", Field("lv_name").Ne("my lv"), nil, `lv_name!="my lv"`, nil},
+		{"single quoted string", Field("lv_name").Eq(`a"b`), nil, `lv_name='a"b'`, nil},
+		{"empty string", Field("lv_tags").Eq(""), nil, `lv_tags=""`, nil},
+		{"unquotable", Field("lv_name").Eq(`a"b'c`), nil, "", ErrUnquotableSelectValue},
+		{"unsupported", Field("lv_name").Eq([]int{1}), nil, "", ErrUnsupportedSelectValue},
+		{"regex", Field("lv_name").Matches("^thin.*"), nil, "lv_name=~^thin.*", nil},
+		{"regex alternation", Field("lv_name").NotMatches("a|b"), nil, `lv_name!~"a|b"`, nil},
+		{"time", Field("lv_time").Since(created), nil, "lv_time since @1700000000", nil},
+		{"list exact", Field("lv_tags").ListEq("a", "b"), nil, "lv_tags=[a,b]", nil},
+		{"list all", Field("lv_tags").ContainsAll("a", "b c"), nil, `lv_tags={a,"b c"}`, nil},
+		{"list any", Field("lv_tags").ContainsAny("a", "b"), nil, "lv_tags={a||b}", nil},
+		{
+			"nested",
+			And(Field("vg_name").Eq("vg1"), Or(Field("lv_size").Ge(MustParseSize("1g")), Not(Field("lv_active").Eq(true)))),
+			nil,
+			"vg_name=vg1 && (lv_size>=1g || !(lv_active=1))",
+			nil,
+		},
+		{"and ignores empty", And(Select(""), Field("vg_name").Eq("vg1")), nil, "vg_name=vg1", nil},
+		{"raw select", Or(Select("lv_name=a"), Field("lv_name").Eq("b")), nil, "(lv_name=a) || lv_name=b", nil},
+		{"valid fields", And(Field("size").Gt(0), Field("vg_name").Eq("vg1")), LVsReportTypes, "size>0 && vg_name=vg1", nil},
+		{"unknown field", Field("pv_name").Eq("/dev/sda"), VGsReportTypes, "", ErrUnknownSelectField},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := NewSelect(tc.expr, tc.types...)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("NewSelect() error = %v, want %v", err, tc.err)
+			}
+			if got != tc.want {
+				t.Fatalf("NewSelect() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
@@ -104,6 +104,12 @@ func (unit Unit) ApplyToVGsOptions(opts *VGsOptions) {
 func (unit Unit) ApplyToPVsOptions(opts *PVsOptions) {
 	opts.Unit = unit
 }
+func (unit Unit) ApplyToReportOptions(opts *ReportOptions) {
+	opts.Unit = unit
+}
+func (unit Unit) ApplyToFullReportOptions(opts *FullReportOptions) {
+	opts.Unit = unit
+}
 
 const (
 	conversionFactor      = 1024
@@ -441,6 +447,10 @@ func (opt PrefixedSize) ApplyToLVExtendOptions(opts *LVExtendOptions) {
 	opts.PrefixedSize = opt
 }
 
+func (opt PrefixedSize) ApplyToLVReduceOptions(opts *LVReduceOptions) {
+	opts.PrefixedSize = opt
+}
+
 type PoolMetadataPrefixedSize PrefixedSize
 
 func (opt PoolMetadataPrefixedSize) ApplyToArgs(args Arguments) error {
@@ -0,0 +1,175 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+var ErrSystemIDAndClearSystemID = errors.New("SystemID and ClearSystemID cannot be used together")
+
+// SystemIDPattern matches the output of lvm systemid, e.g. "  system ID: host1".
+var SystemIDPattern = regexp.MustCompile(`system ID:\s*(\S*)`)
+
+// SystemID is the identifier lvm2 uses to determine which host owns a volume group.
+// Volume groups with a system ID different from the one of the host are foreign and cannot be accessed.
+// See man lvmsystemid for more information.
+type SystemID string
+
+func (opt SystemID) ApplyToVGCreateOptions(opts *VGCreateOptions) {
+	opts.SystemID = opt
+}
+
+func (opt SystemID) ApplyToVGChangeOptions(opts *VGChangeOptions) {
+	opts.SystemID = opt
+}
+
+func (opt SystemID) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--systemid=%s", string(opt)))
+	return nil
+}
+
+// ClearSystemID removes the system ID of a volume group so that it can be accessed by any host.
+type ClearSystemID bool
+
+func (opt ClearSystemID) ApplyToVGChangeOptions(opts *VGChangeOptions) {
+	opts.ClearSystemID = opt
+}
+
+func (opt ClearSystemID) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--systemid=")
+	}
+	return nil
+}
+
+// ExtraSystemIDs are system IDs that are accepted in addition to the system ID of the host for a single command.
+// They allow taking over a foreign volume group, e.g. when failing over from a host that is known to be down,
+// by combining them with the SystemID of the new owner.
+type ExtraSystemIDs []SystemID
+
+func (opt ExtraSystemIDs) ApplyToVGChangeOptions(opts *VGChangeOptions) {
+	opts.ExtraSystemIDs = opt
+}
+
+func (opt ExtraSystemIDs) ApplyToArgs(args Arguments) error {
+	if len(opt) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(opt))
+	for _, id := range opt {
+		ids = append(ids, fmt.Sprintf("%q", string(id)))
+	}
+	args.AddOrReplace(fmt.Sprintf("--config=local/extra_system_ids=[%s]", strings.Join(ids, ",")))
+	return nil
+}
+
+// Foreign includes foreign volume groups, i.e. volume groups owned by other hosts, in reports.
+type Foreign bool
+
+func (opt Foreign) ApplyToVGsOptions(opts *VGsOptions) {
+	opts.Foreign = opt
+}
+
+func (opt Foreign) ApplyToLVsOptions(opts *LVsOptions) {
+	opts.Foreign = opt
+}
+
+func (opt Foreign) ApplyToPVsOptions(opts *PVsOptions) {
+	opts.Foreign = opt
+}
+
+func (opt Foreign) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--foreign")
+	}
+	return nil
+}
+
+// SystemIDSource is the source lvm2 uses to determine the system ID of the host, as configured in global/system_id_source.
+type SystemIDSource string
+
+const (
+	SystemIDSourceNone         SystemIDSource = "none"
+	SystemIDSourceMachineID    SystemIDSource = "machineid"
+	SystemIDSourceAppMachineID SystemIDSource = "appmachineid"
+	SystemIDSourceUname        SystemIDSource = "uname"
+	SystemIDSourceLVMLocal     SystemIDSource = "lvmlocal"
+	SystemIDSourceFile         SystemIDSource = "file"
+)
+
+// HostSystemID is the system ID of the host and the source it was determined from.
+type HostSystemID struct {
+	Source SystemIDSource
+	// SystemID is empty if the host has no system ID, in which case only volume groups without a system ID can be accessed.
+	SystemID SystemID
+}
+
+// Owns returns true if the host can access the volume group based on its system ID.
+func (host *HostSystemID) Owns(vg *VolumeGroup) bool {
+	return !vg.IsForeign(host.SystemID)
+}
+
+type systemIDConfig struct {
+	Global struct {
+		SystemIDSource string `lvm:"system_id_source"`
+	} `lvm:"global"`
+}
+
+func (c *client) SystemID(ctx context.Context) (*HostSystemID, error) {
+	config := systemIDConfig{}
+	if err := c.ReadAndDecodeConfig(ctx, &config, ConfigTypeFull); err != nil {
+		return nil, fmt.Errorf("failed to read system id source: %w", err)
+	}
+
+	host := &HostSystemID{Source: SystemIDSource(config.Global.SystemIDSource)}
+	if host.Source == "" {
+		host.Source = SystemIDSourceNone
+	}
+
+	err := c.RunLVMRaw(ctx, func(out io.Reader) error {
+		var err error
+		host.SystemID, err = ParseSystemID(out)
+		return err
+	}, "systemid")
+	if err != nil {
+		return nil, err
+	}
+
+	return host, nil
+}
+
+// ParseSystemID parses the output of lvm systemid.
+// If no system ID is reported, an empty SystemID is returned.
+func ParseSystemID(out io.Reader) (SystemID, error) {
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		if matches := SystemIDPattern.FindStringSubmatch(scanner.Text()); matches != nil {
+			return SystemID(matches[1]), nil
+		}
+	}
+	return "", scanner.Err()
+}
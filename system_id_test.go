@@ -0,0 +1,123 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestParseSystemID(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		out  string
+		want SystemID
+	}{
+		{"  system ID: host1\n", "host1"},
+		{"  system ID: \n", ""},
+		{"", ""},
+	} {
+		t.Run(tc.out, func(t *testing.T) {
+			t.Parallel()
+			got, err := ParseSystemID(strings.NewReader(tc.out))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Fatalf("ParseSystemID() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestVGChangeOptionsList_SystemID(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts VGChangeOptionsList
+		args []string
+	}{
+		{"set", VGChangeOptionsList{VolumeGroupName("vg"), SystemID("host1")}, []string{"vg", "--systemid=host1", "--yes"}},
+		{"clear", VGChangeOptionsList{VolumeGroupName("vg"), ClearSystemID(true)}, []string{"vg", "--systemid=", "--yes"}},
+		{
+			"take over",
+			VGChangeOptionsList{VolumeGroupName("vg"), SystemID("host1"), ExtraSystemIDs{"host2"}},
+			[]string{"vg", "--systemid=host1", `--config=local/extra_system_ids=["host2"]`, "--yes"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+
+	opts := VGChangeOptionsList{VolumeGroupName("vg"), SystemID("host1"), ClearSystemID(true)}
+	if _, err := opts.AsArgs(); !errors.Is(err, ErrSystemIDAndClearSystemID) {
+		t.Fatalf("expected %v, got %v", ErrSystemIDAndClearSystemID, err)
+	}
+}
+
+func TestVolumeGroup_IsForeign(t *testing.T) {
+	t.Parallel()
+	host := &HostSystemID{Source: SystemIDSourceUname, SystemID: "host1"}
+	for _, tc := range []struct {
+		sysID   string
+		foreign bool
+	}{
+		{"", false},
+		{"host1", false},
+		{"host2", true},
+	} {
+		vg := &VolumeGroup{SysID: tc.sysID}
+		if vg.IsForeign(host.SystemID) != tc.foreign || host.Owns(vg) == tc.foreign {
+			t.Fatalf("expected volume group with system id %q to be foreign=%v", tc.sysID, tc.foreign)
+		}
+	}
+}
+
+func TestForeignVolumeGroupDetails(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		stderr                    string
+		vg, systemID, localSystem string
+	}{
+		{"  Cannot access VG vg1 with system ID host2 with local system ID host1.", "vg1", "host2", "host1"},
+		{"  Cannot access VG vg1 with system ID host2 with unknown local system ID.", "vg1", "host2", ""},
+	} {
+		t.Run(tc.stderr, func(t *testing.T) {
+			t.Parallel()
+			err := NewLVMStdErr([]byte(tc.stderr))
+			if !IsForeignVolumeGroup(err) {
+				t.Fatal("expected foreign volume group error")
+			}
+			vg, systemID, local, ok := ForeignVolumeGroupDetails(err)
+			if !ok || vg != tc.vg || systemID != tc.systemID || local != tc.localSystem {
+				t.Fatalf("unexpected details %q %q %q %v", vg, systemID, local, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+var ErrThinPoolOvercommitExceeded = errors.New("thin pool overcommit exceeded")
+
+// MaxOvercommit is the maximum ratio of the summed virtual size of all thin volumes in a thin pool
+// to the data size of the pool, e.g. 2 allows to provision twice the physical size of the pool.
+// Given to LVCreate, creating a thin volume that would exceed it fails with ErrThinPoolOvercommitExceeded
+// before lvcreate is run. A value of 0 disables the check.
+type MaxOvercommit float64
+
+func (opt MaxOvercommit) ApplyToLVCreateOptions(opts *LVCreateOptions) {
+	opts.MaxOvercommit = opt
+}
+
+// ThinPoolAllocation is the provisioning state of a thin pool.
+// Sizes are always in bytes.
+type ThinPoolAllocation struct {
+	Pool FQLogicalVolumeName
+
+	// Size is the data size of the thin pool, which is the physical space shared by its thin volumes.
+	Size Size
+	// VirtualSize is the sum of the virtual sizes of all thin volumes in the thin pool.
+	VirtualSize Size
+	// ThinVolumes is the number of thin volumes, including thin snapshots, in the thin pool.
+	ThinVolumes int
+
+	DataPercent     float64
+	MetadataPercent float64
+}
+
+// Overcommit is the ratio of VirtualSize to Size.
+// A value above 1 means more space is provisioned than the thin pool can store.
+func (a *ThinPoolAllocation) Overcommit() float64 {
+	if a.Size.Val <= 0 {
+		return 0
+	}
+	return a.VirtualSize.Val / a.Size.Val
+}
+
+// Used is the physical space of the thin pool that is written to.
+func (a *ThinPoolAllocation) Used() Size {
+	return NewSize(a.Size.Val*a.DataPercent/100, UnitBytes)
+}
+
+// Available is the virtual size that can still be provisioned without exceeding the maximum overcommit.
+func (a *ThinPoolAllocation) Available(maxOvercommit MaxOvercommit) Size {
+	available := a.Size.Val*float64(maxOvercommit) - a.VirtualSize.Val
+	if available < 0 {
+		available = 0
+	}
+	return NewSize(available, UnitBytes)
+}
+
+// CheckOvercommit returns ErrThinPoolOvercommitExceeded if provisioning a thin volume
+// with the given virtual size would exceed the maximum overcommit.
+func (a *ThinPoolAllocation) CheckOvercommit(size Size, maxOvercommit MaxOvercommit) error {
+	if maxOvercommit <= 0 {
+		return nil
+	}
+	bytes, err := size.ToUnit(UnitBytes)
+	if err != nil {
+		return err
+	}
+	if available := a.Available(maxOvercommit); bytes.Val > available.Val {
+		return fmt.Errorf("%w: %s cannot provision %s, only %s are available below an overcommit of %.2f (currently %.2f)",
+			ErrThinPoolOvercommitExceeded, &a.Pool, bytes, available, float64(maxOvercommit), a.Overcommit())
+	}
+	return nil
+}
+
+// ThinPoolAllocationsOf calculates the allocation of all thin pools in the given logical volumes.
+// The virtual sizes of thin volumes are summed up by their PoolLogicalVolume,
+// thin volumes of thin pools that are not part of the given logical volumes are ignored.
+// The allocations are returned in the order of the thin pools.
+func ThinPoolAllocationsOf(lvs []*LogicalVolume) ([]*ThinPoolAllocation, error) {
+	var allocations []*ThinPoolAllocation
+	pools := make(map[FQLogicalVolumeName]*ThinPoolAllocation)
+	for _, lv := range lvs {
+		if lv.Attr.VolumeType != VolumeTypeThinPool {
+			continue
+		}
+		size, err := lv.Size.ToUnit(UnitBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine size of thin pool %s: %w", lv.Name, err)
+		}
+		allocation := &ThinPoolAllocation{
+			Pool:            FQLogicalVolumeName{VolumeGroupName: lv.VolumeGroupName, LogicalVolumeName: lv.Name},
+			Size:            size,
+			VirtualSize:     NewSize(0, UnitBytes),
+			DataPercent:     lv.DataPercent,
+			MetadataPercent: lv.MetadataPercent,
+		}
+		pools[allocation.Pool] = allocation
+		allocations = append(allocations, allocation)
+	}
+
+	for _, lv := range lvs {
+		if lv.Attr.VolumeType != VolumeTypeThinVolume || lv.PoolLogicalVolume == "" {
+			continue
+		}
+		allocation, ok := pools[FQLogicalVolumeName{
+			VolumeGroupName:   lv.VolumeGroupName,
+			LogicalVolumeName: LogicalVolumeName(lv.PoolLogicalVolume),
+		}]
+		if !ok {
+			continue
+		}
+		size, err := lv.Size.ToUnit(UnitBytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to determine virtual size of thin volume %s: %w", lv.Name, err)
+		}
+		allocation.VirtualSize.Val += size.Val
+		allocation.ThinVolumes++
+	}
+
+	return allocations, nil
+}
+
+func (c *client) ThinPoolAllocations(ctx context.Context, opts ...LVsOption) ([]*ThinPoolAllocation, error) {
+	lvs, err := c.LVs(ctx, append(slices.Clone(opts), UnitBytes)...)
+	if err != nil {
+		return nil, err
+	}
+	return ThinPoolAllocationsOf(lvs)
+}
+
+// checkThinPoolOvercommit verifies that a thin volume with the given virtual size fits into the thin pool.
+func (c *client) checkThinPoolOvercommit(ctx context.Context, pool *ThinPool, size VirtualSize, maxOvercommit MaxOvercommit) error {
+	allocations, err := c.ThinPoolAllocations(ctx, pool.VolumeGroupName)
+	if err != nil {
+		return fmt.Errorf("failed to determine thin pool allocation: %w", err)
+	}
+	for _, allocation := range allocations {
+		if allocation.Pool == FQLogicalVolumeName(*pool) {
+			return allocation.CheckOvercommit(Size(size), maxOvercommit)
+		}
+	}
+	return fmt.Errorf("%w: thin pool %s", ErrLogicalVolumeNotFound, (*FQLogicalVolumeName)(pool))
+}
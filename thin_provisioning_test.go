@@ -0,0 +1,137 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestThinPoolAllocationsOf(t *testing.T) {
+	t.Parallel()
+	pool := func(vg VolumeGroupName, name string, size string, data float64) *LogicalVolume {
+		return &LogicalVolume{
+			VolumeGroupName: vg,
+			Name:            LogicalVolumeName(name),
+			Size:            MustParseSize(size),
+			Attr:            LVAttributes{VolumeType: VolumeTypeThinPool},
+			DataPercent:     data,
+		}
+	}
+	thin := func(vg VolumeGroupName, name, pool string, size string) *LogicalVolume {
+		return &LogicalVolume{
+			VolumeGroupName:   vg,
+			Name:              LogicalVolumeName(name),
+			PoolLogicalVolume: pool,
+			Size:              MustParseSize(size),
+			Attr:              LVAttributes{VolumeType: VolumeTypeThinVolume},
+		}
+	}
+
+	allocations, err := ThinPoolAllocationsOf([]*LogicalVolume{
+		thin("vg1", "thin1", "pool", "1G"),
+		pool("vg1", "pool", "1G", 50),
+		thin("vg1", "thin2", "pool", "512M"),
+		// same pool name in another volume group must not be mixed up
+		pool("vg2", "pool", "2G", 0),
+		thin("vg2", "thin1", "pool", "1G"),
+		// thin volumes of pools not in the list are ignored
+		thin("vg2", "thin2", "other", "1G"),
+		{VolumeGroupName: "vg1", Name: "linear", Size: MustParseSize("1G"), Attr: LVAttributes{VolumeType: VolumeTypeNone}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocations) != 2 {
+		t.Fatalf("expected 2 thin pools, got %d", len(allocations))
+	}
+
+	vg1, vg2 := allocations[0], allocations[1]
+	if vg1.Pool != (FQLogicalVolumeName{VolumeGroupName: "vg1", LogicalVolumeName: "pool"}) || vg1.ThinVolumes != 2 {
+		t.Fatalf("unexpected allocation %+v", vg1)
+	}
+	if vg1.Overcommit() != 1.5 {
+		t.Fatalf("expected overcommit of 1.5, got %v", vg1.Overcommit())
+	}
+	if vg1.Used() != NewSize(512*1024*1024, UnitBytes) {
+		t.Fatalf("expected half of the pool to be used, got %s", vg1.Used())
+	}
+	if vg2.ThinVolumes != 1 || vg2.Overcommit() != 0.5 {
+		t.Fatalf("unexpected allocation %+v", vg2)
+	}
+
+	for _, tc := range []struct {
+		size string
+		max  MaxOvercommit
+		err  error
+	}{
+		{"512M", 2, nil},
+		{"513M", 2, ErrThinPoolOvercommitExceeded},
+		{"1M", 1.5, ErrThinPoolOvercommitExceeded},
+		{"100G", 0, nil},
+	} {
+		if err := vg1.CheckOvercommit(MustParseSize(tc.size), tc.max); !errors.Is(err, tc.err) {
+			t.Errorf("%s with max overcommit %v: expected %v, got %v", tc.size, tc.max, tc.err, err)
+		}
+	}
+	if vg1.Available(1) != NewSize(0, UnitBytes) {
+		t.Fatalf("expected no space available below an overcommit of 1, got %s", vg1.Available(1))
+	}
+}
+
+func TestThinPoolOvercommit(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{LoopDevices: []Size{MustParseSize("32M")}}.SetupDevicesAndVolumeGroup(t)
+	vg := infra.volumeGroup.Name
+	pool := MustNewThinPool(vg, "pool")
+
+	if err := clnt.LVCreate(ctx, vg, LogicalVolumeName("pool"), TypeThinPool, MustParseSize("8M")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := clnt.LVRemove(ctx, pool); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if err := clnt.LVCreate(ctx, pool, LogicalVolumeName("thin1"), MustParseSize("12M").Virtual(), MaxOvercommit(2)); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := clnt.LVRemove(ctx, MustNewFQLogicalVolumeName(vg, "thin1")); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if err := clnt.LVCreate(ctx, pool, LogicalVolumeName("thin2"), MustParseSize("8M").Virtual(), MaxOvercommit(2)); !errors.Is(err, ErrThinPoolOvercommitExceeded) {
+		t.Fatalf("expected overcommit to be exceeded, got %v", err)
+	}
+
+	allocations, err := clnt.ThinPoolAllocations(ctx, vg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allocations) != 1 || allocations[0].ThinVolumes != 1 || allocations[0].Overcommit() != 1.5 {
+		t.Fatalf("unexpected allocations %+v", allocations)
+	}
+}
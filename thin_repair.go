@@ -0,0 +1,255 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrThinPoolActive                    = errors.New("thin pool is active, deactivate its thin volumes and the thin pool first")
+	ErrSwapMetadataWithPoolMetadataSpare = errors.New("SwapMetadata and PoolMetadataSpare are mutually exclusive")
+)
+
+// Tools used by ThinCheck and ThinDump.
+const (
+	ToolThinCheck Tool = "thin_check"
+	ToolThinDump  Tool = "thin_dump"
+)
+
+const (
+	CreatePoolMetadataSpare      PoolMetadataSpare = "y"
+	DoNotCreatePoolMetadataSpare PoolMetadataSpare = "n"
+)
+
+type (
+	// PoolMetadataSpare controls if lvconvert --repair recreates the spare metadata logical volume
+	// that it used for the repaired metadata.
+	PoolMetadataSpare string
+	// SwapMetadata is a logical volume in the volume group of the thin pool that is swapped in as its metadata.
+	// The previous metadata of the thin pool is then available in this logical volume for manual repair,
+	// e.g. with ThinCheck and ThinDump, and can be swapped back in the same way.
+	SwapMetadata LogicalVolumeName
+	// CheckMetadata runs thin_check on the replaced metadata after the repair, see ThinPoolRepair.
+	CheckMetadata bool
+
+	RepairThinPoolOptions struct {
+		VolumeGroupName
+		LogicalVolumeName
+
+		PoolMetadataSpare
+		SwapMetadata
+		CheckMetadata
+
+		CommonOptions
+	}
+	RepairThinPoolOption interface {
+		ApplyToRepairThinPoolOptions(opts *RepairThinPoolOptions)
+	}
+	RepairThinPoolOptionsList []RepairThinPoolOption
+)
+
+// ThinPoolRepair is the result of RepairThinPool.
+// The health of the thin pool before and after the repair can be verified with LVAttributes.VerifyHealth.
+type ThinPoolRepair struct {
+	Before *LogicalVolume
+	After  *LogicalVolume
+
+	// OldMetadata is the logical volume holding the metadata of the thin pool before the repair.
+	// lvconvert --repair keeps it as <pool>_meta<n>, with SwapMetadata it is the swapped logical volume.
+	// It can be removed once the thin pool is verified to work.
+	OldMetadata *FQLogicalVolumeName
+	// MetadataCheck is the error reported by thin_check on OldMetadata if CheckMetadata was given.
+	MetadataCheck error
+}
+
+var (
+	_ ArgumentGenerator = RepairThinPoolOptionsList{}
+	_ Argument          = (*RepairThinPoolOptions)(nil)
+)
+
+func (c *client) RepairThinPool(ctx context.Context, pool *ThinPool, opts ...RepairThinPoolOption) (*ThinPoolRepair, error) {
+	fq := (*FQLogicalVolumeName)(pool)
+	if err := fq.Validate(); err != nil {
+		return nil, err
+	}
+	options := RepairThinPoolOptions{}
+	for _, opt := range opts {
+		opt.ApplyToRepairThinPoolOptions(&options)
+	}
+	options.VolumeGroupName, options.LogicalVolumeName = fq.VolumeGroupName, fq.LogicalVolumeName
+
+	args, err := RepairThinPoolOptionsList{&options}.AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	lvs, err := c.LVs(ctx, fq.VolumeGroupName)
+	if err != nil {
+		return nil, err
+	}
+	repair := &ThinPoolRepair{Before: findLogicalVolume(lvs, fq.LogicalVolumeName)}
+	if repair.Before == nil {
+		return nil, fmt.Errorf("%w: thin pool %s", ErrLogicalVolumeNotFound, fq)
+	}
+	if repair.Before.Attr.VolumeType != VolumeTypeThinPool {
+		return nil, fmt.Errorf("%s is not a thin pool", fq)
+	}
+	// A pool that needs a check or is suspended is still active, and an unknown state cannot be verified to be inactive.
+	if state := repair.Before.Attr.State; state.IsActive() || state == StateUnknown {
+		return nil, fmt.Errorf("%w: %s (state %q)", ErrThinPoolActive, fq, state)
+	}
+
+	if err := c.RunLVM(ctx, append([]string{"lvconvert"}, args.GetRaw()...)...); err != nil {
+		return nil, err
+	}
+
+	after, err := c.LVs(ctx, fq.VolumeGroupName)
+	if err != nil {
+		return nil, err
+	}
+	if repair.After = findLogicalVolume(after, fq.LogicalVolumeName); repair.After == nil {
+		return nil, fmt.Errorf("%w: thin pool %s after repair", ErrLogicalVolumeNotFound, fq)
+	}
+
+	if options.SwapMetadata != "" {
+		repair.OldMetadata = &FQLogicalVolumeName{VolumeGroupName: fq.VolumeGroupName, LogicalVolumeName: LogicalVolumeName(options.SwapMetadata)}
+	} else {
+		for _, lv := range after {
+			if findLogicalVolume(lvs, lv.Name) == nil && strings.HasPrefix(string(lv.Name), string(fq.LogicalVolumeName)+"_meta") {
+				repair.OldMetadata = &FQLogicalVolumeName{VolumeGroupName: fq.VolumeGroupName, LogicalVolumeName: lv.Name}
+				break
+			}
+		}
+	}
+
+	if options.CheckMetadata && repair.OldMetadata != nil {
+		if repair.MetadataCheck, err = c.checkThinMetadata(ctx, repair.OldMetadata); err != nil {
+			return repair, err
+		}
+	}
+
+	return repair, nil
+}
+
+// checkThinMetadata activates the metadata logical volume for the duration of thin_check.
+// The returned error is the error of thin_check, while errors of the activation are returned separately.
+func (c *client) checkThinMetadata(ctx context.Context, metadata *FQLogicalVolumeName) (checkErr error, err error) {
+	if err := c.LVChange(ctx, metadata, Activate); err != nil {
+		return nil, fmt.Errorf("failed to activate metadata %s for thin_check: %w", metadata, err)
+	}
+	checkErr = ThinCheck(ctx, metadata.DevicePath())
+	if err := c.LVChange(ctx, metadata, Deactivate); err != nil {
+		return checkErr, fmt.Errorf("failed to deactivate metadata %s after thin_check: %w", metadata, err)
+	}
+	return checkErr, nil
+}
+
+// ThinCheck runs thin_check on the thin pool metadata on the given device, which has to be inactive in the thin pool,
+// e.g. a swapped out metadata logical volume. Additional arguments are passed to thin_check.
+func ThinCheck(ctx context.Context, device string, args ...string) error {
+	return runFileSystemTool(ctx, ToolThinCheck, append(args, device)...)
+}
+
+// ThinDump writes the thin pool metadata on the given device as xml to w.
+// Additional arguments, such as --repair, are passed to thin_dump.
+func ThinDump(ctx context.Context, device string, w io.Writer, args ...string) error {
+	stderr := &bytes.Buffer{}
+	cmd := CommandContext(ctx, GetToolPath(ToolThinDump), append(args, device)...)
+	cmd.Stdout, cmd.Stderr = w, stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", ToolThinDump, err, stderr)
+	}
+	return nil
+}
+
+func findLogicalVolume(lvs []*LogicalVolume, name LogicalVolumeName) *LogicalVolume {
+	for _, lv := range lvs {
+		if lv.Name == name {
+			return lv
+		}
+	}
+	return nil
+}
+
+func (list RepairThinPoolOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := RepairThinPoolOptions{}
+	for _, opt := range list {
+		opt.ApplyToRepairThinPoolOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *RepairThinPoolOptions) ApplyToArgs(args Arguments) error {
+	pool := &FQLogicalVolumeName{VolumeGroupName: opts.VolumeGroupName, LogicalVolumeName: opts.LogicalVolumeName}
+	if err := pool.Validate(); err != nil {
+		return err
+	}
+
+	if opts.SwapMetadata != "" {
+		if opts.PoolMetadataSpare != "" {
+			return ErrSwapMetadataWithPoolMetadataSpare
+		}
+		args.AddOrReplaceAll([]string{"--swapmetadata", fmt.Sprintf("--poolmetadata=%s/%s", opts.VolumeGroupName, opts.SwapMetadata)})
+	} else {
+		args.AddOrReplaceAll([]string{"--repair"})
+	}
+
+	for _, arg := range []Argument{
+		pool,
+		opts.PoolMetadataSpare,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (opts *RepairThinPoolOptions) ApplyToRepairThinPoolOptions(new *RepairThinPoolOptions) {
+	*new = *opts
+}
+
+func (opt PoolMetadataSpare) ApplyToRepairThinPoolOptions(opts *RepairThinPoolOptions) {
+	opts.PoolMetadataSpare = opt
+}
+
+func (opt PoolMetadataSpare) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--poolmetadataspare=%s", string(opt)))
+	return nil
+}
+
+func (opt SwapMetadata) ApplyToRepairThinPoolOptions(opts *RepairThinPoolOptions) {
+	opts.SwapMetadata = opt
+}
+
+func (opt CheckMetadata) ApplyToRepairThinPoolOptions(opts *RepairThinPoolOptions) {
+	opts.CheckMetadata = opt
+}
@@ -0,0 +1,138 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestRepairThinPoolOptions(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts RepairThinPoolOptionsList
+		args []string
+		err  error
+	}{
+		{
+			name: "repair",
+			opts: RepairThinPoolOptionsList{&RepairThinPoolOptions{VolumeGroupName: "vg", LogicalVolumeName: "pool"}},
+			args: []string{"--repair", "vg/pool", "--yes"},
+		},
+		{
+			name: "repair without spare",
+			opts: RepairThinPoolOptionsList{
+				&RepairThinPoolOptions{VolumeGroupName: "vg", LogicalVolumeName: "pool"},
+				DoNotCreatePoolMetadataSpare,
+				CheckMetadata(true),
+			},
+			args: []string{"--repair", "vg/pool", "--poolmetadataspare=n", "--yes"},
+		},
+		{
+			name: "swap metadata",
+			opts: RepairThinPoolOptionsList{
+				&RepairThinPoolOptions{VolumeGroupName: "vg", LogicalVolumeName: "pool"},
+				SwapMetadata("meta"),
+			},
+			args: []string{"--swapmetadata", "--poolmetadata=vg/meta", "vg/pool", "--yes"},
+		},
+		{
+			name: "swap metadata with spare",
+			opts: RepairThinPoolOptionsList{
+				&RepairThinPoolOptions{VolumeGroupName: "vg", LogicalVolumeName: "pool"},
+				SwapMetadata("meta"),
+				CreatePoolMetadataSpare,
+			},
+			err: ErrSwapMetadataWithPoolMetadataSpare,
+		},
+		{
+			name: "pool required",
+			opts: RepairThinPoolOptionsList{&RepairThinPoolOptions{VolumeGroupName: "vg"}},
+			err:  ErrLogicalVolumeNameRequired,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("expected error %v, got %v", tc.err, err)
+			}
+			if err != nil {
+				return
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestRepairThinPool(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+	ctx := context.Background()
+	clnt := NewClient()
+
+	infra := test{LoopDevices: []Size{MustParseSize("64M")}}.SetupDevicesAndVolumeGroup(t)
+	vg := infra.volumeGroup.Name
+	pool := MustNewThinPool(vg, "pool")
+
+	if err := clnt.LVCreate(ctx, vg, LogicalVolumeName("pool"), TypeThinPool, MustParseSize("8M")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clnt.RepairThinPool(ctx, pool); !errors.Is(err, ErrThinPoolActive) {
+		t.Fatalf("expected active thin pool to be refused, got %v", err)
+	}
+	if err := clnt.LVChange(ctx, (*FQLogicalVolumeName)(pool), Deactivate); err != nil {
+		t.Fatal(err)
+	}
+
+	repair, err := clnt.RepairThinPool(ctx, pool, CheckMetadata(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repair.Before == nil || repair.After == nil {
+		t.Fatalf("expected state before and after the repair, got %+v", repair)
+	}
+	if err := repair.After.Attr.VerifyHealth(); err != nil {
+		t.Fatalf("expected repaired thin pool to be healthy, got %v", err)
+	}
+	if repair.OldMetadata == nil || !strings.HasPrefix(string(repair.OldMetadata.LogicalVolumeName), "pool_meta") {
+		t.Fatalf("expected old metadata to be kept, got %v", repair.OldMetadata)
+	}
+	if repair.MetadataCheck != nil {
+		t.Fatalf("expected old metadata to pass thin_check, got %v", repair.MetadataCheck)
+	}
+
+	meta := MustNewFQLogicalVolumeName(vg, "meta")
+	if err := clnt.LVCreate(ctx, meta, MustParseSize("4M"), Deactivate); err != nil {
+		t.Fatal(err)
+	}
+	swap, err := clnt.RepairThinPool(ctx, pool, SwapMetadata("meta"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *swap.OldMetadata != *meta {
+		t.Fatalf("expected swapped metadata in %s, got %s", meta, swap.OldMetadata)
+	}
+}
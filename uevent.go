@@ -0,0 +1,431 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrUEventsNotSupported = errors.New("kernel uevents are not supported on this platform")
+	ErrUEventSourceClosed  = errors.New("uevent source is closed")
+	ErrInvalidUEvent       = errors.New("invalid uevent")
+)
+
+// UEventAction is the action of a kernel uevent.
+type UEventAction string
+
+const (
+	UEventActionAdd     UEventAction = "add"
+	UEventActionRemove  UEventAction = "remove"
+	UEventActionChange  UEventAction = "change"
+	UEventActionMove    UEventAction = "move"
+	UEventActionOnline  UEventAction = "online"
+	UEventActionOffline UEventAction = "offline"
+	UEventActionBind    UEventAction = "bind"
+	UEventActionUnbind  UEventAction = "unbind"
+)
+
+// DefaultUEventActions are the actions that signal a block device appeared, disappeared or changed its content.
+var DefaultUEventActions = []UEventAction{UEventActionAdd, UEventActionRemove, UEventActionChange}
+
+// UEventSubsystemBlock is the subsystem of block device uevents.
+const UEventSubsystemBlock = "block"
+
+// UEvent is a kernel uevent as sent on the NETLINK_KOBJECT_UEVENT socket.
+type UEvent struct {
+	Action  UEventAction
+	DevPath string
+	// Env contains all key value pairs of the event, including the ones parsed into the fields below.
+	Env map[string]string
+
+	Subsystem string
+	// DevType is e.g. disk or partition for block devices.
+	DevType string
+	// DevName is the device node name relative to /dev, e.g. sdb or mapper/vg-lv.
+	DevName string
+	Major   int
+	Minor   int
+	SeqNum  uint64
+}
+
+// DevicePath returns the path of the device node of the event or an empty string if the event has no device node.
+func (e *UEvent) DevicePath() string {
+	if e.DevName == "" {
+		return ""
+	}
+	if strings.HasPrefix(e.DevName, "/") {
+		return e.DevName
+	}
+	return "/dev/" + e.DevName
+}
+
+// IsBlockDevice returns true if the event belongs to a block device with a device node.
+func (e *UEvent) IsBlockDevice() bool {
+	return e.Subsystem == UEventSubsystemBlock && e.DevName != ""
+}
+
+// Bytes encodes the event in the kernel wire format.
+func (e *UEvent) Bytes() []byte {
+	env := make(map[string]string, len(e.Env)+7)
+	for k, v := range e.Env {
+		env[k] = v
+	}
+	env["ACTION"] = string(e.Action)
+	env["DEVPATH"] = e.DevPath
+	for key, value := range map[string]string{
+		"SUBSYSTEM": e.Subsystem,
+		"DEVTYPE":   e.DevType,
+		"DEVNAME":   e.DevName,
+	} {
+		if value != "" {
+			env[key] = value
+		}
+	}
+	if e.Major != 0 || e.Minor != 0 {
+		env["MAJOR"], env["MINOR"] = strconv.Itoa(e.Major), strconv.Itoa(e.Minor)
+	}
+	if e.SeqNum != 0 {
+		env["SEQNUM"] = strconv.FormatUint(e.SeqNum, 10)
+	}
+
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	buf := bytes.Buffer{}
+	buf.WriteString(fmt.Sprintf("%s@%s", e.Action, e.DevPath))
+	buf.WriteByte(0)
+	for _, k := range keys {
+		buf.WriteString(fmt.Sprintf("%s=%s", k, env[k]))
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// ParseUEvent parses a kernel uevent in the wire format "ACTION@DEVPATH\0KEY=VALUE\0...".
+// Messages forwarded by udev (prefixed with "libudev") are not kernel uevents and are rejected.
+func ParseUEvent(msg []byte) (*UEvent, error) {
+	fields := bytes.Split(bytes.TrimRight(msg, "\x00"), []byte{0})
+	if len(fields) == 0 || len(fields[0]) == 0 {
+		return nil, fmt.Errorf("%w: empty message", ErrInvalidUEvent)
+	}
+	if bytes.HasPrefix(fields[0], []byte("libudev")) {
+		return nil, fmt.Errorf("%w: udev messages are not supported", ErrInvalidUEvent)
+	}
+	action, devPath, found := strings.Cut(string(fields[0]), "@")
+	if !found {
+		return nil, fmt.Errorf("%w: missing action in header %q", ErrInvalidUEvent, fields[0])
+	}
+
+	event := &UEvent{Action: UEventAction(action), DevPath: devPath, Env: make(map[string]string, len(fields)-1)}
+	for _, field := range fields[1:] {
+		if key, value, ok := strings.Cut(string(field), "="); ok {
+			event.Env[key] = value
+		}
+	}
+
+	event.Subsystem = event.Env["SUBSYSTEM"]
+	event.DevType = event.Env["DEVTYPE"]
+	event.DevName = event.Env["DEVNAME"]
+	for key, fieldPtr := range map[string]*int{
+		"MAJOR": &event.Major,
+		"MINOR": &event.Minor,
+	} {
+		if raw, ok := event.Env[key]; ok {
+			value, err := strconv.Atoi(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s is not a number: %v", ErrInvalidUEvent, key, err)
+			}
+			*fieldPtr = value
+		}
+	}
+	if raw, ok := event.Env["SEQNUM"]; ok {
+		seqNum, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: SEQNUM is not a number: %v", ErrInvalidUEvent, err)
+		}
+		event.SeqNum = seqNum
+	}
+
+	return event, nil
+}
+
+// UEventSource delivers raw kernel uevent messages.
+type UEventSource interface {
+	// Receive blocks until the next message arrives.
+	// After the source was closed, ErrUEventSourceClosed is returned.
+	Receive() ([]byte, error)
+	Close() error
+}
+
+// FakeUEventSource is a UEventSource that delivers emitted events, e.g. for tests.
+type FakeUEventSource struct {
+	messages chan []byte
+	done     chan struct{}
+	close    sync.Once
+}
+
+var _ UEventSource = &FakeUEventSource{}
+
+// NewFakeUEventSource creates a FakeUEventSource that buffers up to size messages.
+func NewFakeUEventSource(size int) *FakeUEventSource {
+	return &FakeUEventSource{
+		messages: make(chan []byte, size),
+		done:     make(chan struct{}),
+	}
+}
+
+// Emit delivers the event to the next Receive call.
+func (s *FakeUEventSource) Emit(event *UEvent) error {
+	return s.EmitRaw(event.Bytes())
+}
+
+// EmitRaw delivers the raw message to the next Receive call.
+func (s *FakeUEventSource) EmitRaw(msg []byte) error {
+	select {
+	case <-s.done:
+		return ErrUEventSourceClosed
+	case s.messages <- msg:
+		return nil
+	}
+}
+
+func (s *FakeUEventSource) Receive() ([]byte, error) {
+	select {
+	case <-s.done:
+		return nil, ErrUEventSourceClosed
+	case msg := <-s.messages:
+		return msg, nil
+	}
+}
+
+func (s *FakeUEventSource) Close() error {
+	s.close.Do(func() {
+		close(s.done)
+	})
+	return nil
+}
+
+const (
+	lvm2LabelID       = "LABELONE"
+	lvm2LabelType     = "LVM2 001"
+	lvm2LabelSectors  = 4
+	lvm2SectorSize    = 512
+	lvm2LabelTypeAt   = 24
+	lvm2LabelMinBytes = lvm2LabelTypeAt + len(lvm2LabelType)
+)
+
+// HasLVM2Label returns true if the device carries an lvm2 physical volume label in one of its first four sectors.
+func HasLVM2Label(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		_ = f.Close()
+	}()
+	return ReadLVM2Label(f)
+}
+
+// ReadLVM2Label returns true if the reader contains an lvm2 physical volume label in one of its first four sectors.
+func ReadLVM2Label(r io.Reader) (bool, error) {
+	buf := make([]byte, lvm2LabelSectors*lvm2SectorSize)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+	for sector := 0; sector+lvm2LabelMinBytes <= n; sector += lvm2SectorSize {
+		label := buf[sector:]
+		if string(label[:len(lvm2LabelID)]) == lvm2LabelID &&
+			string(label[lvm2LabelTypeAt:lvm2LabelMinBytes]) == lvm2LabelType {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type (
+	// UEventHandler is called for every block device event that passed the filters of WatchUEvents.
+	UEventHandler func(ctx context.Context, event *UEvent) error
+
+	// UEventSignatureProbe decides whether the device of an event carries an lvm2 signature.
+	UEventSignatureProbe func(event *UEvent) (bool, error)
+
+	// UEventActions are the actions WatchUEvents reacts to.
+	UEventActions []UEventAction
+
+	// AnySignature makes WatchUEvents handle block devices regardless of their signature.
+	AnySignature bool
+
+	UEventWatchOptions struct {
+		UEventActions
+		UEventSignatureProbe
+		AnySignature
+
+		UEventHandlers []UEventHandler
+	}
+	UEventWatchOption interface {
+		ApplyToUEventWatchOptions(opts *UEventWatchOptions)
+	}
+)
+
+func (opt UEventHandler) ApplyToUEventWatchOptions(opts *UEventWatchOptions) {
+	opts.UEventHandlers = append(opts.UEventHandlers, opt)
+}
+
+func (opt UEventSignatureProbe) ApplyToUEventWatchOptions(opts *UEventWatchOptions) {
+	opts.UEventSignatureProbe = opt
+}
+
+func (opt UEventActions) ApplyToUEventWatchOptions(opts *UEventWatchOptions) {
+	opts.UEventActions = opt
+}
+
+func (opt AnySignature) ApplyToUEventWatchOptions(opts *UEventWatchOptions) {
+	opts.AnySignature = opt
+}
+
+func (opts *UEventWatchOptions) ApplyToUEventWatchOptions(new *UEventWatchOptions) {
+	*new = *opts
+}
+
+// ProbeLVM2Label is the default UEventSignatureProbe and reads the lvm2 label from the device node.
+func ProbeLVM2Label(event *UEvent) (bool, error) {
+	return HasLVM2Label(event.DevicePath())
+}
+
+// WatchUEvents receives events from the source until the context is done or the source fails,
+// and calls the handlers for block device events with one of the UEventActions (DefaultUEventActions if unset).
+// Added and changed devices are only handled if they carry an lvm2 label, unless AnySignature is set.
+// Removed devices cannot be probed anymore and are always handled.
+// Handler errors are logged and do not stop the watch. The source is closed when WatchUEvents returns.
+func WatchUEvents(ctx context.Context, source UEventSource, opts ...UEventWatchOption) error {
+	options := UEventWatchOptions{}
+	for _, opt := range opts {
+		opt.ApplyToUEventWatchOptions(&options)
+	}
+	if len(options.UEventActions) == 0 {
+		options.UEventActions = DefaultUEventActions
+	}
+	if options.UEventSignatureProbe == nil {
+		options.UEventSignatureProbe = ProbeLVM2Label
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = source.Close()
+	})
+	defer func() {
+		stop()
+		_ = source.Close()
+	}()
+
+	for {
+		msg, err := source.Receive()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			return err
+		}
+
+		event, err := ParseUEvent(msg)
+		if err != nil {
+			slog.DebugContext(ctx, "ignoring uevent", slog.String("error", err.Error()))
+			continue
+		}
+		if !event.IsBlockDevice() || !slices.Contains(options.UEventActions, event.Action) {
+			continue
+		}
+
+		if event.Action != UEventActionRemove && !options.AnySignature {
+			ok, err := options.UEventSignatureProbe(event)
+			if err != nil {
+				slog.DebugContext(ctx, "failed to probe device for lvm2 signature",
+					slog.String("device", event.DevicePath()),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		for _, handler := range options.UEventHandlers {
+			if err := handler(ctx, event); err != nil {
+				slog.ErrorContext(ctx, "failed to handle uevent",
+					slog.String("action", string(event.Action)),
+					slog.String("device", event.DevicePath()),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}
+}
+
+// PVScanUEventHandler returns a UEventHandler that replicates the event based autoactivation of lvm2:
+// devices that were added or changed are scanned with pvscan --cache --listvg --checkcomplete --autoactivation event
+// and complete volume groups are autoactivated. Devices that were removed are dropped from the pvscan cache
+// by their device number, as their device node no longer exists.
+// Additional PVScanOption can be passed to change the scan.
+// If onScan is not nil, it is called with the result of every scan, even if pvscan also reported an error.
+func PVScanUEventHandler(clnt PhysicalVolumeClient, onScan func(ctx context.Context, event *UEvent, res *PVScanResult), opts ...PVScanOption) UEventHandler {
+	return func(ctx context.Context, event *UEvent) error {
+		var scan []PVScanOption
+		switch event.Action {
+		case UEventActionAdd, UEventActionChange:
+			scan = []PVScanOption{
+				PhysicalVolumeName(event.DevicePath()),
+				PVScanCache(true),
+				ListVG(true),
+				CheckComplete(true),
+				AutoActivationEvent(true),
+				AutoActivate,
+			}
+		case UEventActionRemove:
+			device := event.DevicePath()
+			if event.Major != 0 || event.Minor != 0 {
+				device = fmt.Sprintf("%d:%d", event.Major, event.Minor)
+			}
+			scan = []PVScanOption{
+				PhysicalVolumeName(device),
+				PVScanCache(true),
+			}
+		default:
+			return nil
+		}
+		res, err := clnt.PVScan(ctx, append(scan, opts...)...)
+		if res != nil && onScan != nil {
+			onScan(ctx, event, res)
+		}
+		return err
+	}
+}
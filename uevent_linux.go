@@ -0,0 +1,69 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// netlinkUEventBufferSize is large enough for any kernel uevent, which is limited to a single page.
+const netlinkUEventBufferSize = 64 * 1024
+
+type netlinkUEventSource struct {
+	file *os.File
+	buf  []byte
+}
+
+// NewNetlinkUEventSource opens a NETLINK_KOBJECT_UEVENT socket that receives the kernel uevents of all devices.
+// When running containerized, the socket only receives events if the container shares the network namespace of the host.
+func NewNetlinkUEventSource() (UEventSource, error) {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, syscall.NETLINK_KOBJECT_UEVENT)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	// group 1 carries the kernel events, group 2 the events forwarded by udev
+	if err := syscall.Bind(fd, &syscall.SockaddrNetlink{Family: syscall.AF_NETLINK, Groups: 1}); err != nil {
+		_ = syscall.Close(fd)
+		return nil, os.NewSyscallError("bind", err)
+	}
+	return &netlinkUEventSource{
+		file: os.NewFile(uintptr(fd), "uevent"),
+		buf:  make([]byte, netlinkUEventBufferSize),
+	}, nil
+}
+
+func (s *netlinkUEventSource) Receive() ([]byte, error) {
+	n, err := s.file.Read(s.buf)
+	if errors.Is(err, os.ErrClosed) {
+		return nil, ErrUEventSourceClosed
+	}
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, n)
+	copy(msg, s.buf[:n])
+	return msg, nil
+}
+
+func (s *netlinkUEventSource) Close() error {
+	if err := s.file.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+		return err
+	}
+	return nil
+}
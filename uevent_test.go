@@ -0,0 +1,219 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestParseUEvent(t *testing.T) {
+	t.Parallel()
+	raw := []byte("add@/devices/virtual/block/loop0\x00ACTION=add\x00DEVPATH=/devices/virtual/block/loop0\x00" +
+		"SUBSYSTEM=block\x00MAJOR=7\x00MINOR=0\x00DEVNAME=loop0\x00DEVTYPE=disk\x00SEQNUM=4711\x00")
+	event, err := ParseUEvent(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event.Action != UEventActionAdd || event.DevPath != "/devices/virtual/block/loop0" ||
+		event.Major != 7 || event.Minor != 0 || event.SeqNum != 4711 || event.DevType != "disk" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+	if !event.IsBlockDevice() || event.DevicePath() != "/dev/loop0" {
+		t.Fatalf("expected block device /dev/loop0, got %q", event.DevicePath())
+	}
+
+	roundTrip, err := ParseUEvent(event.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if roundTrip.DevicePath() != event.DevicePath() || roundTrip.SeqNum != event.SeqNum {
+		t.Fatalf("expected %+v after round trip, got %+v", event, roundTrip)
+	}
+
+	for _, invalid := range [][]byte{
+		nil,
+		[]byte("libudev\x00\xfe\xed\xca\xfe"),
+		[]byte("add\x00SUBSYSTEM=block\x00"),
+		[]byte("add@/devices/x\x00MAJOR=x\x00"),
+	} {
+		if _, err := ParseUEvent(invalid); !errors.Is(err, ErrInvalidUEvent) {
+			t.Fatalf("expected %q to be invalid, got %v", invalid, err)
+		}
+	}
+}
+
+func TestReadLVM2Label(t *testing.T) {
+	t.Parallel()
+	sector := func(id, typ string) []byte {
+		buf := make([]byte, 512)
+		copy(buf, id)
+		copy(buf[24:], typ)
+		return buf
+	}
+	for _, tc := range []struct {
+		name  string
+		data  []byte
+		label bool
+	}{
+		{"first sector", sector("LABELONE", "LVM2 001"), true},
+		{"second sector", append(make([]byte, 512), sector("LABELONE", "LVM2 001")...), true},
+		{"fifth sector", append(make([]byte, 4*512), sector("LABELONE", "LVM2 001")...), false},
+		{"other type", sector("LABELONE", "OTHER001"), false},
+		{"empty", nil, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			label, err := ReadLVM2Label(bytes.NewReader(tc.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if label != tc.label {
+				t.Fatalf("expected label %v, got %v", tc.label, label)
+			}
+		})
+	}
+}
+
+func TestWatchUEvents(t *testing.T) {
+	t.Parallel()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	source := NewFakeUEventSource(16)
+	for _, event := range []*UEvent{
+		{Action: UEventActionAdd, DevPath: "/devices/pci/net/eth0", Subsystem: "net"},
+		{Action: UEventActionAdd, DevPath: "/devices/virtual/block/loop0", Subsystem: "block", DevName: "loop0", SeqNum: 1},
+		{Action: UEventActionAdd, DevPath: "/devices/virtual/block/loop1", Subsystem: "block", DevName: "loop1", SeqNum: 2},
+		{Action: UEventActionOnline, DevPath: "/devices/virtual/block/loop0", Subsystem: "block", DevName: "loop0", SeqNum: 3},
+		{Action: UEventActionChange, DevPath: "/devices/virtual/block/loop0", Subsystem: "block", DevName: "loop0", SeqNum: 4},
+		{Action: UEventActionRemove, DevPath: "/devices/virtual/block/loop1", Subsystem: "block", DevName: "loop1", SeqNum: 5},
+	} {
+		if err := source.Emit(event); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := source.EmitRaw([]byte("garbage")); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var handled []uint64
+	probe := UEventSignatureProbe(func(event *UEvent) (bool, error) {
+		return event.DevName == "loop0", nil
+	})
+	handler := UEventHandler(func(ctx context.Context, event *UEvent) error {
+		mu.Lock()
+		defer mu.Unlock()
+		handled = append(handled, event.SeqNum)
+		if event.Action == UEventActionRemove {
+			cancel()
+		}
+		return errors.New("handler errors do not stop the watch")
+	})
+
+	if err := WatchUEvents(ctx, source, probe, handler); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected watch to stop with the context, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	expected := []uint64{1, 4, 5}
+	if len(handled) != len(expected) || handled[0] != 1 || handled[1] != 4 || handled[2] != 5 {
+		t.Fatalf("expected handled events %v, got %v", expected, handled)
+	}
+	if _, err := source.Receive(); !errors.Is(err, ErrUEventSourceClosed) {
+		t.Fatalf("expected source to be closed, got %v", err)
+	}
+}
+
+type pvScanRecorder struct {
+	PhysicalVolumeClient
+	args [][]string
+}
+
+func (r *pvScanRecorder) PVScan(_ context.Context, opts ...PVScanOption) (*PVScanResult, error) {
+	args, err := PVScanOptionsList(opts).AsArgs()
+	if err != nil {
+		return nil, err
+	}
+	r.args = append(r.args, args.GetRaw())
+	return &PVScanResult{}, nil
+}
+
+func TestPVScanUEventHandler(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	recorder := &pvScanRecorder{}
+	scanned := 0
+	handler := PVScanUEventHandler(recorder, func(_ context.Context, _ *UEvent, _ *PVScanResult) {
+		scanned++
+	})
+
+	for _, event := range []*UEvent{
+		{Action: UEventActionAdd, Subsystem: "block", DevName: "loop0", Major: 7, Minor: 0},
+		{Action: UEventActionOnline, Subsystem: "block", DevName: "loop0", Major: 7, Minor: 0},
+		{Action: UEventActionRemove, Subsystem: "block", DevName: "loop0", Major: 7, Minor: 0},
+	} {
+		if err := handler(ctx, event); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := [][]string{
+		{"--cache", "--listvg", "--checkcomplete", "--autoactivation=event", "--activate", "ay", "--yes", "/dev/loop0"},
+		{"--cache", "--yes", "7:0"},
+	}
+	if scanned != len(expected) || !slices.EqualFunc(recorder.args, expected, slices.Equal[[]string]) {
+		t.Fatalf("expected scans %v, got %v", expected, recorder.args)
+	}
+}
+
+func TestNetlinkUEventSource(t *testing.T) {
+	t.Parallel()
+	source, err := NewNetlinkUEventSource()
+	if errors.Is(err, ErrUEventsNotSupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Skipf("netlink uevent socket is not available: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := source.Receive()
+		done <- err
+	}()
+	if err := source.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != nil && !errors.Is(err, ErrUEventSourceClosed) {
+			t.Fatalf("expected closed source, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Receive to return after Close")
+	}
+}
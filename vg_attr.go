@@ -43,6 +43,11 @@ const (
 	ExportedFalse Exported = '-'
 )
 
+// IsExported returns true if the volume group or physical volume was exported with VGExport.
+func (opt Exported) IsExported() bool {
+	return opt == ExportedTrue
+}
+
 type PartialAttr rune
 
 const (
@@ -32,6 +32,19 @@ type (
 		Tags
 		DelTags
 
+		ActivationState
+		AutoActivationEvent
+		LockType
+		LockStart
+		LockStop
+		LockOpt
+
+		SystemID
+		ClearSystemID
+		ExtraSystemIDs
+
+		*WaitForDeviceOptions
+
 		CommonOptions
 	}
 	VGChangeOption interface {
@@ -46,12 +59,24 @@ var (
 )
 
 func (c *client) VGChange(ctx context.Context, opts ...VGChangeOption) error {
-	args, err := VGChangeOptionsList(opts).AsArgs()
+	options := VGChangeOptions{}
+	for _, opt := range opts {
+		opt.ApplyToVGChangeOptions(&options)
+	}
+
+	args, err := VGChangeOptionsList{&options}.AsArgs()
 	if err != nil {
 		return err
 	}
 
-	return c.RunLVM(ctx, append([]string{"vgchange"}, args.GetRaw()...)...)
+	if err := c.RunLVM(ctx, append([]string{"vgchange"}, args.GetRaw()...)...); err != nil {
+		return err
+	}
+
+	if options.WaitForDeviceOptions == nil || !options.ActivationState.IsActivating() {
+		return nil
+	}
+	return c.waitForActivatedDevices(ctx, options.VolumeGroupName, "", *options.WaitForDeviceOptions)
 }
 
 func (list VGChangeOptionsList) AsArgs() (Arguments, error) {
@@ -67,8 +92,16 @@ func (list VGChangeOptionsList) AsArgs() (Arguments, error) {
 }
 
 func (opts *VGChangeOptions) ApplyToArgs(args Arguments) error {
-	if opts.VolumeGroupName == "" {
-		return fmt.Errorf("VolumeGroupName is required for creation of a volume group")
+	if bool(opts.LockStart) && bool(opts.LockStop) {
+		return ErrLockStartAndLockStop
+	}
+	if opts.SystemID != "" && bool(opts.ClearSystemID) {
+		return ErrSystemIDAndClearSystemID
+	}
+
+	// lockspaces can be started and stopped for all shared volume groups at once
+	if opts.VolumeGroupName == "" && !bool(opts.LockStart) && !bool(opts.LockStop) {
+		return fmt.Errorf("VolumeGroupName is required for changing a volume group")
 	}
 
 	for _, opt := range []Argument{
@@ -79,6 +112,15 @@ func (opts *VGChangeOptions) ApplyToArgs(args Arguments) error {
 		opts.AutoActivation,
 		opts.Tags,
 		opts.DelTags,
+		opts.ActivationState,
+		opts.AutoActivationEvent,
+		opts.LockType,
+		opts.LockStart,
+		opts.LockStop,
+		opts.LockOpt,
+		opts.SystemID,
+		opts.ClearSystemID,
+		opts.ExtraSystemIDs,
 		opts.CommonOptions,
 	} {
 		if err := opt.ApplyToArgs(args); err != nil {
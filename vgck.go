@@ -0,0 +1,266 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UpdateMetadata makes vgck repair inconsistent or outdated metadata with the latest valid copy.
+type UpdateMetadata bool
+
+func (opt UpdateMetadata) ApplyToVGCheckOptions(opts *VGCheckOptions) {
+	opts.UpdateMetadata = opt
+}
+
+func (opt UpdateMetadata) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--updatemetadata")
+	}
+	return nil
+}
+
+type (
+	VGCheckOptions struct {
+		// VolumeGroupName limits the check to a single volume group. If empty, all volume groups are checked.
+		VolumeGroupName
+		UpdateMetadata
+
+		CommonOptions
+	}
+	VGCheckOption interface {
+		ApplyToVGCheckOptions(opts *VGCheckOptions)
+	}
+	VGCheckOptionsList []VGCheckOption
+)
+
+var (
+	_ ArgumentGenerator = VGCheckOptionsList{}
+	_ Argument          = (*VGCheckOptions)(nil)
+)
+
+// VGCheckResult is the typed output of vgck.
+type VGCheckResult struct {
+	// VolumeGroupName is the checked volume group. It is empty if all volume groups were checked.
+	VolumeGroupName
+
+	// SeqNo is the latest metadata sequence number vgck reported, 0 if no sequence number was reported.
+	SeqNo int64
+	// MetadataAreas are the metadata areas vgck reported messages about.
+	MetadataAreas []*VGCheckMetadataArea
+	// Problems are the messages vgck reported about the checked metadata.
+	// Other warnings, e.g. about the devices file or old physical volume headers, are not included.
+	Problems []string
+	// InconsistentMetadata is true if vgck found corrupted or inconsistent metadata.
+	InconsistentMetadata bool
+}
+
+// VGCheckMetadataArea is a metadata area on a physical volume as reported by vgck.
+type VGCheckMetadataArea struct {
+	PhysicalVolumeName
+	// Number is the number of the metadata area on the physical volume, 0 if vgck did not report it.
+	Number int
+	// Offset is the location of the metadata text on the device in bytes, 0 if vgck did not report it.
+	Offset int64
+	// SeqNo is the metadata sequence number found in the metadata area, 0 if vgck did not report it.
+	SeqNo int64
+	// Outdated is true if the metadata area holds an older sequence number than the volume group.
+	Outdated bool
+	// Problems are the messages vgck reported for the metadata area.
+	Problems []string
+}
+
+// IsValid returns true if vgck did not report any problems.
+func (res *VGCheckResult) IsValid() bool {
+	return len(res.Problems) == 0
+}
+
+// IsValid returns true if the metadata area holds the latest metadata and vgck did not report any problems for it.
+func (area *VGCheckMetadataArea) IsValid() bool {
+	return !area.Outdated && len(area.Problems) == 0
+}
+
+// MetadataArea returns the metadata area on the physical volume with the given number
+// or nil if it was not reported. Number 0 matches areas whose number was not reported.
+func (res *VGCheckResult) MetadataArea(pv PhysicalVolumeName, number int) *VGCheckMetadataArea {
+	for _, area := range res.MetadataAreas {
+		if area.PhysicalVolumeName == pv && area.Number == number {
+			return area
+		}
+	}
+	return nil
+}
+
+func (c *client) VGCheck(ctx context.Context, opts ...VGCheckOption) (*VGCheckResult, error) {
+	options := VGCheckOptions{}
+	for _, opt := range opts {
+		opt.ApplyToVGCheckOptions(&options)
+	}
+
+	args, err := VGCheckOptionsList{&options}.AsArgs()
+	if err != nil {
+		return nil, err
+	}
+
+	var res *VGCheckResult
+	err = c.RunLVMRaw(ctx, func(out io.Reader) error {
+		var err error
+		res, err = ParseVGCheckOutput(out)
+		return err
+	}, append([]string{"vgck"}, args.GetRaw()...)...)
+	if res == nil {
+		return nil, err
+	}
+	res.VolumeGroupName = options.VolumeGroupName
+
+	// vgck reports its findings as warnings and errors, which end up in stderr even if the check succeeds.
+	if stdErr, ok := AsLVMStdErr(err); ok {
+		for _, line := range stdErr.Lines(true) {
+			res.addLine(string(line))
+		}
+	}
+
+	return res, err
+}
+
+// ParseVGCheckOutput parses the output of vgck.
+func ParseVGCheckOutput(out io.Reader) (*VGCheckResult, error) {
+	res := &VGCheckResult{}
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		res.addLine(scanner.Text())
+	}
+	return res, scanner.Err()
+}
+
+var (
+	// vgCheckIgnoredSeqNoPattern matches the message about a metadata area holding an older sequence number than another one.
+	vgCheckIgnoredSeqNoPattern = regexp.MustCompile(`ignoring metadata seqno (\d+) on (\S+) for seqno (\d+) on (\S+)`)
+	// vgCheckAreaPatterns match the messages vgck reports about a single metadata area.
+	// The named groups pv, mda, offset and seqno describe the affected metadata area,
+	// the named group vgseqno is the current sequence number of the volume group.
+	vgCheckAreaPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`outdated PV (?P<pv>\S+) seqno (?P<seqno>\d+) has been removed in current VG \S+ seqno (?P<vgseqno>\d+)`),
+		regexp.MustCompile(`(?P<pv>/\S+): Checksum error at offset (?P<offset>\d+)`),
+		regexp.MustCompile(`[Ss]canning (?P<pv>\S+) mda(?P<mda>\d+) failed to read metadata summary`),
+		regexp.MustCompile(`[Bb]ad metadata text on (?P<pv>\S+) in mda(?P<mda>\d+)`),
+		regexp.MustCompile(`[Ii]nvalid metadata text from (?P<pv>\S+) at (?P<offset>\d+)`),
+		regexp.MustCompile(`Metadata location on (?P<pv>\S+) at (?P<offset>\d+) has invalid summary`),
+		regexp.MustCompile(`[Bb]ad (?:metadata|mda) header on (?P<pv>\S+?)(?: at (?P<offset>\d+))?\.?$`),
+	}
+)
+
+func (res *VGCheckResult) addLine(raw string) {
+	line := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(raw), LVMWarningPrefix))
+	if line == "" {
+		return
+	}
+
+	if matches := vgCheckIgnoredSeqNoPattern.FindStringSubmatch(line); matches != nil {
+		outdated := res.metadataArea(PhysicalVolumeName(matches[2]), 0)
+		outdated.SeqNo, _ = strconv.ParseInt(matches[1], 10, 64)
+		outdated.Outdated = true
+		outdated.Problems = append(outdated.Problems, line)
+		latest := res.metadataArea(PhysicalVolumeName(matches[4]), 0)
+		latest.SeqNo, _ = strconv.ParseInt(matches[3], 10, 64)
+		res.SeqNo = max(res.SeqNo, latest.SeqNo)
+		res.addProblem(line)
+		return
+	}
+
+	for _, pattern := range vgCheckAreaPatterns {
+		matches := pattern.FindStringSubmatch(line)
+		if matches == nil {
+			continue
+		}
+		var number int
+		if idx := pattern.SubexpIndex("mda"); idx > 0 {
+			number, _ = strconv.Atoi(matches[idx])
+		}
+		area := res.metadataArea(PhysicalVolumeName(matches[pattern.SubexpIndex("pv")]), number)
+		if idx := pattern.SubexpIndex("offset"); idx > 0 && matches[idx] != "" {
+			area.Offset, _ = strconv.ParseInt(matches[idx], 10, 64)
+		}
+		if idx := pattern.SubexpIndex("seqno"); idx > 0 {
+			area.SeqNo, _ = strconv.ParseInt(matches[idx], 10, 64)
+			area.Outdated = true
+		}
+		if idx := pattern.SubexpIndex("vgseqno"); idx > 0 {
+			seqNo, _ := strconv.ParseInt(matches[idx], 10, 64)
+			res.SeqNo = max(res.SeqNo, seqNo)
+		}
+		area.Problems = append(area.Problems, line)
+		res.addProblem(line)
+		return
+	}
+
+	if InconsistentMetadataPattern.MatchString(line) {
+		res.addProblem(line)
+	}
+}
+
+func (res *VGCheckResult) addProblem(line string) {
+	res.Problems = append(res.Problems, line)
+	if InconsistentMetadataPattern.MatchString(line) {
+		res.InconsistentMetadata = true
+	}
+}
+
+// metadataArea returns the reported metadata area, adding it if it was not reported before.
+func (res *VGCheckResult) metadataArea(pv PhysicalVolumeName, number int) *VGCheckMetadataArea {
+	area := res.MetadataArea(pv, number)
+	if area == nil {
+		area = &VGCheckMetadataArea{PhysicalVolumeName: pv, Number: number}
+		res.MetadataAreas = append(res.MetadataAreas, area)
+	}
+	return area
+}
+
+func (list VGCheckOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := VGCheckOptions{}
+	for _, opt := range list {
+		opt.ApplyToVGCheckOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *VGCheckOptions) ApplyToVGCheckOptions(new *VGCheckOptions) {
+	*new = *opts
+}
+
+func (opts *VGCheckOptions) ApplyToArgs(args Arguments) error {
+	for _, arg := range []Argument{
+		opts.VolumeGroupName,
+		opts.UpdateMetadata,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -40,6 +40,8 @@ type (
 		MetadataSize
 		AllocationPolicy
 		Shared
+		LockType
+		SystemID
 
 		CommonOptions
 	}
@@ -99,6 +101,8 @@ func (opts *VGCreateOptions) ApplyToArgs(args Arguments) error {
 		opts.AllocationPolicy,
 		opts.AutoActivation,
 		opts.Shared,
+		opts.LockType,
+		opts.SystemID,
 		opts.CommonOptions,
 	} {
 		if err := opt.ApplyToArgs(args); err != nil {
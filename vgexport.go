@@ -0,0 +1,83 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"fmt"
+)
+
+type (
+	VGExportOptions struct {
+		VolumeGroupName
+		AllVolumeGroups
+
+		CommonOptions
+	}
+	VGExportOption interface {
+		ApplyToVGExportOptions(opts *VGExportOptions)
+	}
+	VGExportOptionsList []VGExportOption
+)
+
+var (
+	_ ArgumentGenerator = VGExportOptionsList{}
+	_ Argument          = (*VGExportOptions)(nil)
+)
+
+func (c *client) VGExport(ctx context.Context, opts ...VGExportOption) error {
+	args, err := VGExportOptionsList(opts).AsArgs()
+	if err != nil {
+		return err
+	}
+
+	return NewDuplicatePVError(c.RunLVM(ctx, append([]string{"vgexport"}, args.GetRaw()...)...))
+}
+
+func (list VGExportOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := VGExportOptions{}
+	for _, opt := range list {
+		opt.ApplyToVGExportOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *VGExportOptions) ApplyToVGExportOptions(new *VGExportOptions) {
+	*new = *opts
+}
+
+func (opts *VGExportOptions) ApplyToArgs(args Arguments) error {
+	if opts.VolumeGroupName == "" && !opts.AllVolumeGroups {
+		return fmt.Errorf("VolumeGroupName or AllVolumeGroups is required for exporting volume groups")
+	}
+
+	for _, arg := range []Argument{
+		opts.VolumeGroupName,
+		opts.AllVolumeGroups,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestVGImportExportOptions(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts ArgumentGenerator
+		args []string
+		err  bool
+	}{
+		{"export", VGExportOptionsList{VolumeGroupName("vg")}, []string{"vg", "--yes"}, false},
+		{"export all", VGExportOptionsList{AllVolumeGroups(true)}, []string{"--all", "--yes"}, false},
+		{"export without name", VGExportOptionsList{}, nil, true},
+		{"import missing", VGImportOptionsList{VolumeGroupName("vg"), Force(true)}, []string{"vg", "--force", "--yes"}, false},
+		{"import all", VGImportOptionsList{AllVolumeGroups(true)}, []string{"--all", "--yes"}, false},
+		{"import without name", VGImportOptionsList{}, nil, true},
+		{
+			"import clone",
+			VGImportCloneOptionsList{PhysicalVolumeNames{"/dev/sdb", "/dev/sdc"}, BaseVolumeGroupName("vg-clone"), ImportClone(true)},
+			[]string{"--basevgname=vg-clone", "--import", "--yes", "/dev/sdb", "/dev/sdc"},
+			false,
+		},
+		{"import clone without pvs", VGImportCloneOptionsList{BaseVolumeGroupName("vg-clone")}, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestNewDuplicatePVError(t *testing.T) {
+	t.Parallel()
+	stderr := NewLVMStdErr([]byte(`  WARNING: Not using device /dev/sdc for PV wNxQfn-9K2a-2tQ3-3vRe-Yzkk-uWiu-3Jf9kb.
+  WARNING: PV wNxQfn-9K2a-2tQ3-3vRe-Yzkk-uWiu-3Jf9kb prefers device /dev/sdb because device was seen first.
+  Cannot use device /dev/sdc with duplicates.`))
+
+	err := NewDuplicatePVError(stderr)
+	if !IsDuplicatePV(err) || !errors.Is(err, ErrDuplicatePV) {
+		t.Fatalf("expected duplicate pv error, got %v", err)
+	}
+	var dupErr *DuplicatePVError
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("expected DuplicatePVError, got %T", err)
+	}
+	expected := []DuplicatePV{
+		{UUID: "wNxQfn-9K2a-2tQ3-3vRe-Yzkk-uWiu-3Jf9kb", Device: "/dev/sdc"},
+		{UUID: "wNxQfn-9K2a-2tQ3-3vRe-Yzkk-uWiu-3Jf9kb"},
+	}
+	if !slices.Equal(dupErr.Duplicates, expected) {
+		t.Fatalf("expected duplicates %v, got %v", expected, dupErr.Duplicates)
+	}
+	if _, ok := AsLVMStdErr(err); !ok {
+		t.Fatal("expected the original stderr to be preserved")
+	}
+	if !dupErr.Succeeded {
+		t.Fatal("expected duplicates reported without exit code to be warnings of a successful command")
+	}
+
+	failed := errors.Join(stderr, NewExitCodeError(errors.New("exit status 5")))
+	if !errors.As(NewDuplicatePVError(failed), &dupErr) || dupErr.Succeeded {
+		t.Fatalf("expected duplicates of a failed command, got %v", dupErr)
+	}
+
+	other := NewLVMStdErr([]byte("  Volume group \"vg\" not found"))
+	if err := NewDuplicatePVError(other); err != other || IsDuplicatePV(err) {
+		t.Fatalf("expected unrelated error to be returned as is, got %v", err)
+	}
+}
+
+func TestVGExportImport(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+
+	clnt := NewClient()
+	ctx := context.Background()
+
+	test := test{
+		LoopDevices: []Size{
+			MustParseSize("10M"),
+		},
+	}
+
+	infra := test.SetupDevicesAndVolumeGroup(t)
+	name := infra.volumeGroup.Name
+
+	if err := clnt.VGExport(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	vg, err := clnt.VG(ctx, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !vg.Attr.IsExported() {
+		t.Fatalf("expected volume group %s to be exported", name)
+	}
+
+	if err := clnt.VGImport(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	if vg, err = clnt.VG(ctx, name); err != nil {
+		t.Fatal(err)
+	}
+	if vg.Attr.IsExported() {
+		t.Fatalf("expected volume group %s to be imported", name)
+	}
+}
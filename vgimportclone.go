@@ -0,0 +1,132 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"fmt"
+)
+
+// BaseVolumeGroupName is the name of the volume group created by VGImportClone.
+// If the name is already taken, lvm2 appends a number to it.
+type BaseVolumeGroupName VolumeGroupName
+
+func (opt BaseVolumeGroupName) ApplyToVGImportCloneOptions(opts *VGImportCloneOptions) {
+	opts.BaseVolumeGroupName = opt
+}
+
+func (opt BaseVolumeGroupName) ApplyToArgs(args Arguments) error {
+	if opt == "" {
+		return nil
+	}
+	args.AddOrReplace(fmt.Sprintf("--basevgname=%s", string(opt)))
+	return nil
+}
+
+// ImportClone imports the cloned volume group if the original volume group was exported.
+type ImportClone bool
+
+func (opt ImportClone) ApplyToVGImportCloneOptions(opts *VGImportCloneOptions) {
+	opts.ImportClone = opt
+}
+
+func (opt ImportClone) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--import")
+	}
+	return nil
+}
+
+// ImportDevices adds the cloned physical volumes to the devices file.
+type ImportDevices bool
+
+func (opt ImportDevices) ApplyToVGImportCloneOptions(opts *VGImportCloneOptions) {
+	opts.ImportDevices = opt
+}
+
+func (opt ImportDevices) ApplyToArgs(args Arguments) error {
+	if opt {
+		args.AddOrReplace("--importdevices")
+	}
+	return nil
+}
+
+type (
+	VGImportCloneOptions struct {
+		// PhysicalVolumeNames are the duplicated physical volumes that receive new uuids and form the new volume group.
+		PhysicalVolumeNames
+		BaseVolumeGroupName
+		ImportClone
+		ImportDevices
+
+		CommonOptions
+	}
+	VGImportCloneOption interface {
+		ApplyToVGImportCloneOptions(opts *VGImportCloneOptions)
+	}
+	VGImportCloneOptionsList []VGImportCloneOption
+)
+
+var (
+	_ ArgumentGenerator = VGImportCloneOptionsList{}
+	_ Argument          = (*VGImportCloneOptions)(nil)
+)
+
+func (c *client) VGImportClone(ctx context.Context, opts ...VGImportCloneOption) error {
+	args, err := VGImportCloneOptionsList(opts).AsArgs()
+	if err != nil {
+		return err
+	}
+
+	return NewDuplicatePVError(c.RunLVM(ctx, append([]string{"vgimportclone"}, args.GetRaw()...)...))
+}
+
+func (list VGImportCloneOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := VGImportCloneOptions{}
+	for _, opt := range list {
+		opt.ApplyToVGImportCloneOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *VGImportCloneOptions) ApplyToVGImportCloneOptions(new *VGImportCloneOptions) {
+	*new = *opts
+}
+
+func (opts *VGImportCloneOptions) ApplyToArgs(args Arguments) error {
+	if len(opts.PhysicalVolumeNames) == 0 {
+		return fmt.Errorf("PhysicalVolumeNames is required for cloning a volume group")
+	}
+
+	for _, arg := range []Argument{
+		opts.BaseVolumeGroupName,
+		opts.ImportClone,
+		opts.ImportDevices,
+		opts.CommonOptions,
+		opts.PhysicalVolumeNames,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
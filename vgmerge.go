@@ -0,0 +1,152 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrLogicalVolumeNamesConflict = errors.New("logical volume names exist in both volume groups")
+
+// MergeConflictError is returned by VGMerge if both volume groups contain logical volumes with the same name.
+type MergeConflictError struct {
+	Source, Destination VolumeGroupName
+	LogicalVolumes      []LogicalVolumeName
+}
+
+func (e *MergeConflictError) Error() string {
+	names := make([]string, len(e.LogicalVolumes))
+	for i, lv := range e.LogicalVolumes {
+		names[i] = string(lv)
+	}
+	return fmt.Sprintf("cannot merge %s into %s: %s: %s",
+		e.Source, e.Destination, ErrLogicalVolumeNamesConflict, strings.Join(names, ", "))
+}
+
+func (e *MergeConflictError) Unwrap() error {
+	return ErrLogicalVolumeNamesConflict
+}
+
+type (
+	VGMergeOptions struct {
+		Destination VolumeGroupName
+		Source      VolumeGroupName
+
+		CommonOptions
+	}
+	VGMergeOption interface {
+		ApplyToVGMergeOptions(opts *VGMergeOptions)
+	}
+	VGMergeOptionsList []VGMergeOption
+)
+
+// SetDestinationOrSource sets the destination volume group first and the source volume group second,
+// following the order of vgmerge.
+func (opts *VGMergeOptions) SetDestinationOrSource(name VolumeGroupName) {
+	if opts.Destination == "" {
+		opts.Destination = name
+	} else if opts.Source == "" {
+		opts.Source = name
+	} else {
+		opts.Destination = opts.Source
+		opts.Source = name
+	}
+}
+
+var (
+	_ ArgumentGenerator = VGMergeOptionsList{}
+	_ Argument          = (*VGMergeOptions)(nil)
+)
+
+func (c *client) VGMerge(ctx context.Context, opts ...VGMergeOption) error {
+	options := VGMergeOptions{}
+	for _, opt := range opts {
+		opt.ApplyToVGMergeOptions(&options)
+	}
+
+	args, err := VGMergeOptionsList{&options}.AsArgs()
+	if err != nil {
+		return err
+	}
+
+	destination, err := c.LVs(ctx, options.Destination)
+	if err != nil {
+		return fmt.Errorf("failed to read logical volumes of %s before merge: %w", options.Destination, err)
+	}
+	source, err := c.LVs(ctx, options.Source)
+	if err != nil {
+		return fmt.Errorf("failed to read logical volumes of %s before merge: %w", options.Source, err)
+	}
+
+	var conflicts []LogicalVolumeName
+	for _, src := range source {
+		for _, dst := range destination {
+			if src.Name == dst.Name {
+				conflicts = append(conflicts, src.Name)
+			}
+		}
+	}
+	if len(conflicts) > 0 {
+		return &MergeConflictError{
+			Source:         options.Source,
+			Destination:    options.Destination,
+			LogicalVolumes: conflicts,
+		}
+	}
+
+	return c.RunLVM(ctx, append([]string{"vgmerge"}, args.GetRaw()...)...)
+}
+
+func (list VGMergeOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := VGMergeOptions{}
+	for _, opt := range list {
+		opt.ApplyToVGMergeOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *VGMergeOptions) ApplyToVGMergeOptions(new *VGMergeOptions) {
+	*new = *opts
+}
+
+func (opts *VGMergeOptions) ApplyToArgs(args Arguments) error {
+	if opts.Destination == "" {
+		return fmt.Errorf("destination is empty: %w", ErrVolumeGroupNameRequired)
+	}
+	if opts.Source == "" {
+		return fmt.Errorf("source is empty: %w", ErrVolumeGroupNameRequired)
+	}
+
+	for _, arg := range []Argument{
+		opts.Destination,
+		opts.Source,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -26,6 +26,7 @@ type (
 		Tags
 		Unit
 		Select
+		Foreign
 
 		ColumnOptions
 		CommonOptions
@@ -110,6 +111,7 @@ func (opts *VGsOptions) ApplyToArgs(args Arguments) error {
 		opts.CommonOptions,
 		opts.ColumnOptions,
 		opts.Select,
+		opts.Foreign,
 	} {
 		if err := arg.ApplyToArgs(args); err != nil {
 			return err
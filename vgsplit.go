@@ -0,0 +1,261 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+var ErrLogicalVolumesSpanSplit = errors.New("logical volumes would span both volume groups after the split")
+
+// SplitSpanError is returned by VGSplit if logical volumes have extents on physical volumes
+// that are moved to the new volume group as well as on physical volumes that stay in the old one.
+type SplitSpanError struct {
+	Source, Destination VolumeGroupName
+	LogicalVolumes      []LogicalVolumeName
+}
+
+func (e *SplitSpanError) Error() string {
+	names := make([]string, len(e.LogicalVolumes))
+	for i, lv := range e.LogicalVolumes {
+		names[i] = string(lv)
+	}
+	return fmt.Sprintf("cannot split %s into %s: %s: %s",
+		e.Source, e.Destination, ErrLogicalVolumesSpanSplit, strings.Join(names, ", "))
+}
+
+func (e *SplitSpanError) Unwrap() error {
+	return ErrLogicalVolumesSpanSplit
+}
+
+type (
+	VGSplitOptions struct {
+		Source      VolumeGroupName
+		Destination VolumeGroupName
+
+		// PhysicalVolumeNames are the physical volumes that are moved to the destination volume group.
+		PhysicalVolumeNames
+		// LogicalVolumeName moves all physical volumes of the logical volume to the destination volume group.
+		// It cannot be combined with PhysicalVolumeNames.
+		LogicalVolumeName
+
+		CommonOptions
+	}
+	VGSplitOption interface {
+		ApplyToVGSplitOptions(opts *VGSplitOptions)
+	}
+	VGSplitOptionsList []VGSplitOption
+)
+
+// SetSourceOrDestination sets the source volume group first and the destination volume group second,
+// following the order of vgsplit.
+func (opts *VGSplitOptions) SetSourceOrDestination(name VolumeGroupName) {
+	if opts.Source == "" {
+		opts.Source = name
+	} else if opts.Destination == "" {
+		opts.Destination = name
+	} else {
+		opts.Source = opts.Destination
+		opts.Destination = name
+	}
+}
+
+var (
+	_ ArgumentGenerator = VGSplitOptionsList{}
+	_ Argument          = (*VGSplitOptions)(nil)
+)
+
+func (c *client) VGSplit(ctx context.Context, opts ...VGSplitOption) error {
+	options := VGSplitOptions{}
+	for _, opt := range opts {
+		opt.ApplyToVGSplitOptions(&options)
+	}
+
+	args, err := VGSplitOptionsList{&options}.AsArgs()
+	if err != nil {
+		return err
+	}
+
+	segments, err := c.LVSegments(ctx, options.Source, ShowInternal(true))
+	if err != nil {
+		return fmt.Errorf("failed to read segments of %s before split: %w", options.Source, err)
+	}
+
+	lvs, err := c.LVs(ctx, options.Source)
+	if err != nil {
+		return fmt.Errorf("failed to read logical volumes of %s before split: %w", options.Source, err)
+	}
+
+	moving := options.PhysicalVolumeNames
+	if options.LogicalVolumeName != "" {
+		moving = SegmentPhysicalVolumes(segments, options.LogicalVolumeName)
+	} else {
+		pvs, err := c.PVs(ctx, options.Source)
+		if err != nil {
+			return fmt.Errorf("failed to read physical volumes of %s before split: %w", options.Source, err)
+		}
+		moving = ResolvePhysicalVolumeNames(moving, pvs)
+	}
+	if spanning := SplitSpanningLogicalVolumes(segments, lvs, moving); len(spanning) > 0 {
+		return &SplitSpanError{
+			Source:         options.Source,
+			Destination:    options.Destination,
+			LogicalVolumes: spanning,
+		}
+	}
+
+	return c.RunLVM(ctx, append([]string{"vgsplit"}, args.GetRaw()...)...)
+}
+
+func (list VGSplitOptionsList) AsArgs() (Arguments, error) {
+	args := NewArgs(ArgsTypeGeneric)
+	options := VGSplitOptions{}
+	for _, opt := range list {
+		opt.ApplyToVGSplitOptions(&options)
+	}
+	if err := options.ApplyToArgs(args); err != nil {
+		return nil, err
+	}
+	return args, nil
+}
+
+func (opts *VGSplitOptions) ApplyToVGSplitOptions(new *VGSplitOptions) {
+	*new = *opts
+}
+
+func (opts *VGSplitOptions) ApplyToArgs(args Arguments) error {
+	if opts.Source == "" {
+		return fmt.Errorf("source is empty: %w", ErrVolumeGroupNameRequired)
+	}
+	if opts.Destination == "" {
+		return fmt.Errorf("destination is empty: %w", ErrVolumeGroupNameRequired)
+	}
+	if len(opts.PhysicalVolumeNames) == 0 && opts.LogicalVolumeName == "" {
+		return fmt.Errorf("PhysicalVolumeNames or LogicalVolumeName is required for splitting a volume group")
+	}
+	if len(opts.PhysicalVolumeNames) > 0 && opts.LogicalVolumeName != "" {
+		return fmt.Errorf("PhysicalVolumeNames and LogicalVolumeName cannot be used together for splitting a volume group")
+	}
+
+	if opts.LogicalVolumeName != "" {
+		args.AddOrReplace(fmt.Sprintf("--name=%s", opts.LogicalVolumeName))
+	}
+
+	for _, arg := range []Argument{
+		opts.Source,
+		opts.Destination,
+		opts.PhysicalVolumeNames,
+		opts.CommonOptions,
+	} {
+		if err := arg.ApplyToArgs(args); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SegmentPhysicalVolumes returns the physical volumes the logical volume is allocated on,
+// following its internal logical volumes such as RAID images or thin pool data and metadata.
+// The segments need to include internal logical volumes, see ShowInternal.
+func SegmentPhysicalVolumes(segments []*LVSegment, lv LogicalVolumeName) PhysicalVolumeNames {
+	return newSegmentGraph(segments).physicalVolumes(internalLogicalVolumeName(string(lv)), nil)
+}
+
+// ResolvePhysicalVolumeNames returns the names lvm2 reports for the physical volumes, so that aliases
+// such as /dev/disk/by-id symlinks can be compared with the devices of segments.
+// Names that are not reported by lvm2 are matched by their device number, and kept as is if no physical volume matches.
+func ResolvePhysicalVolumeNames(names PhysicalVolumeNames, pvs []*PhysicalVolume) PhysicalVolumeNames {
+	resolved := make(PhysicalVolumeNames, 0, len(names))
+	for _, name := range names {
+		if !slices.ContainsFunc(pvs, func(pv *PhysicalVolume) bool { return pv.Name == name }) {
+			if major, minor, err := DeviceNumber(string(name)); err == nil {
+				if idx := slices.IndexFunc(pvs, func(pv *PhysicalVolume) bool {
+					return pv.Major == major && pv.Minor == minor
+				}); idx >= 0 {
+					name = pvs[idx].Name
+				}
+			}
+		}
+		if !slices.Contains(resolved, name) {
+			resolved = append(resolved, name)
+		}
+	}
+	return resolved
+}
+
+// SplitSpanningLogicalVolumes returns the top-level logical volumes that are allocated on some of the moving
+// physical volumes as well as on physical volumes that are not moving, and therefore cannot be split.
+// Snapshots and their origins have to stay in the same volume group, so they are considered together.
+// Thin volumes do not reference physical volumes directly and are only covered through their thin pool.
+// The segments need to include internal logical volumes, see ShowInternal.
+// The moving physical volumes need to be named as reported by lvm2, see ResolvePhysicalVolumeNames.
+func SplitSpanningLogicalVolumes(segments []*LVSegment, lvs []*LogicalVolume, moving PhysicalVolumeNames) []LogicalVolumeName {
+	graph := newSegmentGraph(segments)
+
+	// snapshots are grouped with their origin, following chains of snapshots of snapshots.
+	origins := make(map[LogicalVolumeName]LogicalVolumeName)
+	for _, lv := range lvs {
+		if lv.Origin != "" {
+			origins[internalLogicalVolumeName(string(lv.Name))] = internalLogicalVolumeName(lv.Origin)
+		}
+	}
+	groupOf := func(lv LogicalVolumeName) LogicalVolumeName {
+		for visited := 0; visited < len(origins); visited++ {
+			origin, ok := origins[lv]
+			if !ok {
+				break
+			}
+			lv = origin
+		}
+		return lv
+	}
+
+	type placement struct{ onMoving, onStaying bool }
+	groups := make(map[LogicalVolumeName]*placement)
+	for _, lv := range graph.order {
+		if !graph.isTopLevel(lv) {
+			continue
+		}
+		group := groupOf(lv)
+		if groups[group] == nil {
+			groups[group] = &placement{}
+		}
+		for _, pv := range graph.physicalVolumes(lv, nil) {
+			if slices.Contains(moving, pv) {
+				groups[group].onMoving = true
+			} else {
+				groups[group].onStaying = true
+			}
+		}
+	}
+
+	var spanning []LogicalVolumeName
+	for _, lv := range graph.order {
+		if !graph.isTopLevel(lv) {
+			continue
+		}
+		if group := groups[groupOf(lv)]; group.onMoving && group.onStaying {
+			spanning = append(spanning, lv)
+		}
+	}
+	return spanning
+}
@@ -0,0 +1,208 @@
+/*
+ Copyright 2024 The lvm2go Authors.
+
+ Licensed under the Apache License, Version 2.0 (the "License");
+ you may not use this file except in compliance with the License.
+ You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+ Unless required by applicable law or agreed to in writing, software
+ distributed under the License is distributed on an "AS IS" BASIS,
+ WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ See the License for the specific language governing permissions and
+ limitations under the License.
+*/
+
+package lvm2go_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	. "github.com/jakobmoellerdev/lvm2go"
+)
+
+func TestVGSplitMergeOptions(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name string
+		opts ArgumentGenerator
+		args []string
+		err  bool
+	}{
+		{
+			"split by pv",
+			VGSplitOptionsList{VolumeGroupName("vg"), VolumeGroupName("vg-new"), PhysicalVolumeName("/dev/sdb")},
+			[]string{"vg", "vg-new", "/dev/sdb", "--yes"},
+			false,
+		},
+		{
+			"split by lv",
+			VGSplitOptionsList{VolumeGroupName("vg"), VolumeGroupName("vg-new"), LogicalVolumeName("lv")},
+			[]string{"--name=lv", "vg", "vg-new", "--yes"},
+			false,
+		},
+		{"split without destination", VGSplitOptionsList{VolumeGroupName("vg"), PhysicalVolumeName("/dev/sdb")}, nil, true},
+		{"split without pvs or lv", VGSplitOptionsList{VolumeGroupName("vg"), VolumeGroupName("vg-new")}, nil, true},
+		{
+			"split with pvs and lv",
+			VGSplitOptionsList{VolumeGroupName("vg"), VolumeGroupName("vg-new"), PhysicalVolumeName("/dev/sdb"), LogicalVolumeName("lv")},
+			nil,
+			true,
+		},
+		{"merge", VGMergeOptionsList{VolumeGroupName("vg"), VolumeGroupName("vg-old")}, []string{"vg", "vg-old", "--yes"}, false},
+		{"merge without source", VGMergeOptionsList{VolumeGroupName("vg")}, nil, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			args, err := tc.opts.AsArgs()
+			if tc.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(args.GetRaw(), tc.args) {
+				t.Fatalf("expected args %v, got %v", tc.args, args.GetRaw())
+			}
+		})
+	}
+}
+
+func TestSplitSpanningLogicalVolumes(t *testing.T) {
+	t.Parallel()
+	seg := func(lv string, devices ...string) *LVSegment {
+		segment := &LVSegment{LogicalVolumeName: LogicalVolumeName(lv)}
+		for _, dev := range devices {
+			segment.Devices = append(segment.Devices, SegDevice{Device: dev})
+		}
+		return segment
+	}
+	withMetadata := func(segment *LVSegment, devices ...string) *LVSegment {
+		for _, dev := range devices {
+			segment.MetadataDevices = append(segment.MetadataDevices, SegDevice{Device: dev})
+		}
+		return segment
+	}
+	segments := []*LVSegment{
+		seg("linear", "/dev/sdb"),
+		seg("spanned", "/dev/sdb"),
+		seg("spanned", "/dev/sdc"),
+		withMetadata(seg("raid", "[raid_rimage_0]", "[raid_rimage_1]"), "[raid_rmeta_0]", "[raid_rmeta_1]"),
+		seg("[raid_rimage_0]", "/dev/sdb"),
+		seg("[raid_rimage_1]", "/dev/sdd"),
+		seg("[raid_rmeta_0]", "/dev/sdb"),
+		seg("[raid_rmeta_1]", "/dev/sdd"),
+		withMetadata(seg("pool", "[pool_tdata]"), "[pool_tmeta]"),
+		seg("[pool_tdata]", "/dev/sdc"),
+		seg("[pool_tmeta]", "/dev/sdc"),
+		withMetadata(seg("split", "[split_tdata]"), "[split_tmeta]"),
+		seg("[split_tdata]", "/dev/sdc"),
+		seg("[split_tmeta]", "/dev/sdb"),
+		seg("[lvol0_pmspare]", "/dev/sdd"),
+		seg("thin"),
+		seg("origin", "/dev/sdb"),
+		seg("snap", "/dev/sdc"),
+	}
+	lvs := []*LogicalVolume{
+		{Name: "origin"},
+		{Name: "snap", Origin: "origin"},
+	}
+
+	for _, tc := range []struct {
+		name     string
+		moving   PhysicalVolumeNames
+		spanning []LogicalVolumeName
+	}{
+		{"first device", PhysicalVolumeNames{"/dev/sdb"}, []LogicalVolumeName{"spanned", "raid", "split", "origin", "snap"}},
+		{"second device", PhysicalVolumeNames{"/dev/sdc"}, []LogicalVolumeName{"spanned", "split", "origin", "snap"}},
+		{"all devices", PhysicalVolumeNames{"/dev/sdb", "/dev/sdc", "/dev/sdd"}, nil},
+		{"raid devices", PhysicalVolumeNames{"/dev/sdb", "/dev/sdd"}, []LogicalVolumeName{"spanned", "split", "origin", "snap"}},
+		{"snapshot with origin", PhysicalVolumeNames{"/dev/sdb", "/dev/sdc"}, []LogicalVolumeName{"raid"}},
+		{"pool metadata on other device", PhysicalVolumeNames{"/dev/sdc", "/dev/sdd"}, []LogicalVolumeName{"spanned", "raid", "split", "origin", "snap"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := SplitSpanningLogicalVolumes(segments, lvs, tc.moving); !slices.Equal(got, tc.spanning) {
+				t.Fatalf("expected spanning logical volumes %v, got %v", tc.spanning, got)
+			}
+		})
+	}
+
+	if pvs := SegmentPhysicalVolumes(segments, "raid"); !slices.Equal(pvs, PhysicalVolumeNames{"/dev/sdb", "/dev/sdd"}) {
+		t.Fatalf("unexpected physical volumes for raid: %v", pvs)
+	}
+	if pvs := SegmentPhysicalVolumes(segments, "split"); !slices.Equal(pvs, PhysicalVolumeNames{"/dev/sdc", "/dev/sdb"}) {
+		t.Fatalf("unexpected physical volumes for split: %v", pvs)
+	}
+}
+
+func TestResolvePhysicalVolumeNames(t *testing.T) {
+	t.Parallel()
+	pvs := []*PhysicalVolume{{Name: "/dev/sdb", Major: 8, Minor: 16}}
+	if got := ResolvePhysicalVolumeNames(PhysicalVolumeNames{"/dev/sdb", "/dev/unknown"}, pvs); !slices.Equal(got, PhysicalVolumeNames{"/dev/sdb", "/dev/unknown"}) {
+		t.Fatalf("expected names to be kept, got %v", got)
+	}
+
+	major, minor, err := DeviceNumber("/dev/null")
+	if err != nil {
+		t.Skipf("cannot read device number of an alias: %v", err)
+	}
+	pvs = append(pvs, &PhysicalVolume{Name: "/dev/sdc", Major: major, Minor: minor})
+	if got := ResolvePhysicalVolumeNames(PhysicalVolumeNames{"/dev/null", "/dev/sdc"}, pvs); !slices.Equal(got, PhysicalVolumeNames{"/dev/sdc"}) {
+		t.Fatalf("expected alias to resolve to /dev/sdc, got %v", got)
+	}
+}
+
+func TestVGSplitMerge(t *testing.T) {
+	t.Parallel()
+	SkipOrFailTestIfNotRoot(t)
+
+	clnt := NewClient()
+	ctx := context.Background()
+
+	test := test{
+		LoopDevices: []Size{
+			MustParseSize("10M"),
+			MustParseSize("10M"),
+		},
+		Volumes: []TestLogicalVolume{{
+			Options: LVCreateOptionList{
+				MustParseExtents("100%FREE"),
+			},
+		}},
+	}
+
+	infra := test.SetupDevicesAndVolumeGroup(t)
+	source := infra.volumeGroup.Name
+	destination := source + "-split"
+	pv := PhysicalVolumeName(infra.loopDevices.Devices()[1])
+
+	err := clnt.VGSplit(ctx, source, destination, pv)
+	var spanErr *SplitSpanError
+	if !errors.As(err, &spanErr) || !errors.Is(err, ErrLogicalVolumesSpanSplit) {
+		t.Fatalf("expected split to be refused, got %v", err)
+	}
+	if !slices.Equal(spanErr.LogicalVolumes, []LogicalVolumeName{infra.lvs[0].LogicalVolumeName()}) {
+		t.Fatalf("unexpected spanning logical volumes %v", spanErr.LogicalVolumes)
+	}
+
+	if err := clnt.LVRemove(ctx, source, infra.lvs[0].LogicalVolumeName()); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.VGSplit(ctx, source, destination, pv); err != nil {
+		t.Fatal(err)
+	}
+	if err := clnt.VGMerge(ctx, source, destination); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := clnt.VG(ctx, destination); !errors.Is(err, ErrVolumeGroupNotFound) {
+		t.Fatalf("expected volume group %s to be merged", destination)
+	}
+}
@@ -115,6 +115,17 @@ func (vg *VolumeGroup) UnmarshalJSON(data []byte) error {
 	return unmarshalToStringAndParse(raw, "vg_attr", &vg.Attr, ParseVGAttributes)
 }
 
+// IsShared returns true if the volume group is a shared volume group managed by lvmlockd.
+func (vg *VolumeGroup) IsShared() bool {
+	return LockType(vg.LockType).IsShared()
+}
+
+// IsForeign returns true if the volume group is owned by a host with a different system ID.
+// Volume groups without a system ID are never foreign.
+func (vg *VolumeGroup) IsForeign(host SystemID) bool {
+	return vg.SysID != "" && SystemID(vg.SysID) != host
+}
+
 type VolumeGroupName string
 
 var _ Argument = VolumeGroupName("")
@@ -165,6 +176,27 @@ func (opt VolumeGroupName) ApplyToLVResizeOptions(opts *LVResizeOptions) {
 func (opt VolumeGroupName) ApplyToLVReduceOptions(opts *LVReduceOptions) {
 	opts.VolumeGroupName = opt
 }
+func (opt VolumeGroupName) ApplyToFullReportOptions(opts *FullReportOptions) {
+	opts.VolumeGroupName = opt
+}
+func (opt VolumeGroupName) ApplyToLVPollOptions(opts *LVPollOptions) {
+	opts.VolumeGroupName = opt
+}
+func (opt VolumeGroupName) ApplyToVGExportOptions(opts *VGExportOptions) {
+	opts.VolumeGroupName = opt
+}
+func (opt VolumeGroupName) ApplyToVGImportOptions(opts *VGImportOptions) {
+	opts.VolumeGroupName = opt
+}
+func (opt VolumeGroupName) ApplyToVGSplitOptions(opts *VGSplitOptions) {
+	opts.SetSourceOrDestination(opt)
+}
+func (opt VolumeGroupName) ApplyToVGMergeOptions(opts *VGMergeOptions) {
+	opts.SetDestinationOrSource(opt)
+}
+func (opt VolumeGroupName) ApplyToVGCheckOptions(opts *VGCheckOptions) {
+	opts.VolumeGroupName = opt
+}
 func (opt VolumeGroupName) ApplyToPVsOptions(opts *PVsOptions) {
 	opts.Select = NewMatchesAllSelect(opts.Select, NewMatchesAllSelector(map[string]string{"vg_name": string(opt)}))
 }
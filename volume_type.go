@@ -27,6 +27,7 @@ const (
 	TypeStriped    Type = "striped"
 	TypeMirrored   Type = "mirrored"
 	TypeRAID0      Type = "raid0"
+	TypeRAID0Meta  Type = "raid0_meta"
 	TypeRAID1      Type = "raid1"
 	TypeRAID4      Type = "raid4"
 	TypeRAID5      Type = "raid5"